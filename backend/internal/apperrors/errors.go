@@ -0,0 +1,131 @@
+// Package apperrors defines the errors services return to handlers. Each
+// error carries the HTTP status, machine-readable code and client-facing
+// message it is rendered with, so handlers only pass errors on and
+// middleware.ErrorHandler turns them into a utils.ErrorResponse.
+//
+// Services return the sentinels below, optionally wrapping the underlying
+// cause; callers test for them with errors.Is. Any other error is reported to
+// the client as ErrInternal and logged with its full chain.
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Violation is one rule that rejected the input, such as a password policy
+// rule. Rule is a stable identifier the frontend can key on.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error with everything needed to render it.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	// Details adds context to the message, such as which field failed
+	// validation.
+	Details string
+	// Violations lists every rule the input broke, for errors that can have
+	// several causes at once.
+	Violations []Violation
+	// RetryAfter, when set, is sent as the Retry-After header.
+	RetryAfter time.Duration
+
+	cause error
+	// base is the sentinel this error was derived from, so errors.Is still
+	// matches after Wrap or WithRetryAfter.
+	base *Error
+}
+
+// New defines a sentinel error.
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.cause)
+	}
+	return e.Message
+}
+
+// Unwrap returns the wrapped cause, if any.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is e or the sentinel e was derived from.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e == t || (e.base != nil && e.base == t)
+}
+
+// Wrap returns a copy of e that records cause.
+func (e *Error) Wrap(cause error) *Error {
+	derived := e.derive()
+	derived.cause = cause
+	return derived
+}
+
+// WithDetails returns a copy of e with the given details.
+func (e *Error) WithDetails(details string) *Error {
+	derived := e.derive()
+	derived.Details = details
+	return derived
+}
+
+// WithViolations returns a copy of e listing the broken rules.
+func (e *Error) WithViolations(violations []Violation) *Error {
+	derived := e.derive()
+	derived.Violations = violations
+	return derived
+}
+
+// WithRetryAfter returns a copy of e that tells the client when to retry.
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	derived := e.derive()
+	derived.RetryAfter = d
+	return derived
+}
+
+func (e *Error) derive() *Error {
+	derived := *e
+	if derived.base == nil {
+		derived.base = e
+	}
+	return &derived
+}
+
+// Validation reports input rejected by the validators package; the
+// validator's message is shown to the client.
+func Validation(err error) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: "VALIDATION_ERROR", Message: err.Error(), cause: err, base: ErrValidation}
+}
+
+// InvalidRequest reports a request body that could not be bound.
+func InvalidRequest(err error) *Error {
+	return ErrValidation.WithDetails(err.Error())
+}
+
+// Internal wraps an unexpected failure. The cause is logged but never shown
+// to the client.
+func Internal(cause error) *Error {
+	return ErrInternal.Wrap(cause)
+}
+
+// Code returns the code err is reported to the client with.
+func Code(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return ErrInternal.Code
+}
@@ -0,0 +1,112 @@
+package apperrors
+
+import "net/http"
+
+// General errors.
+var (
+	ErrInternal         = New(http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+	ErrValidation       = New(http.StatusBadRequest, "VALIDATION_ERROR", "Validation failed")
+	ErrNotAuthenticated = New(http.StatusUnauthorized, "NOT_AUTHENTICATED", "User not authenticated")
+	ErrInvalidUserID    = New(http.StatusBadRequest, "INVALID_USER_ID", "Invalid user ID")
+	ErrUserNotFound     = New(http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+)
+
+// Registration and profile errors.
+var (
+	ErrEmailExists     = New(http.StatusConflict, "EMAIL_EXISTS", "An account with this email already exists")
+	ErrUsernameExists  = New(http.StatusConflict, "USERNAME_EXISTS", "This username is already taken")
+	ErrInvalidPassword = New(http.StatusUnauthorized, "INVALID_PASSWORD", "Password is incorrect")
+	ErrWeakPassword    = New(http.StatusBadRequest, "WEAK_PASSWORD", "Password does not meet the requirements")
+)
+
+// Login and token errors.
+var (
+	ErrInvalidCredentials  = New(http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid username or password")
+	ErrAccountLocked       = New(http.StatusLocked, "ACCOUNT_LOCKED", "Account temporarily locked due to too many failed login attempts")
+	ErrTooManyAttempts     = New(http.StatusTooManyRequests, "TOO_MANY_ATTEMPTS", "Too many login attempts, please try again later")
+	ErrInvalidRefreshToken = New(http.StatusUnauthorized, "INVALID_REFRESH_TOKEN", "Invalid refresh token")
+	ErrRefreshTokenExpired = New(http.StatusUnauthorized, "REFRESH_TOKEN_EXPIRED", "Refresh token has expired")
+	ErrRefreshTokenReused  = New(http.StatusUnauthorized, "REFRESH_TOKEN_REUSED", "Refresh token has already been used")
+)
+
+// Request authentication and authorization errors, reported by the
+// middleware guarding protected routes.
+var (
+	ErrAuthorizationRequired = New(http.StatusUnauthorized, "AUTHORIZATION_REQUIRED", "Authorization header required")
+	ErrBearerTokenRequired   = New(http.StatusUnauthorized, "BEARER_TOKEN_REQUIRED", "Bearer token required")
+	ErrInvalidToken          = New(http.StatusUnauthorized, "INVALID_TOKEN", "Invalid token")
+	ErrTokenRevoked          = New(http.StatusUnauthorized, "TOKEN_REVOKED", "Token has been revoked")
+	ErrSessionRevoked        = New(http.StatusUnauthorized, "SESSION_REVOKED", "Session has been revoked")
+	ErrForbidden             = New(http.StatusForbidden, "FORBIDDEN", "Insufficient permissions")
+	ErrAPIKeyNotAllowed      = New(http.StatusForbidden, "FORBIDDEN", "API keys cannot be used for this endpoint")
+	ErrMissingScope          = New(http.StatusForbidden, "FORBIDDEN", "API key is missing the required scope")
+	ErrEmailNotVerified      = New(http.StatusForbidden, "EMAIL_NOT_VERIFIED", "Please verify your email address to continue")
+)
+
+// Two-factor authentication errors. ErrInvalidMFALoginCode is the login-step
+// variant of ErrInvalidMFACode and is reported as 401 rather than 400.
+var (
+	ErrInvalidMFAToken         = New(http.StatusUnauthorized, "INVALID_MFA_TOKEN", "Invalid or expired MFA token, please log in again")
+	ErrInvalidMFACode          = New(http.StatusBadRequest, "INVALID_MFA_CODE", "Invalid authentication code")
+	ErrInvalidMFALoginCode     = New(http.StatusUnauthorized, "INVALID_MFA_CODE", "Invalid authentication code")
+	ErrMFAAlreadyEnabled       = New(http.StatusConflict, "MFA_ALREADY_ENABLED", "Two-factor authentication is already enabled")
+	ErrMFANotEnabled           = New(http.StatusConflict, "MFA_NOT_ENABLED", "Two-factor authentication is not enabled")
+	ErrMFAEnrollmentNotStarted = New(http.StatusBadRequest, "MFA_ENROLLMENT_NOT_STARTED", "Start enrollment before confirming")
+)
+
+// Email verification errors.
+var (
+	ErrInvalidVerificationToken = New(http.StatusBadRequest, "INVALID_VERIFICATION_TOKEN", "Invalid verification token")
+	ErrVerificationTokenExpired = New(http.StatusBadRequest, "VERIFICATION_TOKEN_EXPIRED", "Verification link has expired")
+	ErrEmailAlreadyVerified     = New(http.StatusConflict, "EMAIL_ALREADY_VERIFIED", "Email is already verified")
+	ErrVerificationThrottled    = New(http.StatusTooManyRequests, "VERIFICATION_THROTTLED", "Please wait before requesting another verification email")
+)
+
+// Password reset errors.
+var (
+	ErrInvalidResetToken = New(http.StatusBadRequest, "INVALID_RESET_TOKEN", "Invalid or already used reset token")
+	ErrResetTokenExpired = New(http.StatusBadRequest, "RESET_TOKEN_EXPIRED", "Reset token has expired")
+)
+
+// Magic link errors. Expired, used and superseded links are not told apart.
+var (
+	ErrInvalidMagicLink = New(http.StatusUnauthorized, "INVALID_MAGIC_LINK", "Sign-in link is invalid or has expired, please request a new one")
+)
+
+// Session and role errors.
+var (
+	ErrInvalidSessionID = New(http.StatusBadRequest, "INVALID_SESSION_ID", "Invalid session ID")
+	ErrSessionNotFound  = New(http.StatusNotFound, "SESSION_NOT_FOUND", "Session not found")
+	ErrUnknownRole      = New(http.StatusBadRequest, "UNKNOWN_ROLE", "Unknown role")
+)
+
+// API key errors.
+var (
+	ErrInvalidAPIKey       = New(http.StatusUnauthorized, "INVALID_API_KEY", "Invalid API key")
+	ErrAPIKeyExpired       = New(http.StatusUnauthorized, "API_KEY_EXPIRED", "API key has expired")
+	ErrInvalidAPIKeyID     = New(http.StatusBadRequest, "INVALID_API_KEY_ID", "Invalid API key ID")
+	ErrAPIKeyNotFound      = New(http.StatusNotFound, "API_KEY_NOT_FOUND", "API key not found")
+	ErrUnknownScope        = New(http.StatusBadRequest, "UNKNOWN_SCOPE", "Unknown API key scope")
+	ErrScopeNotGranted     = New(http.StatusForbidden, "SCOPE_NOT_GRANTED", "The account does not hold the permission for this scope")
+	ErrInvalidAPIKeyExpiry = New(http.StatusBadRequest, "INVALID_API_KEY_EXPIRY", "API key expiry must be in the future")
+)
+
+// Social login errors.
+var (
+	ErrUnknownProvider          = New(http.StatusNotFound, "UNKNOWN_PROVIDER", "Unknown sign-in provider")
+	ErrInvalidOAuthState        = New(http.StatusBadRequest, "INVALID_OAUTH_STATE", "Sign-in request is invalid or has expired, please try again")
+	ErrProviderError            = New(http.StatusBadGateway, "PROVIDER_ERROR", "Could not complete sign-in with the provider")
+	ErrProviderEmailNotVerified = New(http.StatusForbidden, "PROVIDER_EMAIL_NOT_VERIFIED", "The provider did not confirm a verified email address")
+	ErrProviderEmailInvalid     = New(http.StatusForbidden, "PROVIDER_EMAIL_INVALID", "The provider returned an invalid email address")
+	ErrAccountEmailNotVerified  = New(http.StatusConflict, "ACCOUNT_EMAIL_NOT_VERIFIED", "An account with this email already exists; verify its email before signing in with this provider")
+)
+
+// Product errors.
+var (
+	ErrProductsUnavailable   = New(http.StatusBadGateway, "PRODUCTS_UNAVAILABLE", "Failed to fetch products")
+	ErrCategoriesUnavailable = New(http.StatusBadGateway, "CATEGORIES_UNAVAILABLE", "Failed to fetch categories")
+	ErrInvalidProductID      = New(http.StatusBadRequest, "INVALID_PRODUCT_ID", "Invalid product ID")
+	ErrProductNotFound       = New(http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found")
+	ErrInvalidPriceRange     = New(http.StatusBadRequest, "INVALID_PRICE_RANGE", "Invalid price range")
+	ErrProductQueryTooBroad  = New(http.StatusUnprocessableEntity, "PRODUCT_QUERY_TOO_BROAD", "Too many products match, narrow the search or category")
+)
@@ -0,0 +1,17 @@
+// Package cache provides byte-oriented key-value stores with per-entry
+// expiry, used to cache responses that are expensive to produce.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store is a key-value cache. Entries expire after the ttl given to Set;
+// a store may also evict entries earlier to stay within its capacity.
+type Store interface {
+	// Get returns the value stored under key, or false if there is none.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl, replacing any earlier value.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
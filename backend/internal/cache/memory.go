@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryStore is an in-process Store that evicts the least recently used
+// entry once it holds capacity entries. Entries are lost on restart and not
+// shared between instances.
+type MemoryStore struct {
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// recent orders the entries from most to least recently used.
+	recent *list.List
+}
+
+func NewMemoryStore(capacity int) *MemoryStore {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &MemoryStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		recent:   list.New(),
+	}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*memoryEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		s.remove(element)
+		return nil, false, nil
+	}
+	s.recent.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+func (s *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if element, ok := s.entries[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		s.recent.MoveToFront(element)
+		return nil
+	}
+
+	s.entries[key] = s.recent.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+	for s.recent.Len() > s.capacity {
+		s.remove(s.recent.Back())
+	}
+	return nil
+}
+
+// Len returns the number of entries held, including expired entries that
+// have not been looked up since they expired.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.recent.Len()
+}
+
+func (s *MemoryStore) remove(element *list.Element) {
+	s.recent.Remove(element)
+	delete(s.entries, element.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,318 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBulkLength bounds the size of a single value read from Redis.
+const maxBulkLength = 64 << 20
+
+// RedisConfig configures a RedisStore.
+type RedisConfig struct {
+	// Addr is the host:port of the server.
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	TLS      bool
+	// Timeout limits connecting and each command, unless the context of the
+	// call has an earlier deadline.
+	Timeout time.Duration
+	// PoolSize is how many idle connections are kept for reuse.
+	PoolSize int
+}
+
+// ParseRedisURL reads a redis://[user:password@]host[:port][/db] URL. The
+// rediss scheme connects with TLS.
+func ParseRedisURL(raw string) (RedisConfig, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return RedisConfig{}, err
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return RedisConfig{}, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return RedisConfig{}, errors.New("missing host")
+	}
+
+	config := RedisConfig{
+		Addr:     u.Host,
+		TLS:      u.Scheme == "rediss",
+		Timeout:  time.Second,
+		PoolSize: 10,
+	}
+	if u.Port() == "" {
+		config.Addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		config.Username = u.User.Username()
+		config.Password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		config.DB, err = strconv.Atoi(db)
+		if err != nil || config.DB < 0 {
+			return RedisConfig{}, fmt.Errorf("invalid database %q", db)
+		}
+	}
+	return config, nil
+}
+
+// RedisError is an error reply from the server.
+type RedisError string
+
+func (e RedisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// RedisStore is a Store backed by a Redis server, speaking just enough of
+// the RESP protocol to get and set values.
+type RedisStore struct {
+	config RedisConfig
+	idle   chan *redisConn
+}
+
+func NewRedisStore(config RedisConfig) *RedisStore {
+	if config.PoolSize < 1 {
+		config.PoolSize = 1
+	}
+	return &RedisStore{
+		config: config,
+		idle:   make(chan *redisConn, config.PoolSize),
+	}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := s.do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected reply %v to GET", reply)
+	}
+	return value, true, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	millis := ttl.Milliseconds()
+	if millis < 1 {
+		millis = 1
+	}
+	_, err := s.do(ctx, "SET", key, value, "PX", strconv.FormatInt(millis, 10))
+	return err
+}
+
+// Ping checks that the server is reachable.
+func (s *RedisStore) Ping(ctx context.Context) error {
+	_, err := s.do(ctx, "PING")
+	return err
+}
+
+// Close closes the idle connections.
+func (s *RedisStore) Close() error {
+	for {
+		select {
+		case conn := <-s.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends a command and reads its reply. Error replies leave the
+// connection usable; any other failure discards it.
+func (s *RedisStore) do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	conn, err := s.conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("redis: failed to connect: %w", err)
+	}
+
+	reply, err := conn.do(ctx, s.config.Timeout, args...)
+	var replyErr RedisError
+	if err != nil && !errors.As(err, &replyErr) {
+		conn.Close()
+		return nil, err
+	}
+	s.release(conn)
+	return reply, err
+}
+
+func (s *RedisStore) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-s.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: s.config.Timeout}
+	var netConn net.Conn
+	var err error
+	if s.config.TLS {
+		host, _, _ := net.SplitHostPort(s.config.Addr)
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}}
+		netConn, err = tlsDialer.DialContext(ctx, "tcp", s.config.Addr)
+	} else {
+		netConn, err = dialer.DialContext(ctx, "tcp", s.config.Addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &redisConn{Conn: netConn, reader: bufio.NewReader(netConn)}
+	if s.config.Password != "" {
+		args := []interface{}{"AUTH", s.config.Password}
+		if s.config.Username != "" {
+			args = []interface{}{"AUTH", s.config.Username, s.config.Password}
+		}
+		if _, err := conn.do(ctx, s.config.Timeout, args...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if s.config.DB != 0 {
+		if _, err := conn.do(ctx, s.config.Timeout, "SELECT", strconv.Itoa(s.config.DB)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (s *RedisStore) release(conn *redisConn) {
+	select {
+	case s.idle <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *redisConn) do(ctx context.Context, timeout time.Duration, args ...interface{}) (interface{}, error) {
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	if _, err := c.Write(encodeCommand(args)); err != nil {
+		return nil, err
+	}
+	return readReply(c.reader)
+}
+
+// encodeCommand encodes a command as a RESP array of bulk strings.
+func encodeCommand(args []interface{}) []byte {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		var value []byte
+		switch arg := arg.(type) {
+		case string:
+			value = []byte(arg)
+		case []byte:
+			value = arg
+		default:
+			value = []byte(fmt.Sprint(arg))
+		}
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(value)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, value...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
+
+// readReply reads one RESP reply: a string for simple strings, int64 for
+// integers, []byte for bulk strings, []interface{} for arrays, nil for null
+// replies, and a RedisError for error replies.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	payload := string(line[1:])
+	switch line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, RedisError(payload)
+	case ':':
+		n, err := strconv.ParseInt(payload, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid integer reply %q", payload)
+		}
+		return n, nil
+	case '$':
+		length, err := strconv.Atoi(payload)
+		if err != nil || length > maxBulkLength {
+			return nil, fmt.Errorf("redis: invalid bulk length %q", payload)
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		value := make([]byte, length+2)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, err
+		}
+		return value[:length], nil
+	case '*':
+		count, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid array length %q", payload)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			item, err := readReply(r)
+			var replyErr RedisError
+			if err != nil && !errors.As(err, &replyErr) {
+				return nil, err
+			}
+			if err != nil {
+				item = replyErr
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply type %q", line[0])
+	}
+}
+
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, errors.New("redis: malformed reply line")
+	}
+	return line[:len(line)-2], nil
+}
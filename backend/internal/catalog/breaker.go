@@ -0,0 +1,67 @@
+package catalog
+
+import (
+	"sync"
+	"time"
+)
+
+// breaker is a circuit breaker. After threshold consecutive failures it
+// opens and rejects requests for cooldown; then it lets a single trial
+// request through, which closes it again on success or reopens it on
+// failure.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a request may be sent. When it may not, it returns
+// how long until the breaker lets a trial request through.
+func (b *breaker) allow() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return 0, true
+	}
+	if wait := b.cooldown - time.Since(b.openedAt); wait > 0 {
+		return wait, false
+	}
+	if b.probing {
+		return 0, false
+	}
+	b.probing = true
+	return 0, true
+}
+
+// record reports the outcome of a request that allow let through.
+func (b *breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
+
+// abandon reports a request that ended without telling anything about the
+// upstream, such as one the client cancelled.
+func (b *breaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
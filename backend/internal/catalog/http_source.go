@@ -0,0 +1,474 @@
+// Package catalog provides product sources other than the shop's own
+// database.
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// maxResponseSize bounds how much of an upstream response is read.
+const maxResponseSize = 10 << 20
+
+// scanPageSize is how many products are fetched per request while scanning
+// the upstream.
+const scanPageSize = 100
+
+// maxScannedProducts bounds a scan; queries the upstream matches more
+// products for are rejected.
+const maxScannedProducts = 5000
+
+// maxCachedScans bounds how many scans are kept for ScanCacheTTL.
+const maxCachedScans = 100
+
+// maxRetryAfter is the longest Retry-After the source waits for; an upstream
+// asking for more is treated as unavailable.
+const maxRetryAfter = 5 * time.Second
+
+// HTTPConfig configures an HTTPSource.
+type HTTPConfig struct {
+	// BaseURL is the root of a dummyjson.com compatible products API.
+	BaseURL string
+	// Timeout limits each attempt, including reading the response.
+	Timeout time.Duration
+	// MaxRetries is how many times a failed request is retried.
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry; it doubles with
+	// every further retry.
+	RetryBackoff time.Duration
+	// BreakerThreshold is how many requests in a row must fail before the
+	// upstream is given a rest of BreakerCooldown.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// ScanCacheTTL is how long the products a scan matched are kept, so the
+	// other pages of the same query are cut from them instead of scanning
+	// again. Zero disables it.
+	ScanCacheTTL time.Duration
+}
+
+func DefaultHTTPConfig() HTTPConfig {
+	return HTTPConfig{
+		BaseURL:          "https://dummyjson.com",
+		Timeout:          5 * time.Second,
+		MaxRetries:       2,
+		RetryBackoff:     200 * time.Millisecond,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+		ScanCacheTTL:     time.Minute,
+	}
+}
+
+// statusError is an unexpected upstream response status.
+type statusError struct {
+	status     int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("upstream responded with status %d", e.status)
+}
+
+// retryable reports whether the upstream may answer differently next time.
+func (e *statusError) retryable() bool {
+	return e.status == http.StatusTooManyRequests || e.status >= http.StatusInternalServerError
+}
+
+// HTTPSource reads the catalog from an upstream products API.
+type HTTPSource struct {
+	config  HTTPConfig
+	client  *http.Client
+	breaker *breaker
+
+	scanCalls singleflight.Group
+	scanMu    sync.Mutex
+	scans     map[string]cachedScan
+}
+
+// cachedScan is the products a scan matched, in upstream order.
+type cachedScan struct {
+	matches   []models.Product
+	expiresAt time.Time
+}
+
+// NewHTTPSource creates a source for the API at config.BaseURL. A nil client
+// uses one with config.Timeout.
+func NewHTTPSource(config HTTPConfig, client *http.Client) *HTTPSource {
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	return &HTTPSource{
+		config:  config,
+		client:  client,
+		breaker: newBreaker(config.BreakerThreshold, config.BreakerCooldown),
+		scans:   map[string]cachedScan{},
+	}
+}
+
+// ListProducts fetches a page of products. The upstream searches or lists a
+// category but not both, and cannot filter by price; queries that need what
+// it cannot do are answered by scanning every product the upstream matches
+// and filtering them here.
+func (s *HTTPSource) ListProducts(ctx context.Context, query models.ProductQuery) (*models.ProductPage, error) {
+	var page *models.ProductPage
+	var err error
+	if query.PriceMin != nil || query.PriceMax != nil || (query.Search != "" && query.Category != "") {
+		page, err = s.scanProducts(ctx, query)
+	} else {
+		page, err = s.fetchProducts(ctx, query, query.Limit, query.Skip)
+	}
+	if err != nil {
+		if errors.Is(err, apperrors.ErrProductQueryTooBroad) {
+			return nil, err
+		}
+		return nil, apperrors.ErrProductsUnavailable.Wrap(err).WithRetryAfter(retryAfter(err))
+	}
+
+	page.Skip = query.Skip
+	page.Limit = query.Limit
+	if page.Products == nil {
+		page.Products = []models.Product{}
+	}
+	return page, nil
+}
+
+// fetchProducts fetches one upstream page of the products query selects,
+// ignoring the filters the upstream cannot apply.
+func (s *HTTPSource) fetchProducts(ctx context.Context, query models.ProductQuery, limit, skip int) (*models.ProductPage, error) {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("skip", strconv.Itoa(skip))
+	switch query.SortBy {
+	case models.ProductSortTitle, models.ProductSortPrice, models.ProductSortRating:
+		params.Set("sortBy", query.SortBy)
+		if query.SortDesc {
+			params.Set("order", "desc")
+		} else {
+			params.Set("order", "asc")
+		}
+	}
+
+	path := "/products"
+	if query.Search != "" {
+		path = "/products/search"
+		params.Set("q", query.Search)
+	} else if query.Category != "" {
+		path = "/products/category/" + url.PathEscape(query.Category)
+	}
+
+	var page models.ProductPage
+	if err := s.get(ctx, path, params, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// scanProducts cuts the requested window out of the products matching
+// query. The matches are shared by every page of the same query: they are
+// reused for ScanCacheTTL, and concurrent requests share one scan.
+func (s *HTTPSource) scanProducts(ctx context.Context, query models.ProductQuery) (*models.ProductPage, error) {
+	filters := query
+	filters.Skip, filters.Limit = 0, 0
+	encoded, err := json.Marshal(filters)
+	if err != nil {
+		return nil, err
+	}
+	key := string(encoded)
+
+	matches, ok := s.cachedScan(key)
+	if !ok {
+		// The scan is shared, so it must not end when this caller gives up.
+		results := s.scanCalls.DoChan(key, func() (interface{}, error) {
+			matches, err := s.scanMatches(context.WithoutCancel(ctx), filters)
+			if err == nil {
+				s.storeScan(key, matches)
+			}
+			return matches, err
+		})
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case result := <-results:
+			if result.Err != nil {
+				return nil, result.Err
+			}
+			matches = result.Val.([]models.Product)
+		}
+	}
+
+	total := len(matches)
+	from := min(query.Skip, total)
+	to := min(from+query.Limit, total)
+	return &models.ProductPage{Products: append([]models.Product(nil), matches[from:to]...), Total: int64(total)}, nil
+}
+
+func (s *HTTPSource) cachedScan(key string) ([]models.Product, bool) {
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	scan, ok := s.scans[key]
+	if !ok || time.Now().After(scan.expiresAt) {
+		return nil, false
+	}
+	return scan.matches, true
+}
+
+// storeScan keeps matches for ScanCacheTTL. When the cache is full, expired
+// scans are dropped first, then the one closest to expiring.
+func (s *HTTPSource) storeScan(key string, matches []models.Product) {
+	if s.config.ScanCacheTTL <= 0 {
+		return
+	}
+
+	s.scanMu.Lock()
+	defer s.scanMu.Unlock()
+
+	now := time.Now()
+	if len(s.scans) >= maxCachedScans {
+		var oldest string
+		for k, scan := range s.scans {
+			if now.After(scan.expiresAt) {
+				delete(s.scans, k)
+			} else if oldest == "" || scan.expiresAt.Before(s.scans[oldest].expiresAt) {
+				oldest = k
+			}
+		}
+		if len(s.scans) >= maxCachedScans {
+			delete(s.scans, oldest)
+		}
+	}
+	s.scans[key] = cachedScan{matches: matches, expiresAt: now.Add(s.config.ScanCacheTTL)}
+}
+
+// scanMatches pages through everything the upstream matches and keeps the
+// products that pass the remaining filters. The upstream sorts, so the
+// matches stay in order.
+func (s *HTTPSource) scanMatches(ctx context.Context, query models.ProductQuery) ([]models.Product, error) {
+	matches := []models.Product{}
+	for skip := 0; ; skip += scanPageSize {
+		if skip >= maxScannedProducts {
+			return nil, apperrors.ErrProductQueryTooBroad
+		}
+
+		page, err := s.fetchProducts(ctx, query, scanPageSize, skip)
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range page.Products {
+			if matchesFilters(product, query) {
+				matches = append(matches, product)
+			}
+		}
+		if len(page.Products) == 0 || int64(skip+len(page.Products)) >= page.Total {
+			return matches, nil
+		}
+	}
+}
+
+// matchesFilters applies the filters of query that the upstream does not:
+// the category of a search, and the price range.
+func matchesFilters(product models.Product, query models.ProductQuery) bool {
+	if query.Search != "" && query.Category != "" && product.Category != query.Category {
+		return false
+	}
+	if query.PriceMin != nil && product.Price < *query.PriceMin {
+		return false
+	}
+	if query.PriceMax != nil && product.Price > *query.PriceMax {
+		return false
+	}
+	return true
+}
+
+// upstreamProduct is a product as the upstream returns it on its own.
+type upstreamProduct struct {
+	models.Product
+	Reviews []struct {
+		Rating       int       `json:"rating"`
+		Comment      string    `json:"comment"`
+		Date         time.Time `json:"date"`
+		ReviewerName string    `json:"reviewerName"`
+	} `json:"reviews"`
+}
+
+// GetProduct fetches a product with its reviews, and the best rated products
+// of its category.
+func (s *HTTPSource) GetProduct(ctx context.Context, id int) (*models.ProductDetail, error) {
+	var upstream upstreamProduct
+	if err := s.get(ctx, "/products/"+strconv.Itoa(id), nil, &upstream); err != nil {
+		var status *statusError
+		if errors.As(err, &status) && status.status == http.StatusNotFound {
+			return nil, apperrors.ErrProductNotFound
+		}
+		return nil, apperrors.ErrProductsUnavailable.Wrap(err).WithRetryAfter(retryAfter(err))
+	}
+
+	detail := &models.ProductDetail{
+		Product:         upstream.Product,
+		RatingBreakdown: models.NewRatingBreakdown(),
+		Reviews:         []models.ProductReview{},
+		Related:         []models.Product{},
+	}
+	for _, review := range upstream.Reviews {
+		detail.RatingBreakdown.Add(review.Rating, 1)
+		detail.Reviews = append(detail.Reviews, models.ProductReview{
+			ProductID:    id,
+			Rating:       review.Rating,
+			Comment:      review.Comment,
+			ReviewerName: review.ReviewerName,
+			CreatedAt:    review.Date,
+		})
+	}
+	sort.SliceStable(detail.Reviews, func(i, j int) bool {
+		return detail.Reviews[i].CreatedAt.After(detail.Reviews[j].CreatedAt)
+	})
+	if len(detail.Reviews) > models.ProductDetailReviews {
+		detail.Reviews = detail.Reviews[:models.ProductDetailReviews]
+	}
+
+	// One more than needed, in case the product itself is among them.
+	related, err := s.fetchProducts(ctx, models.ProductQuery{
+		Category: detail.Category,
+		SortBy:   models.ProductSortRating,
+		SortDesc: true,
+	}, models.ProductDetailRelated+1, 0)
+	if err != nil {
+		return nil, apperrors.ErrProductsUnavailable.Wrap(err).WithRetryAfter(retryAfter(err))
+	}
+	for _, product := range related.Products {
+		if product.ID != id && len(detail.Related) < models.ProductDetailRelated {
+			detail.Related = append(detail.Related, product)
+		}
+	}
+
+	return detail, nil
+}
+
+// ListCategories fetches the category slugs.
+func (s *HTTPSource) ListCategories(ctx context.Context) ([]string, error) {
+	var categories []string
+	if err := s.get(ctx, "/products/category-list", nil, &categories); err != nil {
+		return nil, apperrors.ErrCategoriesUnavailable.Wrap(err).WithRetryAfter(retryAfter(err))
+	}
+	return categories, nil
+}
+
+// breakerOpenError is returned while the circuit breaker rejects requests.
+type breakerOpenError struct {
+	wait time.Duration
+}
+
+func (e *breakerOpenError) Error() string {
+	return "upstream is unavailable, not retrying for " + e.wait.Round(time.Second).String()
+}
+
+// retryAfter is how long a client should wait after err.
+func retryAfter(err error) time.Duration {
+	var open *breakerOpenError
+	if errors.As(err, &open) {
+		return open.wait
+	}
+	return 0
+}
+
+// get fetches path and decodes the JSON response into dest, retrying
+// failures that may be temporary.
+func (s *HTTPSource) get(ctx context.Context, path string, params url.Values, dest interface{}) error {
+	wait, ok := s.breaker.allow()
+	if !ok {
+		return &breakerOpenError{wait: wait}
+	}
+
+	target := s.config.BaseURL + path
+	if len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := s.fetch(ctx, target, dest)
+		if err == nil {
+			s.breaker.record(false)
+			return nil
+		}
+		if ctx.Err() != nil {
+			s.breaker.abandon()
+			return err
+		}
+
+		var status *statusError
+		if errors.As(err, &status) && !status.retryable() {
+			// The upstream is up; the request is wrong.
+			s.breaker.record(false)
+			return err
+		}
+
+		delay := s.backoff(attempt)
+		if status != nil && status.retryAfter > delay {
+			delay = status.retryAfter
+		}
+		if attempt == s.config.MaxRetries || delay > maxRetryAfter {
+			s.breaker.record(true)
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.breaker.abandon()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff is the wait before retry number attempt+1: RetryBackoff doubled
+// for every earlier retry, plus up to half of that again so that clients
+// do not retry in lockstep.
+func (s *HTTPSource) backoff(attempt int) time.Duration {
+	delay := s.config.RetryBackoff << attempt
+	if delay <= 0 {
+		return 0
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+func (s *HTTPSource) fetch(ctx context.Context, target string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize))
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return &statusError{status: resp.StatusCode, retryAfter: time.Duration(seconds) * time.Second}
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(dest); err != nil {
+		return fmt.Errorf("failed to decode upstream response: %w", err)
+	}
+	return nil
+}
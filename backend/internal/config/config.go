@@ -0,0 +1,502 @@
+// Package config reads the server configuration from the environment once at
+// startup. Load validates every value and reports all problems together, so
+// a misconfigured server refuses to start instead of failing later or, worse,
+// running with insecure defaults.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"mobile-shop-backend/internal/cache"
+	"mobile-shop-backend/internal/catalog"
+	"mobile-shop-backend/internal/database"
+	"mobile-shop-backend/internal/hashing"
+	"mobile-shop-backend/internal/mail"
+	"mobile-shop-backend/internal/oidc"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/signing"
+	"mobile-shop-backend/internal/validators"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minSecretLength is the shortest JWT_SECRET accepted: 32 bytes matches the
+// output size of the HMAC-SHA256 it keys.
+const minSecretLength = 32
+
+// placeholderSecrets are values copied from examples that must never be used.
+var placeholderSecrets = []string{
+	"secret",
+	"changeme",
+	"your-jwt-secret-key",
+	"your-secret-key",
+	"jwt-secret",
+}
+
+// placeholderDBPasswords are the development database passwords that must not
+// reach production, in addition to placeholderSecrets.
+var placeholderDBPasswords = []string{"password", "postgres"}
+
+var defaultCORSOrigins = []string{
+	"http://localhost:3000",
+	"http://localhost:5173",
+	"http://localhost:5174",
+	"http://34.142.218.188",
+	"http://34.142.218.188:8080",
+	"https://34-142-218-188.sslip.io",
+}
+
+var defaultTrustedProxies = []string{
+	"127.0.0.1",      // localhost
+	"::1",            // localhost IPv6
+	"10.0.0.0/8",     // private network
+	"172.16.0.0/12",  // private network
+	"192.168.0.0/16", // private network
+}
+
+// Email verification policies, see middleware.EmailVerificationPolicy.
+var emailVerificationPolicies = []string{"allow", "restrict", "block"}
+
+type Config struct {
+	Port        string
+	ReleaseMode bool
+	// AppURL is the public URL of the frontend, used to build links in emails.
+	AppURL string
+	// JWTSecret keys the HMAC of email verification links.
+	JWTSecret      []byte
+	CORSOrigins    []string
+	TrustedProxies []string
+
+	EmailVerificationPolicy string
+	LoginAttemptStore       string
+	BootstrapAdmin          string
+	// AccountDeletionGracePeriod is how long deleted accounts are kept
+	// before they are purged.
+	AccountDeletionGracePeriod time.Duration
+
+	Database        database.Config
+	Mail            mail.Config
+	Signing         signing.Config
+	Tokens          services.TokenConfig
+	PasswordHashing hashing.Argon2Params
+	PasswordPolicy  validators.PasswordPolicy
+	// PasswordBreachCorpus is the path of a local breach corpus new
+	// passwords are checked against; empty disables the check.
+	PasswordBreachCorpus string
+	OIDCProviders        []oidc.Config
+	// ProductSource is "database" for the shop's own catalog or "http" to
+	// proxy the API at ProductHTTP.BaseURL.
+	ProductSource string
+	ProductHTTP   catalog.HTTPConfig
+	// ProductCacheStore is "memory", "redis" (at Redis) or "none".
+	ProductCacheStore string
+	// ProductCacheSize is how many responses the memory store holds.
+	ProductCacheSize int
+	ProductCache     services.ProductCacheConfig
+	Redis            cache.RedisConfig
+}
+
+// Load reads and validates the configuration.
+func Load() (*Config, error) {
+	l := &loader{}
+
+	cfg := &Config{
+		Port:                       l.port("PORT", "8080"),
+		ReleaseMode:                os.Getenv("GIN_MODE") == "release",
+		AppURL:                     strings.TrimRight(l.url("APP_URL", "http://localhost:5173"), "/"),
+		JWTSecret:                  l.secret("JWT_SECRET"),
+		CORSOrigins:                l.origins("CORS_ORIGINS", defaultCORSOrigins),
+		TrustedProxies:             l.list("TRUSTED_PROXIES", defaultTrustedProxies),
+		EmailVerificationPolicy:    l.oneOf("EMAIL_VERIFICATION_POLICY", "restrict", emailVerificationPolicies...),
+		LoginAttemptStore:          l.oneOf("LOGIN_ATTEMPT_STORE", "postgres", "postgres", "memory"),
+		BootstrapAdmin:             os.Getenv("BOOTSTRAP_ADMIN"),
+		AccountDeletionGracePeriod: l.duration("ACCOUNT_DELETION_GRACE_PERIOD", services.DefaultAccountDeletionGracePeriod),
+		Database: database.Config{
+			URL:      os.Getenv("DATABASE_URL"),
+			Host:     getEnv("DB_HOST", "localhost"),
+			Port:     l.port("DB_PORT", "5432"),
+			User:     getEnv("DB_USER", "postgres"),
+			Password: getEnv("DB_PASSWORD", "password"),
+			Name:     getEnv("DB_NAME", "mobile_shop"),
+			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			TimeZone: getEnv("DB_TIMEZONE", "UTC"),
+		},
+		Mail: mail.Config{
+			Driver:       l.oneOf("MAIL_DRIVER", "log", "log", "smtp"),
+			From:         getEnv("MAIL_FROM", "no-reply@mobshop.local"),
+			SMTPHost:     os.Getenv("SMTP_HOST"),
+			SMTPUsername: os.Getenv("SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+			LogPath:      os.Getenv("MAIL_LOG_PATH"),
+		},
+		Signing: signing.Config{
+			SigningKeyFile:       os.Getenv("JWT_SIGNING_KEY_FILE"),
+			VerificationKeyFiles: l.list("JWT_VERIFICATION_KEY_FILES", nil),
+			Issuer:               getEnv("JWT_ISSUER", "mobile-shop"),
+			Audience:             getEnv("JWT_AUDIENCE", "mobile-shop-api"),
+		},
+		Tokens: services.TokenConfig{
+			AccessTokenTTL:  l.duration("ACCESS_TOKEN_TTL", services.DefaultTokenConfig().AccessTokenTTL),
+			RefreshTokenTTL: l.duration("REFRESH_TOKEN_TTL", services.DefaultTokenConfig().RefreshTokenTTL),
+			MFATokenTTL:     l.duration("MFA_TOKEN_TTL", services.DefaultTokenConfig().MFATokenTTL),
+			MagicLinkTTL:    l.duration("MAGIC_LINK_TTL", services.DefaultTokenConfig().MagicLinkTTL),
+		},
+	}
+
+	cfg.PasswordHashing = l.argon2Params()
+	cfg.PasswordPolicy = l.passwordPolicy()
+	cfg.PasswordBreachCorpus = os.Getenv("PASSWORD_BREACH_CORPUS")
+	if cfg.PasswordBreachCorpus != "" {
+		if _, err := os.Stat(cfg.PasswordBreachCorpus); err != nil {
+			l.fail("PASSWORD_BREACH_CORPUS must be a readable file: %v", err)
+		}
+	}
+
+	cfg.Mail.SMTPPort, _ = strconv.Atoi(l.port("SMTP_PORT", "587"))
+	if cfg.Mail.Driver == "smtp" && cfg.Mail.SMTPHost == "" {
+		l.fail("SMTP_HOST is required when MAIL_DRIVER=smtp")
+	}
+
+	if cfg.Tokens.RefreshTokenTTL <= cfg.Tokens.AccessTokenTTL {
+		l.fail("REFRESH_TOKEN_TTL must be longer than ACCESS_TOKEN_TTL")
+	}
+
+	// An ephemeral key logs everyone out on every deploy and differs between
+	// instances, so production must configure one.
+	if cfg.ReleaseMode && cfg.Signing.SigningKeyFile == "" {
+		l.fail("JWT_SIGNING_KEY_FILE is required when GIN_MODE=release")
+	}
+	if cfg.ReleaseMode {
+		l.databasePassword(cfg.Database)
+	}
+
+	cfg.OIDCProviders = l.oidcProviders(cfg.AppURL)
+	cfg.ProductSource = l.oneOf("PRODUCT_SOURCE", "database", "database", "http")
+	cfg.ProductHTTP = l.productHTTP()
+	cfg.ProductCacheStore = l.oneOf("PRODUCT_CACHE", "memory", "memory", "redis", "none")
+	cfg.ProductCacheSize = l.intRange("PRODUCT_CACHE_SIZE", 1000, 1, 1000000)
+	cfg.ProductCache = services.ProductCacheConfig{
+		TTL:      l.duration("PRODUCT_CACHE_TTL", services.DefaultProductCacheConfig().TTL),
+		StaleTTL: l.duration("PRODUCT_CACHE_STALE_TTL", services.DefaultProductCacheConfig().StaleTTL),
+	}
+	if cfg.ProductCacheStore == "redis" {
+		cfg.Redis = l.redis("REDIS_URL")
+	}
+
+	if err := l.err(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loader collects validation errors so they can be reported together.
+type loader struct {
+	errs []error
+}
+
+func (l *loader) fail(format string, args ...interface{}) {
+	l.errs = append(l.errs, fmt.Errorf(format, args...))
+}
+
+func (l *loader) err() error {
+	if len(l.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n%w", errors.Join(l.errs...))
+}
+
+func (l *loader) secret(key string) []byte {
+	value := os.Getenv(key)
+	switch {
+	case value == "":
+		l.fail("%s is required", key)
+	case len(value) < minSecretLength:
+		l.fail("%s must be at least %d characters long", key, minSecretLength)
+	case isPlaceholder(value):
+		l.fail("%s is a placeholder value, generate a random secret (e.g. openssl rand -base64 48)", key)
+	case strings.Count(value, value[:1]) == len(value):
+		l.fail("%s must not repeat a single character", key)
+	}
+	return []byte(value)
+}
+
+// databasePassword rejects the default and placeholder database passwords.
+// DATABASE_URL may leave the password to other means, so it is only checked
+// when it has one.
+func (l *loader) databasePassword(db database.Config) {
+	if db.URL != "" {
+		u, err := url.Parse(db.URL)
+		if err != nil {
+			return
+		}
+		if password, ok := u.User.Password(); ok && isPlaceholderDBPassword(password) {
+			l.fail("DATABASE_URL uses a placeholder password")
+		}
+		return
+	}
+
+	switch password := os.Getenv("DB_PASSWORD"); {
+	case password == "":
+		l.fail("DB_PASSWORD is required when GIN_MODE=release")
+	case isPlaceholderDBPassword(password):
+		l.fail("DB_PASSWORD is a placeholder value")
+	}
+}
+
+func isPlaceholderDBPassword(value string) bool {
+	for _, placeholder := range placeholderDBPasswords {
+		if strings.EqualFold(value, placeholder) {
+			return true
+		}
+	}
+	return isPlaceholder(value)
+}
+
+func isPlaceholder(value string) bool {
+	lower := strings.ToLower(value)
+	for _, placeholder := range placeholderSecrets {
+		if strings.Contains(lower, placeholder) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *loader) port(key, defaultValue string) string {
+	value := getEnv(key, defaultValue)
+	if port, err := strconv.Atoi(value); err != nil || port < 1 || port > 65535 {
+		l.fail("%s must be a port number between 1 and 65535, got %q", key, value)
+	}
+	return value
+}
+
+func (l *loader) url(key, defaultValue string) string {
+	value := getEnv(key, defaultValue)
+	if !isHTTPURL(value) {
+		l.fail("%s must be an absolute http(s) URL, got %q", key, value)
+	}
+	return value
+}
+
+func (l *loader) origins(key string, defaultValue []string) []string {
+	origins := l.list(key, defaultValue)
+	for _, origin := range origins {
+		// Credentials are allowed, so a wildcard would let any site make
+		// authenticated requests.
+		if origin == "*" {
+			l.fail("%s must list explicit origins, not *", key)
+			continue
+		}
+		if !isHTTPURL(origin) {
+			l.fail("%s contains an invalid origin %q", key, origin)
+		}
+	}
+	return origins
+}
+
+func (l *loader) list(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+func (l *loader) oneOf(key, defaultValue string, allowed ...string) string {
+	value := getEnv(key, defaultValue)
+	for _, candidate := range allowed {
+		if value == candidate {
+			return value
+		}
+	}
+	l.fail("%s must be one of %s, got %q", key, strings.Join(allowed, ", "), value)
+	return defaultValue
+}
+
+func (l *loader) duration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		l.fail("%s must be a positive duration such as 15m or 720h, got %q", key, value)
+		return defaultValue
+	}
+	return d
+}
+
+func (l *loader) positiveInt(key string, defaultValue, max uint64) uint64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || n == 0 || n > max {
+		l.fail("%s must be a whole number between 1 and %d, got %q", key, max, value)
+		return defaultValue
+	}
+	return n
+}
+
+func (l *loader) boolean(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		l.fail("%s must be true or false, got %q", key, value)
+		return defaultValue
+	}
+	return b
+}
+
+func (l *loader) intRange(key string, defaultValue, min, max int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		l.fail("%s must be a whole number between %d and %d, got %q", key, min, max, value)
+		return defaultValue
+	}
+	return n
+}
+
+// redis reads a required redis:// URL.
+func (l *loader) redis(key string) cache.RedisConfig {
+	value := os.Getenv(key)
+	if value == "" {
+		l.fail("%s is required when PRODUCT_CACHE=redis", key)
+		return cache.RedisConfig{}
+	}
+
+	config, err := cache.ParseRedisURL(value)
+	if err != nil {
+		l.fail("%s must be a redis:// or rediss:// URL: %v", key, err)
+	}
+	return config
+}
+
+// productHTTP reads the upstream products API settings from the
+// PRODUCT_SOURCE_* variables.
+func (l *loader) productHTTP() catalog.HTTPConfig {
+	defaults := catalog.DefaultHTTPConfig()
+	return catalog.HTTPConfig{
+		BaseURL:          strings.TrimRight(l.url("PRODUCT_SOURCE_URL", defaults.BaseURL), "/"),
+		Timeout:          l.duration("PRODUCT_SOURCE_TIMEOUT", defaults.Timeout),
+		MaxRetries:       l.intRange("PRODUCT_SOURCE_RETRIES", defaults.MaxRetries, 0, 10),
+		RetryBackoff:     l.duration("PRODUCT_SOURCE_RETRY_BACKOFF", defaults.RetryBackoff),
+		BreakerThreshold: l.intRange("PRODUCT_SOURCE_BREAKER_THRESHOLD", defaults.BreakerThreshold, 1, 1000),
+		BreakerCooldown:  l.duration("PRODUCT_SOURCE_BREAKER_COOLDOWN", defaults.BreakerCooldown),
+		ScanCacheTTL:     l.duration("PRODUCT_SOURCE_SCAN_CACHE_TTL", defaults.ScanCacheTTL),
+	}
+}
+
+// passwordPolicy reads the rules new passwords must follow from
+// PASSWORD_MIN_LENGTH, PASSWORD_REQUIRED_CLASSES (a list of lower, upper,
+// digit and symbol), PASSWORD_DISALLOW_PERSONAL_INFO and
+// PASSWORD_MIN_STRENGTH (0-4).
+func (l *loader) passwordPolicy() validators.PasswordPolicy {
+	policy := validators.DefaultPasswordPolicy()
+	policy.MinLength = l.intRange("PASSWORD_MIN_LENGTH", policy.MinLength, 1, 100)
+	policy.DisallowPersonalInfo = l.boolean("PASSWORD_DISALLOW_PERSONAL_INFO", policy.DisallowPersonalInfo)
+	policy.MinStrength = l.intRange("PASSWORD_MIN_STRENGTH", policy.MinStrength, 0, 4)
+
+	for _, name := range l.list("PASSWORD_REQUIRED_CLASSES", nil) {
+		class, err := validators.ParseCharClass(name)
+		if err != nil {
+			l.fail("PASSWORD_REQUIRED_CLASSES: %v", err)
+			continue
+		}
+		policy.RequiredClasses = append(policy.RequiredClasses, class)
+	}
+	return policy
+}
+
+// argon2Params reads the password hashing cost from PASSWORD_HASH_MEMORY (in
+// KiB), PASSWORD_HASH_ITERATIONS and PASSWORD_HASH_PARALLELISM. Existing
+// hashes are upgraded to new values as users log in.
+func (l *loader) argon2Params() hashing.Argon2Params {
+	params := hashing.DefaultArgon2Params()
+	params.Memory = uint32(l.positiveInt("PASSWORD_HASH_MEMORY", uint64(params.Memory), math.MaxUint32))
+	params.Iterations = uint32(l.positiveInt("PASSWORD_HASH_ITERATIONS", uint64(params.Iterations), math.MaxUint32))
+	params.Parallelism = uint8(l.positiveInt("PASSWORD_HASH_PARALLELISM", uint64(params.Parallelism), math.MaxUint8))
+
+	if err := params.Validate(); err != nil {
+		l.fail("invalid password hashing parameters: %v", err)
+	}
+	return params
+}
+
+// oidcProviders reads the providers listed in OIDC_PROVIDERS. Each provider
+// NAME is configured through OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET
+// and either OIDC_<NAME>_ISSUER for discovery or OIDC_<NAME>_AUTH_URL,
+// OIDC_<NAME>_TOKEN_URL and OIDC_<NAME>_USERINFO_URL. OIDC_<NAME>_EMAILS_URL,
+// OIDC_<NAME>_SCOPES and OIDC_<NAME>_REDIRECT_URL are optional; the redirect
+// URL defaults to <appURL>/auth/callback/<name>. Providers in oidc.Presets
+// only need the client credentials.
+func (l *loader) oidcProviders(appURL string) []oidc.Config {
+	var configs []oidc.Config
+
+	for _, name := range l.list("OIDC_PROVIDERS", nil) {
+		name = strings.ToLower(name)
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		cfg := oidc.Presets[name]
+		cfg.Name = name
+		cfg.ClientID = os.Getenv(prefix + "CLIENT_ID")
+		cfg.ClientSecret = os.Getenv(prefix + "CLIENT_SECRET")
+		cfg.RedirectURL = os.Getenv(prefix + "REDIRECT_URL")
+		cfg.Issuer = getEnv(prefix+"ISSUER", cfg.Issuer)
+		cfg.AuthURL = getEnv(prefix+"AUTH_URL", cfg.AuthURL)
+		cfg.TokenURL = getEnv(prefix+"TOKEN_URL", cfg.TokenURL)
+		cfg.UserInfoURL = getEnv(prefix+"USERINFO_URL", cfg.UserInfoURL)
+		cfg.EmailsURL = getEnv(prefix+"EMAILS_URL", cfg.EmailsURL)
+		if scopes := strings.FieldsFunc(os.Getenv(prefix+"SCOPES"), func(r rune) bool { return r == ',' || r == ' ' }); len(scopes) > 0 {
+			cfg.Scopes = scopes
+		}
+
+		if cfg.ClientID == "" {
+			l.fail("%sCLIENT_ID is required", prefix)
+		}
+		if cfg.Issuer == "" && (cfg.AuthURL == "" || cfg.TokenURL == "" || cfg.UserInfoURL == "") {
+			l.fail("%sISSUER or %sAUTH_URL, %sTOKEN_URL and %sUSERINFO_URL are required", prefix, prefix, prefix, prefix)
+		}
+		if cfg.RedirectURL == "" {
+			cfg.RedirectURL = appURL + "/auth/callback/" + name
+		}
+
+		configs = append(configs, cfg)
+	}
+
+	return configs
+}
+
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -1,10 +1,10 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"mobile-shop-backend/internal/models"
-	"os"
 	"strings"
 	"time"
 
@@ -13,8 +13,21 @@ import (
 	"gorm.io/gorm/logger"
 )
 
-func InitDB() (*gorm.DB, error) {
-	dbURL := getEnv("DATABASE_URL", "")
+// Config holds the connection settings. URL takes precedence over the
+// individual fields.
+type Config struct {
+	URL      string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+	SSLMode  string
+	TimeZone string
+}
+
+func InitDB(cfg Config) (*gorm.DB, error) {
+	dbURL := cfg.URL
 
 	var dsn string
 	if dbURL != "" {
@@ -27,16 +40,8 @@ func InitDB() (*gorm.DB, error) {
 		dsn = dbURL + separator + "prepared_statements=false&statement_cache_mode=disabled&binary_parameters=no&prefer_simple_protocol=true&default_query_exec_mode=simple_protocol&application_name=mobshop_" + timestamp
 		log.Println("Using Supabase database connection with prepared statements disabled")
 	} else {
-		host := getEnv("DB_HOST", "localhost")
-		port := getEnv("DB_PORT", "5432")
-		user := getEnv("DB_USER", "postgres")
-		password := getEnv("DB_PASSWORD", "password")
-		dbname := getEnv("DB_NAME", "mobile_shop")
-		sslmode := getEnv("DB_SSLMODE", "disable")
-		timezone := getEnv("DB_TIMEZONE", "UTC")
-
 		dsn = fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
-			host, user, password, dbname, port, sslmode, timezone)
+			cfg.Host, cfg.User, cfg.Password, cfg.Name, cfg.Port, cfg.SSLMode, cfg.TimeZone)
 		log.Println("Using local database connection")
 	}
 
@@ -59,6 +64,10 @@ func InitDB() (*gorm.DB, error) {
 	sqlDB.SetConnMaxLifetime(time.Second * 10)
 
 	if err := autoMigrate(db); err != nil {
+		var conflicts *CaseConflictError
+		if errors.As(err, &conflicts) {
+			return nil, err
+		}
 		log.Printf("Warning: Database migration had issues (this is usually fine if tables already exist): %v", err)
 	}
 
@@ -67,20 +76,121 @@ func InitDB() (*gorm.DB, error) {
 }
 
 func autoMigrate(db *gorm.DB) error {
-	if !db.Migrator().HasTable(&models.User{}) {
-		if err := db.AutoMigrate(&models.User{}); err != nil {
-			return fmt.Errorf("failed to migrate User table: %v", err)
+	tables := []struct {
+		name  string
+		model interface{}
+	}{
+		{"User", &models.User{}},
+		{"RefreshToken", &models.RefreshToken{}},
+		{"Session", &models.Session{}},
+		{"RevokedToken", &models.RevokedToken{}},
+		{"PasswordResetToken", &models.PasswordResetToken{}},
+		{"LoginAttempt", &models.LoginAttempt{}},
+		{"RecoveryCode", &models.RecoveryCode{}},
+		{"Permission", &models.Permission{}},
+		{"Role", &models.Role{}},
+		{"UserRole", &models.UserRole{}},
+		{"Identity", &models.Identity{}},
+		{"OAuthState", &models.OAuthState{}},
+		{"APIKey", &models.APIKey{}},
+		{"MagicLink", &models.MagicLink{}},
+		{"AuthEvent", &models.AuthEvent{}},
+		{"Product", &models.Product{}},
+		{"ProductReview", &models.ProductReview{}},
+	}
+
+	// AutoMigrate creates missing tables and adds missing columns, so it is
+	// safe to run against a database that already has the schema.
+	for _, table := range tables {
+		if err := db.AutoMigrate(table.model); err != nil {
+			return fmt.Errorf("failed to migrate %s table: %v", table.name, err)
+		}
+	}
+	// The audit log is append-only, even for queries that bypass the
+	// repository. The one exception is the account purge, which blanks the
+	// personal fields of a purged user's events after setting
+	// app.auth_events_purge for its transaction.
+	for _, statement := range []string{
+		`CREATE OR REPLACE FUNCTION auth_events_append_only() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP = 'UPDATE'
+				AND current_setting('app.auth_events_purge', true) = 'on'
+				AND NEW.user_id IS NULL
+				AND COALESCE(NEW.identifier, '') = ''
+				AND COALESCE(NEW.ip_address, '') = ''
+				AND COALESCE(NEW.user_agent, '') = ''
+				AND (NEW.id, NEW.type, NEW.method, NEW.outcome, NEW.reason, NEW.created_at)
+					IS NOT DISTINCT FROM (OLD.id, OLD.type, OLD.method, OLD.outcome, OLD.reason, OLD.created_at)
+			THEN
+				RETURN NEW;
+			END IF;
+			RAISE EXCEPTION 'auth_events is append-only';
+		END;
+		$$ LANGUAGE plpgsql`,
+		"DROP TRIGGER IF EXISTS auth_events_append_only ON auth_events",
+		"CREATE TRIGGER auth_events_append_only BEFORE UPDATE OR DELETE ON auth_events FOR EACH ROW EXECUTE FUNCTION auth_events_append_only()",
+		"DROP TRIGGER IF EXISTS auth_events_no_truncate ON auth_events",
+		"CREATE TRIGGER auth_events_no_truncate BEFORE TRUNCATE ON auth_events FOR EACH STATEMENT EXECUTE FUNCTION auth_events_append_only()",
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to protect auth_events table: %v", err)
 		}
-		log.Println("User table created successfully")
-	} else {
-		log.Println("User table already exists, skipping creation")
 	}
+	if err := createCaseInsensitiveIndexes(db); err != nil {
+		return err
+	}
+
+	log.Println("Database schema is up to date")
 	return nil
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// CaseConflictError lists users whose emails or usernames differ only in
+// case. They predate the case-insensitive indexes and have to be merged or
+// renamed before the indexes can be created.
+type CaseConflictError struct {
+	Conflicts []CaseConflict
+}
+
+// CaseConflict is one value held by several users.
+type CaseConflict struct {
+	Column  string
+	Value   string
+	UserIDs string
+}
+
+func (e *CaseConflictError) Error() string {
+	lines := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		lines = append(lines, fmt.Sprintf("%s %q is used by users %s", c.Column, c.Value, c.UserIDs))
+	}
+	return "users differ only in the case of their email or username; merge or rename them, then restart: " + strings.Join(lines, "; ")
+}
+
+// createCaseInsensitiveIndexes makes emails and usernames unique regardless
+// of case. Conflicting rows, deleted accounts included, are reported as a
+// CaseConflictError instead of letting the index creation fail.
+func createCaseInsensitiveIndexes(db *gorm.DB) error {
+	var conflicts []CaseConflict
+	for _, column := range []string{"email", "username"} {
+		var found []CaseConflict
+		err := db.Raw(fmt.Sprintf(`SELECT '%[1]s' AS column, LOWER(%[1]s) AS value, STRING_AGG(id::text, ', ' ORDER BY created_at) AS user_ids
+			FROM users GROUP BY LOWER(%[1]s) HAVING COUNT(*) > 1 ORDER BY 2`, column)).Scan(&found).Error
+		if err != nil {
+			return fmt.Errorf("failed to check users for case conflicts: %v", err)
+		}
+		conflicts = append(conflicts, found...)
+	}
+	if len(conflicts) > 0 {
+		return &CaseConflictError{Conflicts: conflicts}
+	}
+
+	for _, index := range []string{
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (LOWER(email))",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_lower ON users (LOWER(username))",
+	} {
+		if err := db.Exec(index).Error; err != nil {
+			return fmt.Errorf("failed to create case-insensitive user index: %v", err)
+		}
 	}
-	return defaultValue
+	return nil
 }
@@ -0,0 +1,31 @@
+package database
+
+import (
+	_ "embed"
+	"encoding/json"
+	"mobile-shop-backend/internal/models"
+)
+
+//go:embed seeds/products.json
+var productSeed []byte
+
+//go:embed seeds/product_reviews.json
+var productReviewSeed []byte
+
+// SeedProducts returns the catalog a new database starts with.
+func SeedProducts() ([]models.Product, error) {
+	var products []models.Product
+	if err := json.Unmarshal(productSeed, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// SeedProductReviews returns the reviews of the SeedProducts catalog.
+func SeedProductReviews() ([]models.ProductReview, error) {
+	var reviews []models.ProductReview
+	if err := json.Unmarshal(productReviewSeed, &reviews); err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountHandler struct {
+	accountService *services.AccountService
+}
+
+func NewAccountHandler(accountService *services.AccountService) *AccountHandler {
+	return &AccountHandler{accountService: accountService}
+}
+
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	purgeAt, err := h.accountService.DeleteAccount(userID.(string), req.Password)
+	if err != nil {
+		respondWithError(c, err, "Failed to delete account")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Account deleted", gin.H{
+		"purge_at": purgeAt,
+	})
+}
+
+func (h *AccountHandler) ExportData(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	archive, err := h.accountService.ExportData(userID.(string))
+	if err != nil {
+		respondWithError(c, err, "Failed to export data")
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="mobile-shop-data.zip"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", archive)
+}
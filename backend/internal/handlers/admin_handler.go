@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	adminService *services.AdminService
+	roleService  *services.RoleService
+}
+
+func NewAdminHandler(adminService *services.AdminService, roleService *services.RoleService) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+		roleService:  roleService,
+	}
+}
+
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	skip, err := strconv.Atoi(c.DefaultQuery("skip", "0"))
+	if err != nil || skip < 0 {
+		skip = 0
+	}
+
+	users, total, err := h.adminService.ListUsers(skip, limit)
+	if err != nil {
+		respondWithError(c, err, "Failed to list users")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Users retrieved successfully", gin.H{
+		"users": users,
+		"total": total,
+		"skip":  skip,
+		"limit": limit,
+	})
+}
+
+func (h *AdminHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleService.ListRoles()
+	if err != nil {
+		respondWithError(c, err, "Failed to list roles")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Roles retrieved successfully", gin.H{"roles": roles})
+}
+
+func (h *AdminHandler) SetUserRoles(c *gin.Context) {
+	var req models.SetUserRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	roles, err := h.roleService.SetUserRoles(c.Param("id"), req.Roles)
+	if err != nil {
+		respondWithError(c, err, "Failed to update roles")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Roles updated successfully", gin.H{"roles": roles})
+}
+
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	if err := h.adminService.UnlockUser(c.Param("id")); err != nil {
+		respondWithError(c, err, "Failed to unlock account")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Account unlocked", nil)
+}
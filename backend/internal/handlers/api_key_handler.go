@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	h.listKeys(c, userID.(string))
+}
+
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	h.createKey(c, userID.(string), userID.(string))
+}
+
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	if err := h.apiKeyService.RevokeKey(userID.(string), c.Param("id")); err != nil {
+		respondWithError(c, err, "Failed to revoke API key")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "API key revoked", nil)
+}
+
+// AdminListKeys lists the keys of the user in the :id parameter.
+func (h *APIKeyHandler) AdminListKeys(c *gin.Context) {
+	h.listKeys(c, c.Param("id"))
+}
+
+// AdminCreateKey creates a key for the user in the :id parameter.
+func (h *APIKeyHandler) AdminCreateKey(c *gin.Context) {
+	adminID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	h.createKey(c, c.Param("id"), adminID.(string))
+}
+
+// AdminRevokeKey revokes any user's key.
+func (h *APIKeyHandler) AdminRevokeKey(c *gin.Context) {
+	if err := h.apiKeyService.RevokeAnyKey(c.Param("id")); err != nil {
+		respondWithError(c, err, "Failed to revoke API key")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "API key revoked", nil)
+}
+
+func (h *APIKeyHandler) listKeys(c *gin.Context, userID string) {
+	keys, err := h.apiKeyService.ListKeys(userID)
+	if err != nil {
+		respondWithError(c, err, "Failed to retrieve API keys")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "API keys retrieved successfully", gin.H{
+		"api_keys": keys,
+	})
+}
+
+func (h *APIKeyHandler) createKey(c *gin.Context, userID string, createdByID string) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	key, err := h.apiKeyService.CreateKey(userID, createdByID, &req)
+	if err != nil {
+		respondWithError(c, err, "Failed to create API key")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, "API key created; copy it now, it will not be shown again", gin.H{
+		"api_key": key,
+	})
+}
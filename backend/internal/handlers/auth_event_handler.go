@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthEventHandler struct {
+	authEventService *services.AuthEventService
+}
+
+func NewAuthEventHandler(authEventService *services.AuthEventService) *AuthEventHandler {
+	return &AuthEventHandler{authEventService: authEventService}
+}
+
+// ListEvents searches the audit log of every user.
+func (h *AuthEventHandler) ListEvents(c *gin.Context) {
+	var query models.AuthEventQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	skip, err := strconv.Atoi(c.DefaultQuery("skip", "0"))
+	if err != nil || skip < 0 {
+		skip = 0
+	}
+
+	events, total, err := h.authEventService.ListEvents(&query, skip, limit)
+	if err != nil {
+		respondWithError(c, err, "Failed to list auth events")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Auth events retrieved successfully", gin.H{
+		"events": events,
+		"total":  total,
+		"skip":   skip,
+		"limit":  limit,
+	})
+}
+
+// RecentActivity lists the signed-in user's own recent events.
+func (h *AuthEventHandler) RecentActivity(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	events, err := h.authEventService.RecentActivity(userID.(string), limit)
+	if err != nil {
+		respondWithError(c, err, "Failed to retrieve security activity")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Security activity retrieved successfully", gin.H{
+		"events": events,
+	})
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"log"
+	"mobile-shop-backend/internal/apperrors"
 	"mobile-shop-backend/internal/models"
 	"mobile-shop-backend/internal/services"
 	"mobile-shop-backend/internal/utils"
@@ -11,116 +13,266 @@ import (
 )
 
 type AuthHandler struct {
-	authService *services.AuthService
+	authService              *services.AuthService
+	emailVerificationService *services.EmailVerificationService
 }
 
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
-	return &AuthHandler{authService: authService}
+func NewAuthHandler(authService *services.AuthService, emailVerificationService *services.EmailVerificationService) *AuthHandler {
+	return &AuthHandler{
+		authService:              authService,
+		emailVerificationService: emailVerificationService,
+	}
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req models.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.RespondWithValidationError(c, err)
+		c.Error(apperrors.InvalidRequest(err))
 		return
 	}
 
 	// Additional custom validation
 	if err := validators.ValidateRegisterRequest(&req); err != nil {
-		utils.RespondWithErrorAndCode(c, http.StatusBadRequest, err.Error(), "VALIDATION_ERROR")
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
-	user, token, err := h.authService.Register(&req)
+	user, tokens, err := h.authService.Register(&req, clientInfo(c))
 	if err != nil {
-		switch err.Error() {
-		case "email already exists":
-			utils.RespondWithErrorAndCode(c, http.StatusConflict, "An account with this email already exists", "EMAIL_EXISTS")
-		case "username already exists":
-			utils.RespondWithErrorAndCode(c, http.StatusConflict, "This username is already taken", "USERNAME_EXISTS")
-		default:
-			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to create account")
-		}
+		respondWithError(c, err, "Failed to create account")
 		return
 	}
 
-	utils.RespondWithSuccess(c, http.StatusCreated, "Account created successfully", gin.H{
-		"token": token,
-		"user": gin.H{
-			"id":       user.ID,
-			"name":     user.Name,
-			"username": user.Username,
-			"email":    user.Email,
-		},
-	})
+	// The account is usable right away; a failed email can be resent later.
+	if err := h.emailVerificationService.SendVerification(user); err != nil {
+		log.Printf("Failed to send verification email to new user %s: %v", user.ID, err)
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, "Account created successfully", authPayload(user, tokens))
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.RespondWithValidationError(c, err)
+		c.Error(apperrors.InvalidRequest(err))
 		return
 	}
 
 	// Additional custom validation
 	if err := validators.ValidateLoginRequest(&req); err != nil {
-		utils.RespondWithErrorAndCode(c, http.StatusBadRequest, err.Error(), "VALIDATION_ERROR")
+		c.Error(apperrors.Validation(err))
 		return
 	}
 
-	user, token, err := h.authService.Login(&req)
+	result, err := h.authService.Login(&req, clientInfo(c))
 	if err != nil {
-		switch err.Error() {
-		case "invalid credentials":
-			utils.RespondWithErrorAndCode(c, http.StatusUnauthorized, "Invalid username or password", "INVALID_CREDENTIALS")
-		default:
-			utils.RespondWithError(c, http.StatusInternalServerError, "Login failed")
-		}
+		respondWithError(c, err, "Login failed")
 		return
 	}
 
-	utils.RespondWithSuccess(c, http.StatusOK, "Login successful", gin.H{
-		"token": token,
-		"user": gin.H{
-			"id":       user.ID,
-			"name":     user.Name,
-			"username": user.Username,
-			"email":    user.Email,
-		},
-	})
+	respondWithLoginResult(c, result)
+}
+
+func (h *AuthHandler) CompleteMFALogin(c *gin.Context) {
+	var req models.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	user, tokens, err := h.authService.CompleteMFALogin(req.MFAToken, req.Code, clientInfo(c))
+	if err != nil {
+		respondWithError(c, err, "Login failed")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Login successful", authPayload(user, tokens))
+}
+
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	user, tokens, err := h.authService.Refresh(req.RefreshToken, clientInfo(c))
+	if err != nil {
+		respondWithError(c, err, "Failed to refresh token")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Token refreshed successfully", authPayload(user, tokens))
 }
 
 func (h *AuthHandler) Logout(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	// The refresh token is optional; clients that still hold one should send
+	// it so the whole token family is revoked as well.
+	var req models.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apperrors.InvalidRequest(err))
+			return
+		}
+	}
+
+	expiresAt := c.GetTime("tokenExpiresAt")
+	if err := h.authService.Logout(userID.(string), c.GetString("sessionID"), c.GetString("tokenID"), expiresAt, req.RefreshToken, clientInfo(c)); err != nil {
+		respondWithError(c, err, "Logout failed")
+		return
+	}
+
 	utils.RespondWithSuccess(c, http.StatusOK, "Logout successful", nil)
 }
 
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	if err := h.authService.LogoutAll(userID.(string), clientInfo(c)); err != nil {
+		respondWithError(c, err, "Logout failed")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Logged out of all devices", nil)
+}
+
 func (h *AuthHandler) GetProfile(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
-		utils.RespondWithErrorAndCode(c, http.StatusUnauthorized, "User not authenticated", "NOT_AUTHENTICATED")
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	user, err := h.authService.GetProfile(userID.(string), clientInfo(c))
+	if err != nil {
+		respondWithError(c, err, "Failed to retrieve profile")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Profile retrieved successfully", profilePayload(user))
+}
+
+func (h *AuthHandler) UpdateProfile(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
 		return
 	}
 
-	user, err := h.authService.GetUserByID(userID.(string))
+	if err := validators.ValidateUpdateProfileRequest(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	user, previousEmail, err := h.authService.UpdateProfile(userID.(string), &req, clientInfo(c))
 	if err != nil {
-		switch err.Error() {
-		case "invalid user ID":
-			utils.RespondWithErrorAndCode(c, http.StatusBadRequest, "Invalid user ID", "INVALID_USER_ID")
-		case "user not found":
-			utils.RespondWithErrorAndCode(c, http.StatusNotFound, "User not found", "USER_NOT_FOUND")
-		default:
-			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve profile")
+		respondWithError(c, err, "Failed to update profile")
+		return
+	}
+
+	// The new address has to be verified; a failed email can be resent later.
+	if previousEmail != "" {
+		if err := h.emailVerificationService.SendVerification(user); err != nil {
+			log.Printf("Failed to send verification email to user %s: %v", user.ID, err)
+		}
+		if err := h.emailVerificationService.NotifyEmailChanged(user, previousEmail); err != nil {
+			log.Printf("Failed to notify user %s of the email change: %v", user.ID, err)
 		}
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Profile updated successfully", profilePayload(user))
+}
+
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
 		return
 	}
 
-	utils.RespondWithSuccess(c, http.StatusOK, "Profile retrieved successfully", gin.H{
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	if err := validators.ValidateChangePasswordRequest(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	if err := h.authService.ChangePassword(userID.(string), c.GetString("sessionID"), req.CurrentPassword, req.NewPassword, clientInfo(c)); err != nil {
+		respondWithError(c, err, "Failed to change password")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Password changed, other devices have been signed out", nil)
+}
+
+// clientInfo describes the requesting device for its session record and the
+// audit log.
+// ClientIP only honors X-Forwarded-For from the trusted proxies configured in main.go.
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+func profilePayload(user *models.User) gin.H {
+	return gin.H{
+		"user": gin.H{
+			"id":          user.ID,
+			"name":        user.Name,
+			"username":    user.Username,
+			"email":       user.Email,
+			"verified_at": user.VerifiedAt,
+			"created_at":  user.CreatedAt,
+		},
+	}
+}
+
+func authPayload(user *models.User, tokens *models.TokenPair) gin.H {
+	return gin.H{
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 		"user": gin.H{
-			"id":         user.ID,
-			"name":       user.Name,
-			"username":   user.Username,
-			"email":      user.Email,
-			"created_at": user.CreatedAt,
+			"id":             user.ID,
+			"name":           user.Name,
+			"username":       user.Username,
+			"email":          user.Email,
+			"email_verified": user.VerifiedAt != nil,
 		},
-	})
+	}
+}
+
+// respondWithLoginResult renders a successful first login step: either the
+// token pair or a request for the second factor.
+func respondWithLoginResult(c *gin.Context, result *services.LoginResult) {
+	if result.MFAToken != "" {
+		utils.RespondWithSuccess(c, http.StatusOK, "Two-factor authentication required", gin.H{
+			"mfa_required": true,
+			"mfa_token":    result.MFAToken,
+		})
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Login successful", authPayload(result.User, result.Tokens))
 }
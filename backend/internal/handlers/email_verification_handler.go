@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailVerificationHandler struct {
+	emailVerificationService *services.EmailVerificationService
+}
+
+func NewEmailVerificationHandler(emailVerificationService *services.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{emailVerificationService: emailVerificationService}
+}
+
+func (h *EmailVerificationHandler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	user, err := h.emailVerificationService.Verify(req.Token)
+	if err != nil {
+		respondWithError(c, err, "Failed to verify email")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Email verified successfully", gin.H{
+		"email":       user.Email,
+		"verified_at": user.VerifiedAt,
+	})
+}
+
+func (h *EmailVerificationHandler) ResendVerification(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	if err := h.emailVerificationService.ResendVerification(userID.(string)); err != nil {
+		respondWithError(c, err, "Failed to send verification email")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Verification email sent", nil)
+}
@@ -0,0 +1,9 @@
+package handlers
+
+import "github.com/gin-gonic/gin"
+
+// respondWithError hands err to middleware.ErrorHandler for rendering.
+// fallback replaces the generic message when err is not an apperrors.Error.
+func respondWithError(c *gin.Context, err error, fallback string) {
+	c.Error(err).SetMeta(fallback)
+}
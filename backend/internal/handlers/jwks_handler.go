@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"mobile-shop-backend/internal/signing"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	keySet *signing.KeySet
+}
+
+func NewJWKSHandler(keySet *signing.KeySet) *JWKSHandler {
+	return &JWKSHandler{keySet: keySet}
+}
+
+// GetJWKS serves the public verification keys in standard JWKS format so
+// other services can verify access tokens without a shared secret. The
+// response is not wrapped in the usual API envelope.
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keySet.JWKS())
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MagicLinkHandler struct {
+	magicLinkService *services.MagicLinkService
+}
+
+func NewMagicLinkHandler(magicLinkService *services.MagicLinkService) *MagicLinkHandler {
+	return &MagicLinkHandler{magicLinkService: magicLinkService}
+}
+
+func (h *MagicLinkHandler) RequestMagicLink(c *gin.Context) {
+	var req models.MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	if err := h.magicLinkService.RequestLink(req.Email); err != nil {
+		respondWithError(c, err, "Failed to send sign-in link")
+		return
+	}
+
+	// Same response whether or not the account exists.
+	utils.RespondWithSuccess(c, http.StatusOK, "If an account with that email exists, a sign-in link has been sent", nil)
+}
+
+func (h *MagicLinkHandler) ConsumeMagicLink(c *gin.Context) {
+	var req models.ConsumeMagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	result, err := h.magicLinkService.ConsumeLink(req.Token, clientInfo(c))
+	if err != nil {
+		respondWithError(c, err, "Sign-in failed")
+		return
+	}
+
+	respondWithLoginResult(c, result)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	mfaService *services.MFAService
+}
+
+func NewMFAHandler(mfaService *services.MFAService) *MFAHandler {
+	return &MFAHandler{mfaService: mfaService}
+}
+
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	enrollment, err := h.mfaService.BeginEnrollment(userID.(string))
+	if err != nil {
+		respondWithError(c, err, "Failed to start two-factor enrollment")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Scan the QR code with your authenticator app, then confirm with a code", enrollment)
+}
+
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	codes, err := h.mfaService.ConfirmEnrollment(userID.(string), req.Code)
+	if err != nil {
+		respondWithError(c, err, "Failed to enable two-factor authentication")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Two-factor authentication enabled", gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+func (h *MFAHandler) Disable(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	var req models.DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	if err := h.mfaService.Disable(userID.(string), req.Password, req.Code); err != nil {
+		respondWithError(c, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(userID.(string), req.Code)
+	if err != nil {
+		respondWithError(c, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Recovery codes regenerated", gin.H{
+		"recovery_codes": codes,
+	})
+}
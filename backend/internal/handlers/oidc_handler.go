@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCHandler struct {
+	oidcService *services.OIDCService
+}
+
+func NewOIDCHandler(oidcService *services.OIDCService) *OIDCHandler {
+	return &OIDCHandler{oidcService: oidcService}
+}
+
+func (h *OIDCHandler) ListProviders(c *gin.Context) {
+	utils.RespondWithSuccess(c, http.StatusOK, "Providers retrieved successfully", gin.H{
+		"providers": h.oidcService.ProviderNames(),
+	})
+}
+
+// Authorize returns the provider URL the frontend should redirect to. The
+// provider sends the user back to the configured redirect URL with a code
+// and state, which the frontend posts to Callback.
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	authURL, err := h.oidcService.BeginLogin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		respondWithError(c, err, "Sign-in failed")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Authorization URL created", gin.H{
+		"authorization_url": authURL,
+	})
+}
+
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	var req models.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	result, err := h.oidcService.CompleteLogin(c.Request.Context(), c.Param("provider"), req.State, req.Code, clientInfo(c))
+	if err != nil {
+		respondWithError(c, err, "Sign-in failed")
+		return
+	}
+
+	respondWithLoginResult(c, result)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/utils"
+	"mobile-shop-backend/internal/validators"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordResetHandler struct {
+	passwordResetService *services.PasswordResetService
+}
+
+func NewPasswordResetHandler(passwordResetService *services.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{passwordResetService: passwordResetService}
+}
+
+func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	if err := h.passwordResetService.RequestReset(req.Email); err != nil {
+		respondWithError(c, err, "Failed to process password reset request")
+		return
+	}
+
+	// Same response whether or not the account exists.
+	utils.RespondWithSuccess(c, http.StatusOK, "If an account with that email exists, a password reset link has been sent", nil)
+}
+
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.InvalidRequest(err))
+		return
+	}
+
+	// Additional custom validation
+	if err := validators.ValidateResetPasswordRequest(&req); err != nil {
+		c.Error(apperrors.Validation(err))
+		return
+	}
+
+	if err := h.passwordResetService.ResetPassword(req.Token, req.Password); err != nil {
+		respondWithError(c, err, "Failed to reset password")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Password has been reset successfully", nil)
+}
@@ -1,137 +1,119 @@
 package handlers
 
 import (
-	"encoding/json"
+	"fmt"
+	"math"
+	"mobile-shop-backend/internal/apperrors"
 	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
 	"net/http"
-	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-type ProductHandler struct{}
-
-func NewProductHandler() *ProductHandler {
-	return &ProductHandler{}
+type ProductHandler struct {
+	productSource services.ProductSource
 }
 
-type ProductResponse struct {
-	Products []models.Product `json:"products"`
-	Total    int              `json:"total"`
-	Skip     int              `json:"skip"`
-	Limit    int              `json:"limit"`
+func NewProductHandler(productSource services.ProductSource) *ProductHandler {
+	return &ProductHandler{productSource: productSource}
 }
 
 func (h *ProductHandler) GetProducts(c *gin.Context) {
-	// Get pagination parameters
-	limitStr := c.DefaultQuery("limit", "12")
-	skipStr := c.DefaultQuery("skip", "0")
-
-	// Get filtering parameters
-	search := c.Query("search")
-	category := c.Query("category")
-	sortBy := c.DefaultQuery("sortBy", "title")
-	sortOrder := c.DefaultQuery("sortOrder", "asc")
-	priceMin := c.Query("priceMin")
-	priceMax := c.Query("priceMax")
-
-	limit, err := strconv.Atoi(limitStr)
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "12"))
 	if err != nil || limit <= 0 || limit > 100 {
 		limit = 12
 	}
 
-	skip, err := strconv.Atoi(skipStr)
+	skip, err := strconv.Atoi(c.DefaultQuery("skip", "0"))
 	if err != nil || skip < 0 {
 		skip = 0
 	}
 
-	baseURL := "https://dummyjson.com/products"
-	params := url.Values{}
-
-	// Add pagination
-	params.Add("limit", strconv.Itoa(limit))
-	params.Add("skip", strconv.Itoa(skip))
-
-	// Add sorting
-	if sortBy == "price" || sortBy == "title" || sortBy == "rating" {
-		params.Add("sortBy", sortBy)
-		if sortOrder == "desc" {
-			params.Add("order", "desc")
-		} else {
-			params.Add("order", "asc")
-		}
+	priceMin, priceMax, err := parsePriceRange(c.Query("priceMin"), c.Query("priceMax"))
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	// Handle different filtering scenarios
-	var apiURL string
-
-	if search != "" {
-		apiURL = "https://dummyjson.com/products/search?" + params.Encode() + "&q=" + url.QueryEscape(search)
-	} else if category != "" {
-		apiURL = "https://dummyjson.com/products/category/" + url.QueryEscape(category) + "?" + params.Encode()
-	} else {
-		apiURL = baseURL + "?" + params.Encode()
+	query := models.ProductQuery{
+		Search:   strings.TrimSpace(c.Query("search")),
+		Category: strings.TrimSpace(c.Query("category")),
+		SortBy:   c.DefaultQuery("sortBy", models.ProductSortTitle),
+		SortDesc: c.Query("sortOrder") == "desc",
+		PriceMin: priceMin,
+		PriceMax: priceMax,
+		Limit:    limit,
+		Skip:     skip,
 	}
 
-	resp, err := http.Get(apiURL)
+	page, err := h.productSource.ListProducts(c.Request.Context(), query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products"})
+		respondWithError(c, err, "Failed to fetch products")
 		return
 	}
-	defer resp.Body.Close()
 
-	var productResponse ProductResponse
-	if err := json.NewDecoder(resp.Body).Decode(&productResponse); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse products"})
+	c.JSON(http.StatusOK, page)
+}
+
+// GetProduct returns a product with its reviews and related products.
+func (h *ProductHandler) GetProduct(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.Error(apperrors.ErrInvalidProductID)
 		return
 	}
 
-	if priceMin != "" || priceMax != "" {
-		filteredProducts := make([]models.Product, 0)
-		for _, product := range productResponse.Products {
-			includeProduct := true
-
-			if priceMin != "" {
-				if minPrice, err := strconv.ParseFloat(priceMin, 64); err == nil {
-					if product.Price < minPrice {
-						includeProduct = false
-					}
-				}
-			}
-
-			if priceMax != "" && includeProduct {
-				if maxPrice, err := strconv.ParseFloat(priceMax, 64); err == nil {
-					if product.Price > maxPrice {
-						includeProduct = false
-					}
-				}
-			}
-
-			if includeProduct {
-				filteredProducts = append(filteredProducts, product)
-			}
-		}
-		productResponse.Products = filteredProducts
-		productResponse.Total = len(filteredProducts)
+	product, err := h.productSource.GetProduct(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err, "Failed to fetch product")
+		return
 	}
 
-	c.JSON(http.StatusOK, productResponse)
+	c.JSON(http.StatusOK, product)
 }
 
 func (h *ProductHandler) GetCategories(c *gin.Context) {
-	resp, err := http.Get("https://dummyjson.com/products/category-list")
+	categories, err := h.productSource.ListCategories(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		respondWithError(c, err, "Failed to fetch categories")
 		return
 	}
-	defer resp.Body.Close()
 
-	var categories []string
-	if err := json.NewDecoder(resp.Body).Decode(&categories); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse categories"})
-		return
+	c.JSON(http.StatusOK, gin.H{"categories": categories})
+}
+
+// parsePriceRange reads the price bounds. An empty bound leaves that side
+// of the range open.
+func parsePriceRange(minValue, maxValue string) (*float64, *float64, error) {
+	priceMin, err := parsePrice("priceMin", minValue)
+	if err != nil {
+		return nil, nil, err
+	}
+	priceMax, err := parsePrice("priceMax", maxValue)
+	if err != nil {
+		return nil, nil, err
+	}
+	if priceMin != nil && priceMax != nil && *priceMin > *priceMax {
+		return nil, nil, apperrors.ErrInvalidPriceRange.WithDetails("priceMin must not be greater than priceMax")
 	}
+	return priceMin, priceMax, nil
+}
 
-	c.JSON(http.StatusOK, gin.H{"categories": categories})
+func parsePrice(name, value string) (*float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(price) || math.IsInf(price, 0) {
+		return nil, apperrors.ErrInvalidPriceRange.WithDetails(fmt.Sprintf("%s must be a number, got %q", name, value))
+	}
+	if price < 0 {
+		return nil, apperrors.ErrInvalidPriceRange.WithDetails(name + " must not be negative")
+	}
+	return &price, nil
 }
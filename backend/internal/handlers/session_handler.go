@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	sessionService *services.SessionService
+}
+
+func NewSessionHandler(sessionService *services.SessionService) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService}
+}
+
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(userID.(string), c.GetString("sessionID"))
+	if err != nil {
+		respondWithError(c, err, "Failed to retrieve sessions")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Sessions retrieved successfully", gin.H{
+		"sessions": sessions,
+	})
+}
+
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.Error(apperrors.ErrNotAuthenticated)
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(userID.(string), c.Param("id")); err != nil {
+		respondWithError(c, err, "Failed to revoke session")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Session revoked", nil)
+}
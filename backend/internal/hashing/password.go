@@ -0,0 +1,183 @@
+// Package hashing hashes and verifies user passwords. New hashes use argon2id
+// in the PHC string format ($argon2id$v=19$m=...,t=...,p=...$salt$hash), so
+// the parameters travel with each hash and can be raised later. Hashes made
+// by earlier versions with bcrypt are still accepted and reported by
+// NeedsRehash, so callers can upgrade them the next time the password is
+// known.
+package hashing
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownFormat is returned for stored hashes that were produced by
+// neither argon2id nor bcrypt.
+var ErrUnknownFormat = errors.New("unknown password hash format")
+
+// PasswordHasher hashes passwords for storage and checks them at login.
+type PasswordHasher interface {
+	// Hash returns the encoded hash of password.
+	Hash(password string) (string, error)
+	// Verify reports whether password matches the encoded hash. A mismatch
+	// is not an error.
+	Verify(encodedHash, password string) (bool, error)
+	// NeedsRehash reports whether encodedHash should be replaced by a fresh
+	// Hash, because it uses another algorithm or weaker parameters.
+	NeedsRehash(encodedHash string) bool
+}
+
+// Argon2Params are the argon2id cost parameters. Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params returns the parameters used unless configured
+// otherwise: 64 MiB, 3 passes and 2 lanes, above the OWASP minimum.
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// Validate reports parameters argon2 cannot use or that are trivially weak.
+func (p Argon2Params) Validate() error {
+	switch {
+	case p.Iterations < 1:
+		return errors.New("iterations must be at least 1")
+	case p.Parallelism < 1:
+		return errors.New("parallelism must be at least 1")
+	case p.Memory < 8*uint32(p.Parallelism):
+		return fmt.Errorf("memory must be at least %d KiB for parallelism %d", 8*uint32(p.Parallelism), p.Parallelism)
+	case p.SaltLength < 8:
+		return errors.New("salt length must be at least 8 bytes")
+	case p.KeyLength < 16:
+		return errors.New("key length must be at least 16 bytes")
+	}
+	return nil
+}
+
+// Argon2idHasher creates argon2id hashes and verifies both argon2id and
+// legacy bcrypt hashes.
+type Argon2idHasher struct {
+	params Argon2Params
+}
+
+func NewArgon2idHasher(params Argon2Params) *Argon2idHasher {
+	return &Argon2idHasher{params: params}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+	return encodeArgon2id(h.params, salt, key), nil
+}
+
+func (h *Argon2idHasher) Verify(encodedHash, password string) (bool, error) {
+	switch {
+	case isArgon2id(encodedHash):
+		params, salt, key, err := decodeArgon2id(encodedHash)
+		if err != nil {
+			return false, err
+		}
+		candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+		return subtle.ConstantTimeCompare(key, candidate) == 1, nil
+
+	case isBcrypt(encodedHash):
+		err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+
+	default:
+		return false, ErrUnknownFormat
+	}
+}
+
+func (h *Argon2idHasher) NeedsRehash(encodedHash string) bool {
+	if !isArgon2id(encodedHash) {
+		return true
+	}
+
+	params, salt, key, err := decodeArgon2id(encodedHash)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.params.Memory ||
+		params.Iterations != h.params.Iterations ||
+		params.Parallelism != h.params.Parallelism ||
+		uint32(len(salt)) != h.params.SaltLength ||
+		uint32(len(key)) != h.params.KeyLength
+}
+
+func isArgon2id(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$argon2id$")
+}
+
+func isBcrypt(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") ||
+		strings.HasPrefix(encodedHash, "$2b$") ||
+		strings.HasPrefix(encodedHash, "$2y$")
+}
+
+func encodeArgon2id(params Argon2Params, salt, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2id(encodedHash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrUnknownFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, ErrUnknownFormat
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnknownFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownFormat
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
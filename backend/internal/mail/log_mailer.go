@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer does not deliver email. It appends each message to a file, or to
+// the process log when no path is set, which makes it suitable for local
+// development and tests.
+type LogMailer struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewLogMailer(path string) *LogMailer {
+	return &LogMailer{path: path}
+}
+
+func (m *LogMailer) Send(msg Message) error {
+	entry := fmt.Sprintf("=== %s ===\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+
+	if m.path == "" {
+		log.Print(entry)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail log: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(entry); err != nil {
+		return fmt.Errorf("failed to write mail log: %v", err)
+	}
+	return nil
+}
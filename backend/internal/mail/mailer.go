@@ -0,0 +1,43 @@
+package mail
+
+import "fmt"
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outgoing email. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Config selects and configures the mailer.
+type Config struct {
+	Driver       string
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	LogPath      string
+}
+
+// NewMailer selects a mailer based on the driver. "smtp" delivers through
+// the configured SMTP server; "log" writes messages to LogPath (or the
+// process log).
+func NewMailer(cfg Config) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP host is required for the smtp driver")
+		}
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	case "log", "":
+		return NewLogMailer(cfg.LogPath), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
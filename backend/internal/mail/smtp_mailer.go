@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPMailer sends email through an SMTP server using PLAIN authentication
+// when credentials are configured.
+type SMTPMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", m.host, m.port)
+	if err := smtp.SendMail(addr, auth, m.from, []string{msg.To}, m.buildMessage(msg)); err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+	return nil
+}
+
+func (m *SMTPMailer) buildMessage(msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + m.from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"mobile-shop-backend/internal/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireSession rejects requests authenticated with an API key, for
+// endpoints only a signed-in user may call, such as changing the password or
+// managing API keys. It must run after AuthMiddleware.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("apiKeyID") != "" {
+			abort(c, apperrors.ErrAPIKeyNotAllowed)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireScope lets requests authenticated with an API key through only if
+// the key has every one of the given scopes. Signed-in users are not
+// restricted. It must run after AuthMiddleware.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("apiKeyID") == "" {
+			c.Next()
+			return
+		}
+
+		granted := c.GetStringSlice("scopes")
+		for _, scope := range scopes {
+			if !containsAny(granted, []string{scope}) {
+				abort(c, apperrors.ErrMissingScope)
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
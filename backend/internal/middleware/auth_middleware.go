@@ -1,55 +1,158 @@
 package middleware
 
 import (
-	"net/http"
-	"os"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/repositories"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/signing"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-func getJWTSecret() []byte {
-	secret := os.Getenv("JWT_SECRET")
-	return []byte(secret)
-}
+// lastSeenResolution is how stale a session's last seen time may get before
+// a request updates it.
+const lastSeenResolution = time.Minute
+
+// AuthMiddleware accepts access tokens signed by any key in keySet. The
+// token's kid, alg, iss, aud and exp are all checked by keySet.Parse.
+//
+// API keys are accepted too, in an X-API-Key header or as
+// "Authorization: ApiKey <key>". Requests made with a key carry the key's
+// effective scopes and permissions and no roles or session; RequireSession
+// and RequireScope decide which routes they may use.
+func AuthMiddleware(db *gorm.DB, keySet *signing.KeySet, apiKeyService *services.APIKeyService) gin.HandlerFunc {
+	userRepo := repositories.NewUserRepository(db)
+	revokedTokenRepo := repositories.NewRevokedTokenRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
 
-func AuthMiddleware(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if rawKey, ok := apiKeyFromRequest(c); ok {
+			authenticateAPIKey(c, apiKeyService, rawKey)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+			abort(c, apperrors.ErrAuthorizationRequired)
 			return
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Bearer token required"})
-			c.Abort()
+			abort(c, apperrors.ErrBearerTokenRequired)
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return getJWTSecret(), nil
-		})
+		claims, err := keySet.Parse(tokenString)
+		if err != nil {
+			abort(c, apperrors.ErrInvalidToken.Wrap(err))
+			return
+		}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+		// Other token types (such as MFA pending tokens) share the signing key
+		// but must never grant access.
+		if claims["typ"] != services.TokenTypeAccess {
+			abort(c, apperrors.ErrInvalidToken)
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			userID := claims["user_id"]
-			c.Set("userID", userID)
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		userID, _ := claims["user_id"].(string)
+		jti, _ := claims["jti"].(string)
+		sessionID, _ := claims["sid"].(string)
+		version, _ := claims["ver"].(float64)
+		userUUID, err := uuid.Parse(userID)
+		sessionUUID, sessionErr := uuid.Parse(sessionID)
+		if err != nil || sessionErr != nil || jti == "" {
+			abort(c, apperrors.ErrInvalidToken)
+			return
+		}
+
+		revoked, err := revokedTokenRepo.IsRevoked(jti)
+		if err != nil {
+			c.Error(err).SetMeta("Failed to verify token")
 			c.Abort()
 			return
 		}
+		if revoked {
+			abort(c, apperrors.ErrTokenRevoked)
+			return
+		}
+
+		user, err := userRepo.GetByID(userUUID)
+		if err != nil || user.TokenVersion != int(version) {
+			abort(c, apperrors.ErrTokenRevoked)
+			return
+		}
+
+		session, err := sessionRepo.GetByID(sessionUUID)
+		if err != nil || session.UserID != userUUID || session.RevokedAt != nil {
+			abort(c, apperrors.ErrSessionRevoked)
+			return
+		}
+
+		now := time.Now()
+		if session.LastSeenAt.Before(now.Add(-lastSeenResolution)) {
+			_ = sessionRepo.Touch(session.ID, c.ClientIP(), now, now.Add(-lastSeenResolution))
+		}
+
+		c.Set("userID", userID)
+		c.Set("sessionID", sessionID)
+		c.Set("tokenID", jti)
+		c.Set("emailVerified", user.VerifiedAt != nil)
+		c.Set("roles", stringSliceClaim(claims, "roles"))
+		c.Set("permissions", stringSliceClaim(claims, "perms"))
+		if expiresAt, err := claims.GetExpirationTime(); err == nil && expiresAt != nil {
+			c.Set("tokenExpiresAt", expiresAt.Time)
+		}
 
 		c.Next()
 	}
 }
+
+// apiKeyFromRequest returns the API key sent with the request, if any.
+func apiKeyFromRequest(c *gin.Context) (string, bool) {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key, true
+	}
+
+	authHeader := c.GetHeader("Authorization")
+	if scheme, key, found := strings.Cut(authHeader, " "); found && strings.EqualFold(scheme, "ApiKey") {
+		return strings.TrimSpace(key), true
+	}
+	return "", false
+}
+
+func authenticateAPIKey(c *gin.Context, apiKeyService *services.APIKeyService, rawKey string) {
+	principal, err := apiKeyService.Authenticate(rawKey, c.ClientIP())
+	if err != nil {
+		c.Error(err).SetMeta("Failed to verify API key")
+		c.Abort()
+		return
+	}
+
+	c.Set("userID", principal.User.ID.String())
+	c.Set("apiKeyID", principal.Key.ID.String())
+	c.Set("emailVerified", principal.User.VerifiedAt != nil)
+	c.Set("roles", []string{})
+	c.Set("permissions", principal.Permissions)
+	c.Set("scopes", principal.Scopes)
+
+	c.Next()
+}
+
+// stringSliceClaim reads a JSON array claim, which jwt decodes as []interface{}.
+func stringSliceClaim(claims jwt.MapClaims, name string) []string {
+	raw, _ := claims[name].([]interface{})
+	values := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
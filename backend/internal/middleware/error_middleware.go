@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"math"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler renders the last error a handler recorded with c.Error as a
+// utils.ErrorResponse. Errors from the apperrors package are sent with their
+// own status, code and message; anything else is logged and reported as a
+// 500, using the handler's fallback message (set as the error's Meta) when
+// there is one.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil || c.Writer.Written() {
+			return
+		}
+
+		var appErr *apperrors.Error
+		if !errors.As(last.Err, &appErr) {
+			appErr = apperrors.Internal(last.Err)
+			if fallback, ok := last.Meta.(string); ok && fallback != "" {
+				appErr.Message = fallback
+			}
+		}
+
+		if appErr.Status >= http.StatusInternalServerError {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, last.Err)
+		}
+		if appErr.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+		}
+
+		c.JSON(appErr.Status, utils.ErrorResponse{
+			Error:      appErr.Message,
+			Code:       appErr.Code,
+			Details:    appErr.Details,
+			Violations: appErr.Violations,
+		})
+	}
+}
+
+// abort stops the handler chain with err, for ErrorHandler to render.
+func abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
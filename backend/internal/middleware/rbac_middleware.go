@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"mobile-shop-backend/internal/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole allows the request if the authenticated user has any of the
+// given roles. It must run after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !containsAny(c.GetStringSlice("roles"), roles) {
+			abort(c, apperrors.ErrForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequirePermission allows the request only if the authenticated user holds
+// every one of the given permissions. It must run after AuthMiddleware.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted := c.GetStringSlice("permissions")
+		for _, permission := range permissions {
+			if !containsAny(granted, []string{permission}) {
+				abort(c, apperrors.ErrForbidden)
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+func containsAny(have []string, want []string) bool {
+	for _, w := range want {
+		for _, h := range have {
+			if h == w {
+				return true
+			}
+		}
+	}
+	return false
+}
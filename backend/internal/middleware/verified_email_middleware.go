@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"mobile-shop-backend/internal/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailVerificationPolicy controls which routes unverified users may access.
+type EmailVerificationPolicy string
+
+const (
+	// VerificationPolicyAllow lets unverified users do everything.
+	VerificationPolicyAllow EmailVerificationPolicy = "allow"
+	// VerificationPolicyRestrict blocks purchasing (checkout) until verified.
+	VerificationPolicyRestrict EmailVerificationPolicy = "restrict"
+	// VerificationPolicyBlock limits unverified users to their profile
+	// (viewing, editing, password change, export and deletion), logout and
+	// resending the verification email.
+	VerificationPolicyBlock EmailVerificationPolicy = "block"
+)
+
+// RequireVerifiedEmail rejects requests from users whose email address has
+// not been verified. It must run after AuthMiddleware.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("emailVerified") {
+			abort(c, apperrors.ErrEmailNotVerified)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKeyPrefix starts every API key, so leaked keys are easy to recognise.
+const APIKeyPrefix = "msk_"
+
+// ScopeProfileRead lets an API key read its owner's profile.
+const ScopeProfileRead = "profile:read"
+
+// APIKeyScopes lists the scopes an API key can be granted. Apart from
+// ScopeProfileRead they are RBAC permissions, which a key can only use while
+// its owner still holds them.
+var APIKeyScopes = append([]string{ScopeProfileRead}, AllPermissions...)
+
+// APIKey lets scripts call the API as a user without signing in. Only the
+// SHA-256 hash of the key is stored; the key itself is shown once, when it
+// is created.
+type APIKey struct {
+	ID     uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID uuid.UUID `json:"-" gorm:"type:uuid;index;not null"`
+	Name   string    `json:"name" gorm:"size:100;not null"`
+	// Prefix is the start of the key, shown so users can tell keys apart.
+	Prefix  string   `json:"prefix" gorm:"size:16;not null"`
+	KeyHash string   `json:"-" gorm:"uniqueIndex;not null"`
+	Scopes  []string `json:"scopes" gorm:"type:text;serializer:json;not null"`
+	// CreatedByID is the user who created the key: the owner, or an admin.
+	CreatedByID uuid.UUID  `json:"created_by" gorm:"type:uuid;not null"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	LastUsedIP  string     `json:"last_used_ip" gorm:"size:64"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Types of AuthEvent.
+const (
+	AuthEventRegister       = "register"
+	AuthEventLogin          = "login"
+	AuthEventMFALogin       = "mfa_login"
+	AuthEventTokenRefresh   = "token_refresh"
+	AuthEventLogout         = "logout"
+	AuthEventLogoutAll      = "logout_all"
+	AuthEventProfileView    = "profile_view"
+	AuthEventProfileUpdate  = "profile_update"
+	AuthEventPasswordChange = "password_change"
+)
+
+// AuthEventTypes lists every event type, for validating filters.
+var AuthEventTypes = []string{
+	AuthEventRegister,
+	AuthEventLogin,
+	AuthEventMFALogin,
+	AuthEventTokenRefresh,
+	AuthEventLogout,
+	AuthEventLogoutAll,
+	AuthEventProfileView,
+	AuthEventProfileUpdate,
+	AuthEventPasswordChange,
+}
+
+// Outcomes of an AuthEvent. A password login of an account with MFA enabled
+// ends in AuthOutcomeMFARequired and is followed by an AuthEventMFALogin.
+const (
+	AuthOutcomeSuccess     = "success"
+	AuthOutcomeFailure     = "failure"
+	AuthOutcomeMFARequired = "mfa_required"
+)
+
+// AuthEvent is one entry of the security audit log. Entries are never
+// deleted. When an account is purged its entries are kept but pseudonymized:
+// UserID, Identifier, IPAddress and UserAgent are cleared.
+type AuthEvent struct {
+	ID     uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	Type   string     `json:"type" gorm:"size:32;not null;index"`
+	UserID *uuid.UUID `json:"user_id,omitempty" gorm:"type:uuid;index"`
+	// Identifier is the username or email a login or registration was
+	// attempted with, which matters when no account matched it.
+	Identifier string `json:"identifier,omitempty" gorm:"size:100"`
+	// Method is how a login was made: "password", "magic_link" or
+	// "oidc:<provider>".
+	Method    string `json:"method,omitempty" gorm:"size:64"`
+	IPAddress string `json:"ip_address" gorm:"size:64;index"`
+	UserAgent string `json:"user_agent" gorm:"size:512"`
+	Outcome   string `json:"outcome" gorm:"size:16;not null"`
+	// Reason is the error code of a failure.
+	Reason    string    `json:"reason,omitempty" gorm:"size:64"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+func (e *AuthEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// AuthEventFilter selects audit log entries. Zero fields match everything.
+type AuthEventFilter struct {
+	Type         string
+	ExcludeTypes []string
+	UserID       *uuid.UUID
+	Outcome      string
+	IPAddress    string
+	From         *time.Time
+	To           *time.Time
+}
+
+// AuthEventQuery holds the filters of the admin audit log endpoint. From and
+// To are RFC 3339 timestamps.
+type AuthEventQuery struct {
+	Type    string `form:"type"`
+	UserID  string `form:"user_id"`
+	Outcome string `form:"outcome"`
+	IP      string `form:"ip"`
+	From    string `form:"from"`
+	To      string `form:"to"`
+}
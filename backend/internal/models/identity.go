@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Identity links a user to an account at an external OpenID Connect
+// provider. The provider and subject pair is unique.
+type Identity struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;index;not null"`
+	Provider  string    `json:"provider" gorm:"uniqueIndex:idx_identities_provider_subject;not null"`
+	Subject   string    `json:"-" gorm:"uniqueIndex:idx_identities_provider_subject;not null"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BeforeCreate hook to generate UUID before creating identity
+func (i *Identity) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// OAuthState holds the PKCE verifier and nonce of an authorization request
+// between the redirect to the provider and the callback. Only the SHA-256
+// hash of the state parameter is stored.
+type OAuthState struct {
+	StateHash    string    `gorm:"primaryKey"`
+	Provider     string    `gorm:"not null"`
+	CodeVerifier string    `gorm:"not null"`
+	Nonce        string    `gorm:"not null"`
+	ExpiresAt    time.Time `gorm:"index;not null"`
+	CreatedAt    time.Time
+}
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
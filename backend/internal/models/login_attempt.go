@@ -0,0 +1,41 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginAttempt tracks consecutive failed logins for a throttling key, such as
+// an account or a client IP address.
+type LoginAttempt struct {
+	Key           string     `json:"key" gorm:"primaryKey"`
+	Failures      int        `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// AccountLoginAttemptKey identifies an existing account. Failures are counted
+// against the account no matter which identifier was used to log in.
+func AccountLoginAttemptKey(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+// UnknownLoginAttemptKey identifies login attempts for identifiers that do
+// not match any account, so probing unknown names is throttled the same way.
+func UnknownLoginAttemptKey(identifier string) string {
+	return "login:" + strings.ToLower(strings.TrimSpace(identifier))
+}
+
+// LoginAttemptKeys returns every key that can name user: the account's own
+// and those of attempts made with its email or username while they matched
+// no account, e.g. after it was deleted.
+func LoginAttemptKeys(user *User) []string {
+	return []string{
+		AccountLoginAttemptKey(user.ID),
+		UnknownLoginAttemptKey(user.Email),
+		UnknownLoginAttemptKey(user.Username),
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MagicLink records a passwordless sign-in link. The link carries a signed
+// token whose jti is the ID, and the row makes sure it is used only once.
+type MagicLink struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;index;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email,max=100"`
+}
+
+type ConsumeMagicLinkRequest struct {
+	Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecoveryCode is a single-use fallback for a lost authenticator. Only the
+// SHA-256 hash of the code is stored.
+type RecoveryCode struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;index;not null"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID before creating recovery code
+func (r *RecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableMFARequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
@@ -8,13 +8,22 @@ import (
 )
 
 type User struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
-	Name      string    `json:"name" gorm:"not null"`
-	Username  string    `json:"username" gorm:"uniqueIndex;not null"`
-	Email     string    `json:"email" gorm:"uniqueIndex;not null"`
-	Password  string    `json:"-" gorm:"column:password_hash;not null"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                 uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	Name               string     `json:"name" gorm:"not null"`
+	Username           string     `json:"username" gorm:"uniqueIndex;not null"`
+	Email              string     `json:"email" gorm:"uniqueIndex;not null"`
+	Password           string     `json:"-" gorm:"column:password_hash;not null"`
+	TokenVersion       int        `json:"-" gorm:"not null;default:0"`
+	VerifiedAt         *time.Time `json:"verified_at"`
+	VerificationSentAt *time.Time `json:"-"`
+	TOTPSecret         string     `json:"-" gorm:"column:totp_secret"`
+	TOTPLastUsedStep   int64      `json:"-" gorm:"column:totp_last_used_step;not null;default:0"`
+	MFAEnabledAt       *time.Time `json:"mfa_enabled_at" gorm:"column:mfa_enabled_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	// DeletedAt is set when the user deletes their account. The row is kept
+	// for a grace period and then purged.
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // BeforeCreate hook to generate UUID before creating user
@@ -25,27 +34,48 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// LoginRequest identifies the account by username or email. Username is the
+// field older clients send and is used when Identifier is empty.
 type LoginRequest struct {
-	Username string `json:"username" binding:"required,min=3,max=30"`
-	Password string `json:"password" binding:"required,min=6,max=100"`
+	Identifier string `json:"identifier" binding:"max=100"`
+	Username   string `json:"username" binding:"max=100"`
+	Password   string `json:"password" binding:"required,max=100"`
+}
+
+// LoginIdentifier returns the username or email the client signed in with.
+func (r *LoginRequest) LoginIdentifier() string {
+	if r.Identifier != "" {
+		return r.Identifier
+	}
+	return r.Username
 }
 
 type RegisterRequest struct {
 	Name     string `json:"name" binding:"required,min=2,max=50"`
 	Username string `json:"username" binding:"required,min=3,max=30"`
 	Email    string `json:"email" binding:"required,email,max=100"`
-	Password string `json:"password" binding:"required,min=6,max=100"`
-}
-
-type Product struct {
-	ID          int      `json:"id"`
-	Title       string   `json:"title"`
-	Description string   `json:"description"`
-	Price       float64  `json:"price"`
-	Rating      float64  `json:"rating"`
-	Stock       int      `json:"stock"`
-	Brand       string   `json:"brand"`
-	Category    string   `json:"category"`
-	Thumbnail   string   `json:"thumbnail"`
-	Images      []string `json:"images"`
+	Password string `json:"password" binding:"required,max=100"`
+}
+
+// UpdateProfileRequest changes the fields that are present; omitted fields
+// keep their current value.
+type UpdateProfileRequest struct {
+	Name     *string `json:"name" binding:"omitempty,min=2,max=50"`
+	Username *string `json:"username" binding:"omitempty,min=3,max=30"`
+	Email    *string `json:"email" binding:"omitempty,email,max=100"`
+	// CurrentPassword is required when Email changes.
+	CurrentPassword string `json:"current_password"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,max=100"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
 }
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordResetToken is a single-use token emailed to a user who forgot their
+// password. Only the SHA-256 hash of the token is stored.
+type PasswordResetToken struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;index;not null"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID before creating password reset token
+func (t *PasswordResetToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email,max=100"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,max=100"`
+}
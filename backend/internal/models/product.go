@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// Product is an item of the shop's catalog.
+type Product struct {
+	ID          int      `json:"id" gorm:"primaryKey"`
+	Title       string   `json:"title" gorm:"size:200;not null"`
+	Description string   `json:"description" gorm:"type:text"`
+	Price       float64  `json:"price" gorm:"type:numeric(10,2);not null;index"`
+	Rating      float64  `json:"rating" gorm:"type:numeric(3,2);not null;default:0"`
+	Stock       int      `json:"stock" gorm:"not null;default:0"`
+	Brand       string   `json:"brand" gorm:"size:100"`
+	Category    string   `json:"category" gorm:"size:100;not null;index"`
+	Thumbnail   string   `json:"thumbnail" gorm:"size:500"`
+	Images      []string `json:"images" gorm:"type:text;serializer:json"`
+}
+
+// Columns products can be sorted by.
+const (
+	ProductSortTitle  = "title"
+	ProductSortPrice  = "price"
+	ProductSortRating = "rating"
+)
+
+// ProductQuery selects a page of the catalog. Search matches the title,
+// description and brand; nil price bounds are open.
+type ProductQuery struct {
+	Search   string
+	Category string
+	SortBy   string
+	SortDesc bool
+	PriceMin *float64
+	PriceMax *float64
+	Limit    int
+	Skip     int
+}
+
+// ProductPage is one page of a product listing. Total counts every product
+// matching the query, not just this page.
+type ProductPage struct {
+	Products []Product `json:"products"`
+	Total    int64     `json:"total"`
+	Skip     int       `json:"skip"`
+	Limit    int       `json:"limit"`
+}
+
+// ProductReview is a customer's rating of a product.
+type ProductReview struct {
+	ID           int       `json:"id" gorm:"primaryKey"`
+	ProductID    int       `json:"product_id" gorm:"not null;index"`
+	Rating       int       `json:"rating" gorm:"not null;check:rating BETWEEN 1 AND 5"`
+	Comment      string    `json:"comment" gorm:"type:text"`
+	ReviewerName string    `json:"reviewer_name" gorm:"size:100"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RatingBreakdown counts the reviews of a product by rating.
+type RatingBreakdown struct {
+	Count int64 `json:"count"`
+	// Stars maps every rating from 1 to 5 to its number of reviews.
+	Stars map[int]int64 `json:"stars"`
+}
+
+// NewRatingBreakdown returns a breakdown with no reviews.
+func NewRatingBreakdown() RatingBreakdown {
+	return RatingBreakdown{Stars: map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+}
+
+// Add counts count more reviews with rating. Ratings outside 1 to 5 are
+// ignored.
+func (b *RatingBreakdown) Add(rating int, count int64) {
+	if _, ok := b.Stars[rating]; !ok {
+		return
+	}
+	b.Stars[rating] += count
+	b.Count += count
+}
+
+// How much of the catalog a ProductDetail includes.
+const (
+	ProductDetailReviews = 10
+	ProductDetailRelated = 4
+)
+
+// ProductDetail is a product with everything its page shows: its latest
+// ProductDetailReviews reviews, the breakdown of all its ratings, and up to
+// ProductDetailRelated of the best rated other products in its category.
+type ProductDetail struct {
+	Product
+	RatingBreakdown RatingBreakdown `json:"rating_breakdown"`
+	Reviews         []ProductReview `json:"reviews"`
+	Related         []Product       `json:"related"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+)
+
+const (
+	PermissionUsersRead  = "users:read"
+	PermissionUsersWrite = "users:write"
+	PermissionRolesRead  = "roles:read"
+	PermissionRolesWrite = "roles:write"
+	PermissionAuditRead  = "audit:read"
+)
+
+// AllPermissions lists every permission known to the application. The admin
+// role is granted all of them.
+var AllPermissions = []string{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionRolesRead,
+	PermissionRolesWrite,
+	PermissionAuditRead,
+}
+
+// DefaultRoles are created on startup if missing, and their permissions are
+// kept in sync with this list.
+var DefaultRoles = map[string][]string{
+	RoleAdmin:   AllPermissions,
+	RoleSupport: {PermissionUsersRead, PermissionAuditRead},
+}
+
+type Role struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	Name        string       `json:"name" gorm:"uniqueIndex;not null"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+type Permission struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" gorm:"uniqueIndex;not null"`
+}
+
+// UserRole links users to roles. It is the join table for User.Roles.
+type UserRole struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	RoleID    uint      `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+type SetUserRolesRequest struct {
+	Roles []string `json:"roles" binding:"required"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshToken is a long-lived, single-use token that can be exchanged for a
+// new access token. Only the SHA-256 hash of the token is stored. Tokens that
+// descend from the same login share a FamilyID so that reuse of a rotated
+// token can revoke the whole chain.
+type RefreshToken struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;index;not null"`
+	FamilyID  uuid.UUID  `json:"family_id" gorm:"type:uuid;index;not null"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID before creating refresh token
+func (t *RefreshToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevokedToken records the jti of an access token that was explicitly logged
+// out. Rows only need to live until the token would have expired anyway.
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;index;not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
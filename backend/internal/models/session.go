@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is one signed-in device. Its ID is the family ID of the refresh
+// tokens issued for the login, and access tokens carry it in the "sid" claim.
+type Session struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID  `json:"-" gorm:"type:uuid;index;not null"`
+	UserAgent  string     `json:"user_agent" gorm:"size:512"`
+	IPAddress  string     `json:"ip_address" gorm:"size:64"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at" gorm:"not null"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt  *time.Time `json:"-"`
+}
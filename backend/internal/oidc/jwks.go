@@ -0,0 +1,156 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// keyRefreshInterval limits how often an unknown kid triggers a JWKS refetch.
+const keyRefreshInterval = time.Minute
+
+type keySet struct {
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// signingKey returns the provider key with the given kid. Providers rotate
+// keys, so an unknown kid refetches the key set, at most once per
+// keyRefreshInterval.
+func (p *Provider) signingKey(ctx context.Context, ep *endpoints, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys.lookup(kid); ok {
+		return key, nil
+	}
+	if p.keys != nil && time.Since(p.keys.fetchedAt) < keyRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := p.fetchKeys(ctx, ep.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+
+	if key, ok := p.keys.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (s *keySet) lookup(kid string) (interface{}, bool) {
+	if s == nil {
+		return nil, false
+	}
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (p *Provider) fetchKeys(ctx context.Context, jwksURI string) (*keySet, error) {
+	if jwksURI == "" {
+		return nil, errors.New("provider has no jwks_uri")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.doJSON(req, &doc); err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+
+	set := &keySet{keys: map[string]interface{}{}, fetchedAt: time.Now()}
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of unsupported types are skipped rather than failing the set.
+		if key, err := jwk.publicKey(); err == nil {
+			set.keys[jwk.Kid] = key
+		}
+	}
+	return set, nil
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,360 @@
+// Package oidc implements the client side of the OpenID Connect
+// authorization code flow with PKCE. Providers are discovered from their
+// issuer, or configured with explicit endpoints for plain OAuth2 providers
+// that expose an OIDC-style userinfo endpoint.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Config describes a single identity provider.
+type Config struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	// Explicit endpoints, used instead of discovery when Issuer is empty.
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	// EmailsURL lists the addresses of the account, for providers whose
+	// userinfo does not say whether the email is verified. When set, the
+	// verified primary address from this list is used.
+	EmailsURL string
+}
+
+// Presets holds the settings of well-known providers without discovery.
+// Configured values take precedence.
+var Presets = map[string]Config{
+	"github": {
+		AuthURL:     "https://github.com/login/oauth/authorize",
+		TokenURL:    "https://github.com/login/oauth/access_token",
+		UserInfoURL: "https://api.github.com/user",
+		EmailsURL:   "https://api.github.com/user/emails",
+		Scopes:      []string{"read:user", "user:email"},
+	},
+}
+
+// Claims is the identity asserted by the provider.
+type Claims struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+type endpoints struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider talks to one identity provider. Discovery metadata and signing
+// keys are fetched lazily and cached, so a provider that is down at startup
+// does not keep the server from booting.
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu        sync.Mutex
+	endpoints *endpoints
+	keys      *keySet
+}
+
+// NewProvider creates a provider. A nil client uses a client with a
+// 10 second timeout.
+func NewProvider(config Config, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &Provider{config: config, client: client}
+}
+
+// Name returns the provider name used in URLs and stored identities.
+func (p *Provider) Name() string {
+	return p.config.Name
+}
+
+// AuthCodeURL builds the URL the browser is sent to. codeChallenge is the
+// S256 PKCE challenge derived from the verifier kept by the caller.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(ep.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+
+	q := authURL.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.config.ClientID)
+	q.Set("redirect_uri", p.config.RedirectURL)
+	q.Set("scope", strings.Join(p.config.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+	authURL.RawQuery = q.Encode()
+
+	return authURL.String(), nil
+}
+
+// Exchange redeems an authorization code and returns the verified identity.
+// When the provider returns an ID token its signature, issuer, audience,
+// expiry and nonce are checked; otherwise the userinfo endpoint is queried.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"client_secret": {p.config.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		IDToken     string `json:"id_token"`
+		Error       string `json:"error"`
+	}
+	if err := p.doJSON(req, &token); err != nil {
+		return nil, fmt.Errorf("token exchange failed: %w", err)
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("token exchange failed: %s", token.Error)
+	}
+
+	if token.IDToken != "" {
+		return p.verifyIDToken(ctx, ep, token.IDToken, nonce)
+	}
+	if ep.UserInfoEndpoint != "" && token.AccessToken != "" {
+		return p.userInfo(ctx, ep, token.AccessToken)
+	}
+	return nil, errors.New("provider returned neither an id token nor a usable access token")
+}
+
+func (p *Provider) verifyIDToken(ctx context.Context, ep *endpoints, rawToken, nonce string) (*Claims, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.signingKey(ctx, ep, kid)
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, claims, keyFunc,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(ep.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+
+	if got, _ := claims["nonce"].(string); got == "" || got != nonce {
+		return nil, errors.New("invalid id token: nonce mismatch")
+	}
+	// With several audiences the authorized party must be this client.
+	if azp, ok := claims["azp"].(string); ok && azp != p.config.ClientID {
+		return nil, errors.New("invalid id token: unexpected authorized party")
+	}
+
+	return claimsFromMap(claims)
+}
+
+func (p *Provider) userInfo(ctx context.Context, ep *endpoints, accessToken string) (*Claims, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ep.UserInfoEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	claims := map[string]interface{}{}
+	if err := p.doJSON(req, &claims); err != nil {
+		return nil, fmt.Errorf("userinfo request failed: %w", err)
+	}
+	identity, err := claimsFromMap(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.config.EmailsURL != "" {
+		identity.Email, identity.EmailVerified, err = p.primaryEmail(ctx, accessToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return identity, nil
+}
+
+// primaryEmail returns the primary address from the emails endpoint, in the
+// shape GitHub uses. An account whose primary address is unverified gets the
+// address back unverified.
+func (p *Provider) primaryEmail(ctx context.Context, accessToken string) (string, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.EmailsURL, nil)
+	if err != nil {
+		return "", false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.doJSON(req, &emails); err != nil {
+		return "", false, fmt.Errorf("emails request failed: %w", err)
+	}
+	for _, email := range emails {
+		if email.Primary {
+			return email.Email, email.Verified, nil
+		}
+	}
+	return "", false, nil
+}
+
+// discover returns the provider endpoints, fetching the discovery document
+// on first use.
+func (p *Provider) discover(ctx context.Context) (*endpoints, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.endpoints != nil {
+		return p.endpoints, nil
+	}
+
+	if p.config.Issuer == "" {
+		p.endpoints = &endpoints{
+			AuthorizationEndpoint: p.config.AuthURL,
+			TokenEndpoint:         p.config.TokenURL,
+			UserInfoEndpoint:      p.config.UserInfoURL,
+		}
+		return p.endpoints, nil
+	}
+
+	issuer := strings.TrimRight(p.config.Issuer, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var ep endpoints
+	if err := p.doJSON(req, &ep); err != nil {
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+	// The issuer in the document must be the one we were configured with,
+	// otherwise tokens from another issuer could be accepted.
+	if strings.TrimRight(ep.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery failed: issuer %q does not match %q", ep.Issuer, p.config.Issuer)
+	}
+	if ep.AuthorizationEndpoint == "" || ep.TokenEndpoint == "" || ep.JWKSURI == "" {
+		return nil, errors.New("discovery failed: missing required endpoints")
+	}
+
+	p.endpoints = &ep
+	return p.endpoints, nil
+}
+
+func (p *Provider) doJSON(req *http.Request, out interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
+
+func claimsFromMap(m map[string]interface{}) (*Claims, error) {
+	claims := &Claims{
+		Email:             stringClaim(m, "email"),
+		Name:              stringClaim(m, "name"),
+		PreferredUsername: stringClaim(m, "preferred_username"),
+	}
+
+	if claims.PreferredUsername == "" {
+		claims.PreferredUsername = stringClaim(m, "login")
+	}
+
+	// Some providers send numeric subjects or "true" as a string. Plain
+	// OAuth2 providers such as GitHub identify the account by "id" instead.
+	sub, ok := m["sub"]
+	if !ok {
+		sub = m["id"]
+	}
+	switch sub := sub.(type) {
+	case string:
+		claims.Subject = sub
+	case float64:
+		claims.Subject = fmt.Sprintf("%.0f", sub)
+	}
+	switch verified := m["email_verified"].(type) {
+	case bool:
+		claims.EmailVerified = verified
+	case string:
+		claims.EmailVerified = verified == "true"
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("identity has no subject")
+	}
+	return claims, nil
+}
+
+func stringClaim(m map[string]interface{}, key string) string {
+	value, _ := m[key].(string)
+	return value
+}
+
+// CodeChallenge returns the S256 PKCE challenge for a code verifier.
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository defines the interface for API key data operations
+type APIKeyRepository interface {
+	Create(key *models.APIKey) error
+	GetByID(id uuid.UUID) (*models.APIKey, error)
+	GetByHash(keyHash string) (*models.APIKey, error)
+	ListForUser(userID uuid.UUID) ([]models.APIKey, error)
+	Touch(id uuid.UUID, ipAddress string, usedAt time.Time, staleBefore time.Time) error
+	Revoke(id uuid.UUID) (bool, error)
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByID(id uuid.UUID) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.Where("id = ?", id).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetByHash(keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.Where("key_hash = ?", keyHash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListForUser returns all of the user's keys, including revoked ones, newest
+// first.
+func (r *apiKeyRepository) ListForUser(userID uuid.UUID) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// Touch records a use of the key, but only when the stored value is older
+// than staleBefore, so requests do not each cost a write.
+func (r *apiKeyRepository) Touch(id uuid.UUID, ipAddress string, usedAt time.Time, staleBefore time.Time) error {
+	return r.db.Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, staleBefore).
+		Updates(map[string]interface{}{
+			"last_used_at": usedAt,
+			"last_used_ip": ipAddress,
+		}).Error
+}
+
+// Revoke disables the key. It reports false when the key does not exist or
+// was already revoked.
+func (r *apiKeyRepository) Revoke(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"mobile-shop-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuthEventRepository defines the interface for the security audit log. The
+// log is append-only, so there is no way to change or remove entries; only
+// UserRepository.PurgeDeleted pseudonymizes them.
+type AuthEventRepository interface {
+	Create(event *models.AuthEvent) error
+	List(filter models.AuthEventFilter, offset, limit int) ([]models.AuthEvent, int64, error)
+	ListForUser(userID uuid.UUID) ([]models.AuthEvent, error)
+}
+
+type authEventRepository struct {
+	db *gorm.DB
+}
+
+// NewAuthEventRepository creates a new auth event repository
+func NewAuthEventRepository(db *gorm.DB) AuthEventRepository {
+	return &authEventRepository{db: db}
+}
+
+func (r *authEventRepository) Create(event *models.AuthEvent) error {
+	return r.db.Create(event).Error
+}
+
+// List returns the events matching filter, newest first, and how many match
+// in total.
+func (r *authEventRepository) List(filter models.AuthEventFilter, offset, limit int) ([]models.AuthEvent, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.AuthEvent{}).Scopes(matchingAuthEvents(filter)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var events []models.AuthEvent
+	err := r.db.Scopes(matchingAuthEvents(filter)).Order("created_at DESC").Offset(offset).Limit(limit).Find(&events).Error
+	return events, total, err
+}
+
+// ListForUser returns every event of a user, newest first.
+func (r *authEventRepository) ListForUser(userID uuid.UUID) ([]models.AuthEvent, error) {
+	var events []models.AuthEvent
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&events).Error
+	return events, err
+}
+
+// matchingAuthEvents applies the conditions of filter.
+func matchingAuthEvents(filter models.AuthEventFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.Type != "" {
+			db = db.Where("type = ?", filter.Type)
+		}
+		if len(filter.ExcludeTypes) > 0 {
+			db = db.Where("type NOT IN ?", filter.ExcludeTypes)
+		}
+		if filter.UserID != nil {
+			db = db.Where("user_id = ?", *filter.UserID)
+		}
+		if filter.Outcome != "" {
+			db = db.Where("outcome = ?", filter.Outcome)
+		}
+		if filter.IPAddress != "" {
+			db = db.Where("ip_address = ?", filter.IPAddress)
+		}
+		if filter.From != nil {
+			db = db.Where("created_at >= ?", *filter.From)
+		}
+		if filter.To != nil {
+			db = db.Where("created_at < ?", *filter.To)
+		}
+		return db
+	}
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"mobile-shop-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IdentityRepository defines the interface for linked external identity data operations
+type IdentityRepository interface {
+	Create(identity *models.Identity) error
+	GetByProviderSubject(provider, subject string) (*models.Identity, error)
+	ListForUser(userID uuid.UUID) ([]models.Identity, error)
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+// NewIdentityRepository creates a new identity repository
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) Create(identity *models.Identity) error {
+	return r.db.Create(identity).Error
+}
+
+func (r *identityRepository) GetByProviderSubject(provider, subject string) (*models.Identity, error) {
+	var identity models.Identity
+	err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) ListForUser(userID uuid.UUID) ([]models.Identity, error) {
+	var identities []models.Identity
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	return identities, err
+}
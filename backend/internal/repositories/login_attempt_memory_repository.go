@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"mobile-shop-backend/internal/models"
+	"sync"
+	"time"
+)
+
+// memoryPruneInterval is how many writes happen between sweeps of stale entries.
+const memoryPruneInterval = 1000
+
+type memoryLoginAttemptRepository struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempt
+	writes   int
+	maxAge   time.Duration
+}
+
+// NewMemoryLoginAttemptRepository creates an in-process login attempt
+// repository. Counters are lost on restart and not shared between instances.
+// Entries idle for longer than maxAge are pruned periodically.
+func NewMemoryLoginAttemptRepository(maxAge time.Duration) LoginAttemptRepository {
+	return &memoryLoginAttemptRepository{
+		attempts: make(map[string]models.LoginAttempt),
+		maxAge:   maxAge,
+	}
+}
+
+func (r *memoryLoginAttemptRepository) Get(key string) (*models.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok {
+		return &models.LoginAttempt{Key: key}, nil
+	}
+	return &attempt, nil
+}
+
+func (r *memoryLoginAttemptRepository) RecordFailure(key string, at time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok || attempt.LastFailureAt.Before(at.Add(-window)) {
+		attempt.Key = key
+		attempt.Failures = 0
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = at
+	attempt.UpdatedAt = at
+	r.attempts[key] = attempt
+
+	r.writes++
+	if r.writes%memoryPruneInterval == 0 {
+		r.prune(at)
+	}
+
+	return &attempt, nil
+}
+
+func (r *memoryLoginAttemptRepository) Lock(key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok {
+		attempt.Key = key
+	}
+	attempt.LockedUntil = &until
+	attempt.UpdatedAt = time.Now()
+	r.attempts[key] = attempt
+	return nil
+}
+
+func (r *memoryLoginAttemptRepository) Reset(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
+
+// prune drops entries that are neither recent nor locked. Callers must hold r.mu.
+func (r *memoryLoginAttemptRepository) prune(now time.Time) {
+	for key, attempt := range r.attempts {
+		locked := attempt.LockedUntil != nil && attempt.LockedUntil.After(now)
+		if !locked && attempt.LastFailureAt.Before(now.Add(-r.maxAge)) {
+			delete(r.attempts, key)
+		}
+	}
+}
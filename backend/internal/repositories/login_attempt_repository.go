@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"errors"
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginAttemptRepository stores failed login counters. Implementations must
+// be safe for concurrent use.
+type LoginAttemptRepository interface {
+	// Get returns the attempt record for key, or a zero record when none exists.
+	Get(key string) (*models.LoginAttempt, error)
+	// RecordFailure increments the failure counter for key. Counters whose
+	// last failure is older than window start again from one.
+	RecordFailure(key string, at time.Time, window time.Duration) (*models.LoginAttempt, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+}
+
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptRepository creates a Postgres-backed login attempt repository,
+// which shares counters between all server instances.
+func NewLoginAttemptRepository(db *gorm.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+func (r *loginAttemptRepository) Get(key string) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := r.db.Where("key = ?", key).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.LoginAttempt{Key: key}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (r *loginAttemptRepository) RecordFailure(key string, at time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	attempt := models.LoginAttempt{Key: key, Failures: 1, LastFailureAt: at}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"failures":        gorm.Expr("CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END", at.Add(-window)),
+			"last_failure_at": at,
+			"updated_at":      at,
+		}),
+	}).Create(&attempt).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(key)
+}
+
+func (r *loginAttemptRepository) Lock(key string, until time.Time) error {
+	return r.db.Model(&models.LoginAttempt{}).Where("key = ?", key).Update("locked_until", until).Error
+}
+
+func (r *loginAttemptRepository) Reset(key string) error {
+	return r.db.Where("key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
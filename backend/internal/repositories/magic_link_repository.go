@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MagicLinkRepository defines the interface for magic link data operations
+type MagicLinkRepository interface {
+	Create(link *models.MagicLink) error
+	GetLatestForUser(userID uuid.UUID) (*models.MagicLink, error)
+	MarkUsed(id uuid.UUID, userID uuid.UUID, usedAt time.Time) (bool, error)
+	DeleteForUser(userID uuid.UUID) error
+}
+
+type magicLinkRepository struct {
+	db *gorm.DB
+}
+
+// NewMagicLinkRepository creates a new magic link repository
+func NewMagicLinkRepository(db *gorm.DB) MagicLinkRepository {
+	return &magicLinkRepository{db: db}
+}
+
+func (r *magicLinkRepository) Create(link *models.MagicLink) error {
+	return r.db.Create(link).Error
+}
+
+// GetLatestForUser returns the most recently sent link.
+func (r *magicLinkRepository) GetLatestForUser(userID uuid.UUID) (*models.MagicLink, error) {
+	var link models.MagicLink
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// MarkUsed consumes the user's link. It reports false when the link does not
+// exist, was already used or has expired, so a link can never sign in twice.
+func (r *magicLinkRepository) MarkUsed(id uuid.UUID, userID uuid.UUID, usedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.MagicLink{}).
+		Where("id = ? AND user_id = ? AND used_at IS NULL AND expires_at > ?", id, userID, usedAt).
+		Update("used_at", usedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteForUser removes every outstanding link for the user.
+func (r *magicLinkRepository) DeleteForUser(userID uuid.UUID) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.MagicLink{}).Error
+}
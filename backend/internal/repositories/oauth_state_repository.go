@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OAuthStateRepository defines the interface for pending authorization request data operations
+type OAuthStateRepository interface {
+	Create(state *models.OAuthState) error
+	Consume(stateHash string) (*models.OAuthState, error)
+	DeleteExpired(now time.Time) error
+}
+
+type oauthStateRepository struct {
+	db *gorm.DB
+}
+
+// NewOAuthStateRepository creates a new OAuth state repository
+func NewOAuthStateRepository(db *gorm.DB) OAuthStateRepository {
+	return &oauthStateRepository{db: db}
+}
+
+func (r *oauthStateRepository) Create(state *models.OAuthState) error {
+	return r.db.Create(state).Error
+}
+
+// Consume deletes and returns the state in one statement, so a callback can
+// only be completed once.
+func (r *oauthStateRepository) Consume(stateHash string) (*models.OAuthState, error) {
+	var states []models.OAuthState
+	result := r.db.Clauses(clause.Returning{}).Where("state_hash = ?", stateHash).Delete(&states)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(states) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &states[0], nil
+}
+
+func (r *oauthStateRepository) DeleteExpired(now time.Time) error {
+	return r.db.Where("expires_at < ?", now).Delete(&models.OAuthState{}).Error
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordResetRepository defines the interface for password reset token data operations
+type PasswordResetRepository interface {
+	Create(token *models.PasswordResetToken) error
+	GetByHash(tokenHash string) (*models.PasswordResetToken, error)
+	GetLatestForUser(userID uuid.UUID) (*models.PasswordResetToken, error)
+	MarkUsed(id uuid.UUID, usedAt time.Time) (bool, error)
+	DeleteForUser(userID uuid.UUID) error
+}
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetRepository creates a new password reset token repository
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+func (r *passwordResetRepository) Create(token *models.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *passwordResetRepository) GetByHash(tokenHash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetLatestForUser returns the most recently issued token.
+func (r *passwordResetRepository) GetLatestForUser(userID uuid.UUID) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed consumes the token. It reports false when the token was already
+// used, so a token can never reset a password twice.
+func (r *passwordResetRepository) MarkUsed(id uuid.UUID, usedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteForUser removes every outstanding reset token for the user.
+func (r *passwordResetRepository) DeleteForUser(userID uuid.UUID) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.PasswordResetToken{}).Error
+}
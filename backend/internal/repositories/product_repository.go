@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"mobile-shop-backend/internal/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// likeEscaper escapes the wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ProductRepository defines the interface for product data operations
+type ProductRepository interface {
+	List(query models.ProductQuery) ([]models.Product, int64, error)
+	GetByID(id int) (*models.Product, error)
+	Related(product *models.Product, limit int) ([]models.Product, error)
+	Reviews(productID, limit int) ([]models.ProductReview, error)
+	RatingCounts(productID int) (map[int]int64, error)
+	Categories() ([]string, error)
+	Count() (int64, error)
+	CreateBatch(products []models.Product, reviews []models.ProductReview) error
+}
+
+type productRepository struct {
+	db *gorm.DB
+}
+
+// NewProductRepository creates a new product repository
+func NewProductRepository(db *gorm.DB) ProductRepository {
+	return &productRepository{db: db}
+}
+
+// List returns the page of products selected by query and how many products
+// match it in total. query.SortBy must be one of the models.ProductSort
+// columns.
+func (r *productRepository) List(query models.ProductQuery) ([]models.Product, int64, error) {
+	var total int64
+	if err := r.db.Model(&models.Product{}).Scopes(matchingProducts(query)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := query.SortBy
+	if query.SortDesc {
+		order += " DESC"
+	}
+	// The ID breaks ties so pages never overlap.
+	var products []models.Product
+	err := r.db.Scopes(matchingProducts(query)).Order(order).Order("id").Offset(query.Skip).Limit(query.Limit).Find(&products).Error
+	return products, total, err
+}
+
+// matchingProducts applies the filters of query.
+func matchingProducts(query models.ProductQuery) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if query.Search != "" {
+			pattern := "%" + likeEscaper.Replace(query.Search) + "%"
+			db = db.Where("title ILIKE ? OR description ILIKE ? OR brand ILIKE ?", pattern, pattern, pattern)
+		}
+		if query.Category != "" {
+			db = db.Where("category = ?", query.Category)
+		}
+		if query.PriceMin != nil {
+			db = db.Where("price >= ?", *query.PriceMin)
+		}
+		if query.PriceMax != nil {
+			db = db.Where("price <= ?", *query.PriceMax)
+		}
+		return db
+	}
+}
+
+func (r *productRepository) GetByID(id int) (*models.Product, error) {
+	var product models.Product
+	err := r.db.First(&product, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// Related returns the best rated other products in the category of product.
+func (r *productRepository) Related(product *models.Product, limit int) ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.Where("category = ? AND id <> ?", product.Category, product.ID).
+		Order("rating DESC").Order("id").Limit(limit).Find(&products).Error
+	return products, err
+}
+
+// Reviews returns the latest reviews of a product.
+func (r *productRepository) Reviews(productID, limit int) ([]models.ProductReview, error) {
+	var reviews []models.ProductReview
+	err := r.db.Where("product_id = ?", productID).Order("created_at DESC").Order("id DESC").Limit(limit).Find(&reviews).Error
+	return reviews, err
+}
+
+// RatingCounts counts the reviews of a product by rating. Ratings nobody
+// gave are missing.
+func (r *productRepository) RatingCounts(productID int) (map[int]int64, error) {
+	var rows []struct {
+		Rating int
+		Count  int64
+	}
+	err := r.db.Model(&models.ProductReview{}).Select("rating, COUNT(*) AS count").
+		Where("product_id = ?", productID).Group("rating").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Rating] = row.Count
+	}
+	return counts, nil
+}
+
+// Categories returns every category that has products, in alphabetical order.
+func (r *productRepository) Categories() ([]string, error) {
+	var categories []string
+	err := r.db.Model(&models.Product{}).Distinct("category").Order("category").Pluck("category", &categories).Error
+	return categories, err
+}
+
+func (r *productRepository) Count() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Product{}).Count(&count).Error
+	return count, err
+}
+
+// CreateBatch inserts products and their reviews with the IDs they already
+// have, and moves the ID sequences past them.
+func (r *productRepository) CreateBatch(products []models.Product, reviews []models.ProductReview) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(products, 100).Error; err != nil {
+			return err
+		}
+		if len(reviews) > 0 {
+			if err := tx.CreateInBatches(reviews, 100).Error; err != nil {
+				return err
+			}
+		}
+		for _, table := range []string{"products", "product_reviews"} {
+			if err := tx.Exec("SELECT setval(pg_get_serial_sequence(?, 'id'), (SELECT COALESCE(MAX(id), 0) + 1 FROM "+table+"), false)", table).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
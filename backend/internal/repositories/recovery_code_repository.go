@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeRepository defines the interface for MFA recovery code data operations
+type RecoveryCodeRepository interface {
+	ReplaceForUser(userID uuid.UUID, codeHashes []string) error
+	Use(userID uuid.UUID, codeHash string, usedAt time.Time) (bool, error)
+	CountUnused(userID uuid.UUID) (int64, error)
+	DeleteForUser(userID uuid.UUID) error
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewRecoveryCodeRepository creates a new recovery code repository
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+// ReplaceForUser atomically swaps the user's recovery codes for a new set.
+func (r *recoveryCodeRepository) ReplaceForUser(userID uuid.UUID, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]models.RecoveryCode, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Use consumes an unused recovery code. It reports false when no such code exists.
+func (r *recoveryCodeRepository) Use(userID uuid.UUID, codeHash string, usedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", usedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *recoveryCodeRepository) CountUnused(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *recoveryCodeRepository) DeleteForUser(userID uuid.UUID) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenRepository defines the interface for refresh token data operations
+type RefreshTokenRepository interface {
+	Create(token *models.RefreshToken) error
+	GetByHash(tokenHash string) (*models.RefreshToken, error)
+	MarkUsed(id uuid.UUID, usedAt time.Time) (bool, error)
+	RevokeFamily(familyID uuid.UUID) error
+	RevokeAllForUser(userID uuid.UUID) error
+	RevokeOthersForUser(userID uuid.UUID, exceptFamilyID uuid.UUID) error
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(token *models.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *refreshTokenRepository) GetByHash(tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed flags the token as consumed. It reports false when the token had
+// already been used or revoked, so concurrent refreshes cannot both succeed.
+func (r *refreshTokenRepository) MarkUsed(id uuid.UUID, usedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", usedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *refreshTokenRepository) RevokeFamily(familyID uuid.UUID) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *refreshTokenRepository) RevokeAllForUser(userID uuid.UUID) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeOthersForUser revokes the user's refresh tokens except those of one family.
+func (r *refreshTokenRepository) RevokeOthersForUser(userID uuid.UUID, exceptFamilyID uuid.UUID) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, exceptFamilyID).
+		Update("revoked_at", time.Now()).Error
+}
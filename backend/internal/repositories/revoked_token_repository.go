@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevokedTokenRepository defines the interface for the access token denylist
+type RevokedTokenRepository interface {
+	Revoke(token *models.RevokedToken) error
+	IsRevoked(jti string) (bool, error)
+	DeleteExpired(before time.Time) error
+}
+
+type revokedTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRevokedTokenRepository creates a new revoked token repository
+func NewRevokedTokenRepository(db *gorm.DB) RevokedTokenRepository {
+	return &revokedTokenRepository{db: db}
+}
+
+func (r *revokedTokenRepository) Revoke(token *models.RevokedToken) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+func (r *revokedTokenRepository) IsRevoked(jti string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *revokedTokenRepository) DeleteExpired(before time.Time) error {
+	return r.db.Where("expires_at < ?", before).Delete(&models.RevokedToken{}).Error
+}
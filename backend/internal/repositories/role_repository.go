@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"mobile-shop-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RoleRepository defines the interface for role and permission data operations
+type RoleRepository interface {
+	EnsureRole(name string, permissions []string) error
+	List() ([]models.Role, error)
+	GetByNames(names []string) ([]models.Role, error)
+	GetUserRoles(userID uuid.UUID) ([]models.Role, error)
+	GetRoleNamesForUsers(userIDs []uuid.UUID) (map[uuid.UUID][]string, error)
+	SetUserRoles(userID uuid.UUID, roleIDs []uint) error
+	CountUsersWithRole(name string) (int64, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository creates a new role repository
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// EnsureRole creates the role and any missing permissions, then sets the
+// role's permissions to exactly the given list.
+func (r *roleRepository) EnsureRole(name string, permissions []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		role := models.Role{Name: name}
+		if err := tx.Where("name = ?", name).FirstOrCreate(&role).Error; err != nil {
+			return err
+		}
+
+		perms := make([]models.Permission, len(permissions))
+		for i, permName := range permissions {
+			perms[i] = models.Permission{Name: permName}
+			if err := tx.Where("name = ?", permName).FirstOrCreate(&perms[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&role).Association("Permissions").Replace(perms)
+	})
+}
+
+func (r *roleRepository) List() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Preload("Permissions").Order("name").Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepository) GetByNames(names []string) ([]models.Role, error) {
+	var roles []models.Role
+	if len(names) == 0 {
+		return roles, nil
+	}
+	err := r.db.Preload("Permissions").Where("name IN ?", names).Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepository) GetUserRoles(userID uuid.UUID) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Find(&roles).Error
+	return roles, err
+}
+
+// GetRoleNamesForUsers loads the role names of several users in one query.
+// Users without roles are absent from the result.
+func (r *roleRepository) GetRoleNamesForUsers(userIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	roleNames := make(map[uuid.UUID][]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return roleNames, nil
+	}
+
+	var rows []struct {
+		UserID uuid.UUID
+		Name   string
+	}
+	err := r.db.Model(&models.UserRole{}).
+		Select("user_roles.user_id, roles.name").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("user_roles.user_id IN ?", userIDs).
+		Order("roles.name").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		roleNames[row.UserID] = append(roleNames[row.UserID], row.Name)
+	}
+	return roleNames, nil
+}
+
+func (r *roleRepository) SetUserRoles(userID uuid.UUID, roleIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+		if len(roleIDs) == 0 {
+			return nil
+		}
+		userRoles := make([]models.UserRole, len(roleIDs))
+		for i, roleID := range roleIDs {
+			userRoles[i] = models.UserRole{UserID: userID, RoleID: roleID}
+		}
+		return tx.Create(&userRoles).Error
+	})
+}
+
+func (r *roleRepository) CountUsersWithRole(name string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.UserRole{}).
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("roles.name = ?", name).
+		Count(&count).Error
+	return count, err
+}
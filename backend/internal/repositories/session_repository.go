@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SessionRepository defines the interface for session data operations
+type SessionRepository interface {
+	Create(session *models.Session) error
+	GetByID(id uuid.UUID) (*models.Session, error)
+	ListActiveForUser(userID uuid.UUID, now time.Time) ([]models.Session, error)
+	ListForUser(userID uuid.UUID) ([]models.Session, error)
+	Refresh(id uuid.UUID, ipAddress, userAgent string, lastSeenAt, expiresAt time.Time) error
+	Touch(id uuid.UUID, ipAddress string, lastSeenAt time.Time, staleBefore time.Time) error
+	Revoke(id uuid.UUID, userID uuid.UUID) (bool, error)
+	RevokeAllForUser(userID uuid.UUID) error
+	RevokeOthersForUser(userID uuid.UUID, exceptID uuid.UUID) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *sessionRepository) GetByID(id uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	err := r.db.Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListActiveForUser returns the sessions that are neither revoked nor
+// expired, most recently used first.
+func (r *sessionRepository) ListActiveForUser(userID uuid.UUID, now time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// ListForUser returns all of the user's sessions, including ended ones,
+// newest first.
+func (r *sessionRepository) ListForUser(userID uuid.UUID) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// Refresh records a token refresh, which extends the session.
+func (r *sessionRepository) Refresh(id uuid.UUID, ipAddress, userAgent string, lastSeenAt, expiresAt time.Time) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"ip_address":   ipAddress,
+			"user_agent":   userAgent,
+			"last_seen_at": lastSeenAt,
+			"expires_at":   expiresAt,
+		}).Error
+}
+
+// Touch updates the last seen time, but only when the stored value is older
+// than staleBefore, so authenticated requests do not each cost a write.
+func (r *sessionRepository) Touch(id uuid.UUID, ipAddress string, lastSeenAt time.Time, staleBefore time.Time) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ? AND last_seen_at < ?", id, staleBefore).
+		Updates(map[string]interface{}{
+			"ip_address":   ipAddress,
+			"last_seen_at": lastSeenAt,
+		}).Error
+}
+
+// Revoke ends the user's session. It reports false when no active session
+// with that ID belongs to the user.
+func (r *sessionRepository) Revoke(id uuid.UUID, userID uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *sessionRepository) RevokeAllForUser(userID uuid.UUID) error {
+	return r.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeOthersForUser revokes the user's sessions except those of one session.
+func (r *sessionRepository) RevokeOthersForUser(userID uuid.UUID, exceptID uuid.UUID) error {
+	return r.db.Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, exceptID).
+		Update("revoked_at", time.Now()).Error
+}
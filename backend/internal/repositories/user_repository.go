@@ -2,19 +2,33 @@ package repositories
 
 import (
 	"mobile-shop-backend/internal/models"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-// UserRepository defines the interface for user data operations
+// UserRepository defines the interface for user data operations. Emails and
+// usernames are matched case-insensitively. Lookups ignore soft-deleted
+// users, but their email and username stay taken until the account is purged.
 type UserRepository interface {
 	Create(user *models.User) error
 	GetByEmail(email string) (*models.User, error)
 	GetByUsername(username string) (*models.User, error)
 	GetByID(id uuid.UUID) (*models.User, error)
+	List(offset, limit int) ([]models.User, int64, error)
 	EmailExists(email string) (bool, error)
 	UsernameExists(username string) (bool, error)
+	UpdateProfile(user *models.User) error
+	UpdatePassword(id uuid.UUID, passwordHash string) error
+	IncrementTokenVersion(id uuid.UUID) error
+	MarkEmailVerified(id uuid.UUID, verifiedAt time.Time) error
+	SetVerificationSentAt(id uuid.UUID, sentAt time.Time) error
+	UpdateMFA(id uuid.UUID, totpSecret string, enabledAt *time.Time) error
+	AdvanceTOTPStep(id uuid.UUID, step int64) (bool, error)
+	SoftDelete(id uuid.UUID) error
+	PurgeDeleted(before time.Time) (int64, error)
 }
 
 type userRepository struct {
@@ -32,7 +46,7 @@ func (r *userRepository) Create(user *models.User) error {
 
 func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.Where("LOWER(email) = LOWER(?)", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +55,7 @@ func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 
 func (r *userRepository) GetByUsername(username string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := r.db.Where("LOWER(username) = LOWER(?)", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -57,14 +71,148 @@ func (r *userRepository) GetByID(id uuid.UUID) (*models.User, error) {
 	return &user, nil
 }
 
+func (r *userRepository) List(offset, limit int) ([]models.User, int64, error) {
+	var users []models.User
+	var total int64
+	if err := r.db.Model(&models.User{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := r.db.Order("created_at DESC").Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
+
 func (r *userRepository) EmailExists(email string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Where("email = ?", email).Count(&count).Error
+	err := r.db.Unscoped().Model(&models.User{}).Where("LOWER(email) = LOWER(?)", email).Count(&count).Error
 	return count > 0, err
 }
 
 func (r *userRepository) UsernameExists(username string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Where("username = ?", username).Count(&count).Error
+	err := r.db.Unscoped().Model(&models.User{}).Where("LOWER(username) = LOWER(?)", username).Count(&count).Error
 	return count > 0, err
 }
+
+// UpdateProfile saves the user's name, username, email and email
+// verification state.
+func (r *userRepository) UpdateProfile(user *models.User) error {
+	return r.db.Model(user).Select("name", "username", "email", "verified_at", "verification_sent_at").Updates(user).Error
+}
+
+func (r *userRepository) UpdatePassword(id uuid.UUID, passwordHash string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("password_hash", passwordHash).Error
+}
+
+func (r *userRepository) IncrementTokenVersion(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error
+}
+
+func (r *userRepository) MarkEmailVerified(id uuid.UUID, verifiedAt time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ? AND verified_at IS NULL", id).Update("verified_at", verifiedAt).Error
+}
+
+func (r *userRepository) SetVerificationSentAt(id uuid.UUID, sentAt time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("verification_sent_at", sentAt).Error
+}
+
+// UpdateMFA stores the TOTP secret and whether MFA is enabled. An empty secret
+// with a nil enabledAt turns MFA off.
+func (r *userRepository) UpdateMFA(id uuid.UUID, totpSecret string, enabledAt *time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"totp_secret":         totpSecret,
+		"mfa_enabled_at":      enabledAt,
+		"totp_last_used_step": 0,
+	}).Error
+}
+
+// AdvanceTOTPStep records step as the last accepted TOTP step. It reports
+// false if a code from the same or a later step was already used.
+func (r *userRepository) AdvanceTOTPStep(id uuid.UUID, step int64) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_used_step < ?", id, step).
+		Update("totp_last_used_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// SoftDelete marks the user deleted. The account disappears from every
+// lookup but its data is kept until PurgeDeleted removes it.
+func (r *userRepository) SoftDelete(id uuid.UUID) error {
+	return r.db.Where("id = ?", id).Delete(&models.User{}).Error
+}
+
+// PurgeDeleted permanently removes users deleted before the cutoff together
+// with everything that references them, including their login throttling
+// state, which releases their email and username. Their audit log entries
+// are kept without anything identifying them. It returns the number of
+// purged users.
+func (r *userRepository) PurgeDeleted(before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var users []models.User
+		if err := tx.Unscoped().Select("id", "email", "username").
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Find(&users).Error; err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(users))
+		attemptKeys := make([]string, 0, 3*len(users))
+		for i := range users {
+			ids[i] = users[i].ID
+			attemptKeys = append(attemptKeys, models.LoginAttemptKeys(&users[i])...)
+		}
+
+		if err := pseudonymizeAuthEvents(tx, users); err != nil {
+			return err
+		}
+		if err := tx.Where("key IN ?", attemptKeys).Delete(&models.LoginAttempt{}).Error; err != nil {
+			return err
+		}
+
+		for _, dependent := range []interface{}{
+			&models.Session{},
+			&models.RefreshToken{},
+			&models.RevokedToken{},
+			&models.PasswordResetToken{},
+			&models.RecoveryCode{},
+			&models.Identity{},
+			&models.UserRole{},
+			&models.APIKey{},
+			&models.MagicLink{},
+		} {
+			if err := tx.Where("user_id IN ?", ids).Delete(dependent).Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&models.User{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
+
+// pseudonymizeAuthEvents clears what identifies users from the audit log:
+// their user ID, and the identifier, IP address and user agent of their
+// events and of attempts made with their email or username. The append-only
+// trigger lets this update through because of the transaction-local
+// app.auth_events_purge setting.
+func pseudonymizeAuthEvents(tx *gorm.DB, users []models.User) error {
+	ids := make([]uuid.UUID, len(users))
+	identifiers := make([]string, 0, 2*len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+		identifiers = append(identifiers, strings.ToLower(user.Email), strings.ToLower(user.Username))
+	}
+
+	if err := tx.Exec("SET LOCAL app.auth_events_purge = 'on'").Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.AuthEvent{}).
+		Where("user_id IN ? OR LOWER(identifier) IN ?", ids, identifiers).
+		Updates(map[string]interface{}{"user_id": nil, "identifier": "", "ip_address": "", "user_agent": ""}).Error
+}
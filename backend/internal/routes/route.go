@@ -1,62 +1,274 @@
 package routes
 
 import (
-    "mobile-shop-backend/internal/handlers"
-    "mobile-shop-backend/internal/middleware"
-    "mobile-shop-backend/internal/repositories"
-    "mobile-shop-backend/internal/services"
-    "net/http"
-    "os"
-
-    "github.com/gin-gonic/gin"
-    "gorm.io/gorm"
+	"context"
+	"log"
+	"mobile-shop-backend/internal/cache"
+	"mobile-shop-backend/internal/catalog"
+	"mobile-shop-backend/internal/config"
+	"mobile-shop-backend/internal/database"
+	"mobile-shop-backend/internal/handlers"
+	"mobile-shop-backend/internal/hashing"
+	"mobile-shop-backend/internal/mail"
+	"mobile-shop-backend/internal/middleware"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/oidc"
+	"mobile-shop-backend/internal/repositories"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/signing"
+	"mobile-shop-backend/internal/validators"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func SetupRoutes(r *gin.Engine, db *gorm.DB) {
-    jwtSecret := getJWTSecret()
-
-    // Initialize
-    userRepo := repositories.NewUserRepository(db)
-    authService := services.NewAuthService(userRepo, jwtSecret)
-    authHandler := handlers.NewAuthHandler(authService)
-    productHandler := handlers.NewProductHandler()
-
-    // Setup route groups
-    setupPublicRoutes(r, authHandler, productHandler)
-    setupProtectedRoutes(r, db, authHandler)
-    setupHealthRoute(r)
-}
-
-func setupPublicRoutes(r *gin.Engine, authHandler *handlers.AuthHandler, productHandler *handlers.ProductHandler) {
-    api := r.Group("/api")
-    {
-        api.POST("/register", authHandler.Register)
-        api.POST("/login", authHandler.Login)
-        api.POST("/logout", authHandler.Logout)
-        api.GET("/products", productHandler.GetProducts)
-        api.GET("/categories", productHandler.GetCategories)
-    }
-}
-
-func setupProtectedRoutes(r *gin.Engine, db *gorm.DB, authHandler *handlers.AuthHandler) {
-    api := r.Group("/api")
-    protected := api.Group("/")
-    protected.Use(middleware.AuthMiddleware(db))
-    {
-        protected.GET("/profile", authHandler.GetProfile)
-        protected.POST("/checkout", func(c *gin.Context) {
-            c.JSON(http.StatusOK, gin.H{"message": "Coming soon"})
-        })
-    }
-}
-
-func setupHealthRoute(r *gin.Engine) {
-    r.GET("/health", func(c *gin.Context) {
-        c.JSON(http.StatusOK, gin.H{"status": "ok", "mode": "full"})
-    })
-}
-
-func getJWTSecret() []byte {
-    secret := os.Getenv("JWT_SECRET")
-    return []byte(secret)
-}
\ No newline at end of file
+// SetupRoutes registers the API on r. It returns the account service so the
+// caller can run its purger for as long as the server runs.
+func SetupRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config) *services.AccountService {
+	keySet, err := signing.LoadKeySet(cfg.Signing)
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	mailer, err := mail.NewMailer(cfg.Mail)
+	if err != nil {
+		log.Fatalf("Failed to configure mailer: %v", err)
+	}
+
+	// Initialize
+	userRepo := repositories.NewUserRepository(db)
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(db)
+	revokedTokenRepo := repositories.NewRevokedTokenRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	passwordHasher := hashing.NewArgon2idHasher(cfg.PasswordHashing)
+	passwordPolicy := newPasswordPolicy(cfg)
+	loginThrottleConfig := services.DefaultLoginThrottleConfig()
+	loginThrottler := services.NewLoginThrottler(newLoginAttemptRepository(db, cfg.LoginAttemptStore, loginThrottleConfig), loginThrottleConfig)
+	passwordResetRepo := repositories.NewPasswordResetRepository(db)
+	passwordResetService := services.NewPasswordResetService(userRepo, passwordResetRepo, refreshTokenRepo, sessionRepo, loginThrottler, passwordHasher, passwordPolicy, mailer, cfg.AppURL)
+	passwordResetHandler := handlers.NewPasswordResetHandler(passwordResetService)
+	emailVerificationService := services.NewEmailVerificationService(userRepo, mailer, cfg.JWTSecret, cfg.AppURL)
+	emailVerificationHandler := handlers.NewEmailVerificationHandler(emailVerificationService)
+	roleRepo := repositories.NewRoleRepository(db)
+	roleService := services.NewRoleService(roleRepo, userRepo)
+	if err := roleService.SeedDefaults(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if err := roleService.BootstrapAdmin(cfg.BootstrapAdmin); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	adminService := services.NewAdminService(userRepo, roleService, loginThrottler)
+	adminHandler := handlers.NewAdminHandler(adminService, roleService)
+	recoveryCodeRepo := repositories.NewRecoveryCodeRepository(db)
+	mfaService := services.NewMFAService(userRepo, recoveryCodeRepo, loginThrottler, passwordHasher, "MobileShop")
+	mfaHandler := handlers.NewMFAHandler(mfaService)
+	authEventRepo := repositories.NewAuthEventRepository(db)
+	authService := services.NewAuthService(userRepo, refreshTokenRepo, revokedTokenRepo, sessionRepo, authEventRepo, roleService, loginThrottler, mfaService, passwordHasher, passwordPolicy, keySet, cfg.Tokens)
+	authHandler := handlers.NewAuthHandler(authService, emailVerificationService)
+	identityRepo := repositories.NewIdentityRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	oidcService := services.NewOIDCService(newOIDCProviders(cfg.OIDCProviders), repositories.NewOAuthStateRepository(db), identityRepo, userRepo, authService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService)
+	magicLinkService := services.NewMagicLinkService(userRepo, repositories.NewMagicLinkRepository(db), authService, keySet, mailer, cfg.Tokens.MagicLinkTTL, cfg.AppURL)
+	magicLinkHandler := handlers.NewMagicLinkHandler(magicLinkService)
+	sessionHandler := handlers.NewSessionHandler(services.NewSessionService(sessionRepo, refreshTokenRepo))
+	accountService := services.NewAccountService(userRepo, sessionRepo, refreshTokenRepo, identityRepo, recoveryCodeRepo, apiKeyRepo, authEventRepo, roleService, passwordHasher, cfg.AccountDeletionGracePeriod)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, userRepo, roleService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	authEventHandler := handlers.NewAuthEventHandler(services.NewAuthEventService(authEventRepo))
+	authMiddleware := middleware.AuthMiddleware(db, keySet, apiKeyService)
+	productSource, productCache := newCachedProductSource(newProductSource(db, cfg), cfg)
+	productHandler := handlers.NewProductHandler(productSource)
+
+	// Setup route groups
+	setupPublicRoutes(r, authHandler, passwordResetHandler, emailVerificationHandler, oidcHandler, magicLinkHandler, productHandler)
+	setupProtectedRoutes(r, authMiddleware, authHandler, accountHandler, sessionHandler, emailVerificationHandler, mfaHandler, apiKeyHandler, authEventHandler, middleware.EmailVerificationPolicy(cfg.EmailVerificationPolicy))
+	setupAdminRoutes(r, authMiddleware, adminHandler, apiKeyHandler, authEventHandler)
+	setupHealthRoute(r, productCache)
+	setupWellKnownRoutes(r, handlers.NewJWKSHandler(keySet))
+
+	return accountService
+}
+
+func setupPublicRoutes(r *gin.Engine, authHandler *handlers.AuthHandler, passwordResetHandler *handlers.PasswordResetHandler, emailVerificationHandler *handlers.EmailVerificationHandler, oidcHandler *handlers.OIDCHandler, magicLinkHandler *handlers.MagicLinkHandler, productHandler *handlers.ProductHandler) {
+	api := r.Group("/api")
+	{
+		api.POST("/register", authHandler.Register)
+		api.POST("/login", authHandler.Login)
+		api.POST("/login/mfa", authHandler.CompleteMFALogin)
+		api.POST("/login/magic-link", magicLinkHandler.RequestMagicLink)
+		api.POST("/login/magic-link/consume", magicLinkHandler.ConsumeMagicLink)
+		api.POST("/token/refresh", authHandler.RefreshToken)
+		api.POST("/password/forgot", passwordResetHandler.ForgotPassword)
+		api.POST("/password/reset", passwordResetHandler.ResetPassword)
+		api.POST("/email/verify", emailVerificationHandler.VerifyEmail)
+		api.GET("/auth/providers", oidcHandler.ListProviders)
+		api.GET("/auth/:provider/authorize", oidcHandler.Authorize)
+		api.POST("/auth/:provider/callback", oidcHandler.Callback)
+		api.GET("/products", productHandler.GetProducts)
+		api.GET("/products/:id", productHandler.GetProduct)
+		api.GET("/categories", productHandler.GetCategories)
+	}
+}
+
+func setupProtectedRoutes(r *gin.Engine, authMiddleware gin.HandlerFunc, authHandler *handlers.AuthHandler, accountHandler *handlers.AccountHandler, sessionHandler *handlers.SessionHandler, emailVerificationHandler *handlers.EmailVerificationHandler, mfaHandler *handlers.MFAHandler, apiKeyHandler *handlers.APIKeyHandler, authEventHandler *handlers.AuthEventHandler, verificationPolicy middleware.EmailVerificationPolicy) {
+	api := r.Group("/api")
+	protected := api.Group("/")
+	protected.Use(authMiddleware)
+	{
+		// API keys may only call the routes that name a scope
+		protected.GET("/profile", middleware.RequireScope(models.ScopeProfileRead), authHandler.GetProfile)
+	}
+
+	interactive := protected.Group("/")
+	interactive.Use(middleware.RequireSession())
+	{
+		// Always available, even to users who have not verified their email
+		interactive.POST("/logout", authHandler.Logout)
+		interactive.POST("/logout/all", authHandler.LogoutAll)
+		interactive.PATCH("/profile", authHandler.UpdateProfile)
+		interactive.POST("/profile/password", authHandler.ChangePassword)
+		interactive.DELETE("/profile", accountHandler.DeleteAccount)
+		interactive.GET("/profile/export", accountHandler.ExportData)
+		interactive.POST("/email/verify/resend", emailVerificationHandler.ResendVerification)
+	}
+
+	member := interactive.Group("/")
+	if verificationPolicy == middleware.VerificationPolicyBlock {
+		member.Use(middleware.RequireVerifiedEmail())
+	}
+	{
+		member.GET("/profile/security-activity", authEventHandler.RecentActivity)
+		member.GET("/sessions", sessionHandler.ListSessions)
+		member.DELETE("/sessions/:id", sessionHandler.RevokeSession)
+		member.GET("/api-keys", apiKeyHandler.ListKeys)
+		member.POST("/api-keys", apiKeyHandler.CreateKey)
+		member.DELETE("/api-keys/:id", apiKeyHandler.RevokeKey)
+		member.POST("/mfa/enroll", mfaHandler.Enroll)
+		member.POST("/mfa/confirm", mfaHandler.Confirm)
+		member.POST("/mfa/disable", mfaHandler.Disable)
+		member.POST("/mfa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+	}
+
+	purchasing := member.Group("/")
+	if verificationPolicy == middleware.VerificationPolicyRestrict {
+		purchasing.Use(middleware.RequireVerifiedEmail())
+	}
+	{
+		purchasing.POST("/checkout", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "Coming soon"})
+		})
+	}
+}
+
+func setupAdminRoutes(r *gin.Engine, authMiddleware gin.HandlerFunc, adminHandler *handlers.AdminHandler, apiKeyHandler *handlers.APIKeyHandler, authEventHandler *handlers.AuthEventHandler) {
+	admin := r.Group("/api/admin")
+	admin.Use(authMiddleware)
+	{
+		admin.GET("/users", middleware.RequirePermission(models.PermissionUsersRead), adminHandler.ListUsers)
+		admin.PUT("/users/:id/roles", middleware.RequirePermission(models.PermissionRolesWrite), adminHandler.SetUserRoles)
+		admin.POST("/users/:id/unlock", middleware.RequirePermission(models.PermissionUsersWrite), adminHandler.UnlockUser)
+		admin.GET("/roles", middleware.RequirePermission(models.PermissionRolesRead), adminHandler.ListRoles)
+		admin.GET("/auth-events", middleware.RequirePermission(models.PermissionAuditRead), authEventHandler.ListEvents)
+		admin.GET("/users/:id/api-keys", middleware.RequirePermission(models.PermissionUsersRead), apiKeyHandler.AdminListKeys)
+		// Keys must not be able to mint or revoke other keys
+		admin.POST("/users/:id/api-keys", middleware.RequireSession(), middleware.RequirePermission(models.PermissionUsersWrite), apiKeyHandler.AdminCreateKey)
+		admin.DELETE("/api-keys/:id", middleware.RequireSession(), middleware.RequirePermission(models.PermissionUsersWrite), apiKeyHandler.AdminRevokeKey)
+	}
+}
+
+func setupWellKnownRoutes(r *gin.Engine, jwksHandler *handlers.JWKSHandler) {
+	r.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+}
+
+func setupHealthRoute(r *gin.Engine, productCache *services.CachedProductSource) {
+	r.GET("/health", func(c *gin.Context) {
+		health := gin.H{"status": "ok", "mode": "full"}
+		if productCache != nil {
+			health["product_cache"] = productCache.Stats()
+		}
+		c.JSON(http.StatusOK, health)
+	})
+}
+
+// newLoginAttemptRepository picks the failed-login counter store. Postgres
+// (the default) shares counters between instances; "memory" keeps them
+// in-process.
+func newLoginAttemptRepository(db *gorm.DB, store string, throttleConfig services.LoginThrottleConfig) repositories.LoginAttemptRepository {
+	if store == "memory" {
+		return repositories.NewMemoryLoginAttemptRepository(throttleConfig.FailureWindow + throttleConfig.LockoutDuration)
+	}
+	return repositories.NewLoginAttemptRepository(db)
+}
+
+// newProductSource picks where the catalog comes from. The database (the
+// default) is seeded on first start; "http" proxies an upstream API.
+func newProductSource(db *gorm.DB, cfg *config.Config) services.ProductSource {
+	if cfg.ProductSource == "http" {
+		return catalog.NewHTTPSource(cfg.ProductHTTP, nil)
+	}
+
+	productService := services.NewProductService(repositories.NewProductRepository(db))
+	products, err := database.SeedProducts()
+	if err != nil {
+		log.Printf("Warning: failed to read product catalog seed: %v", err)
+		return productService
+	}
+	reviews, err := database.SeedProductReviews()
+	if err != nil {
+		log.Printf("Warning: failed to read product review seed: %v", err)
+		return productService
+	}
+	if err := productService.SeedCatalog(products, reviews); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return productService
+}
+
+// newCachedProductSource puts the configured cache in front of source. The
+// cache is nil when caching is disabled.
+func newCachedProductSource(source services.ProductSource, cfg *config.Config) (services.ProductSource, *services.CachedProductSource) {
+	var store cache.Store
+	switch cfg.ProductCacheStore {
+	case "none":
+		return source, nil
+	case "redis":
+		redisStore := cache.NewRedisStore(cfg.Redis)
+		if err := redisStore.Ping(context.Background()); err != nil {
+			log.Printf("Warning: product cache is unreachable, serving without it until it is: %v", err)
+		}
+		store = redisStore
+	default:
+		store = cache.NewMemoryStore(cfg.ProductCacheSize)
+	}
+
+	cached := services.NewCachedProductSource(source, store, cfg.ProductCache)
+	return cached, cached
+}
+
+// newOIDCProviders builds the external sign-in providers. Discovery happens
+// on first use.
+func newOIDCProviders(configs []oidc.Config) []*oidc.Provider {
+	providers := make([]*oidc.Provider, 0, len(configs))
+	for _, providerConfig := range configs {
+		providers = append(providers, oidc.NewProvider(providerConfig, nil))
+	}
+	return providers
+}
+
+// newPasswordPolicy builds the configured password policy, loading the breach
+// corpus if one is configured.
+func newPasswordPolicy(cfg *config.Config) *validators.PasswordPolicy {
+	policy := cfg.PasswordPolicy
+	if cfg.PasswordBreachCorpus != "" {
+		corpus, err := validators.LoadBreachCorpus(cfg.PasswordBreachCorpus)
+		if err != nil {
+			log.Fatalf("Failed to load breached password corpus: %v", err)
+		}
+		log.Printf("Loaded %d breached password hashes", corpus.Len())
+		policy.Breached = corpus
+	}
+	return &policy
+}
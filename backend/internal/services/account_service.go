@@ -0,0 +1,333 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/hashing"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultAccountDeletionGracePeriod is how long a deleted account's data is
+// kept before it is purged.
+const DefaultAccountDeletionGracePeriod = 30 * 24 * time.Hour
+
+// exportFileName is the name of the JSON document inside the export archive.
+const exportFileName = "mobile-shop-data.json"
+
+// UserDataExport is everything stored about a user, as delivered by
+// ExportData. Secrets such as password and token hashes are left out.
+type UserDataExport struct {
+	ExportedAt time.Time          `json:"exported_at"`
+	Profile    ExportedProfile    `json:"profile"`
+	Roles      []string           `json:"roles"`
+	Identities []ExportedIdentity `json:"linked_identities"`
+	MFA        ExportedMFA        `json:"two_factor_authentication"`
+	Sessions   []ExportedSession  `json:"sessions"`
+	APIKeys    []ExportedAPIKey   `json:"api_keys"`
+	// SecurityEvents is the user's part of the security audit log.
+	SecurityEvents []ExportedSecurityEvent `json:"security_events"`
+}
+
+type ExportedProfile struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Username   string     `json:"username"`
+	Email      string     `json:"email"`
+	VerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type ExportedIdentity struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"linked_at"`
+}
+
+type ExportedMFA struct {
+	EnabledAt           *time.Time `json:"enabled_at"`
+	UnusedRecoveryCodes int64      `json:"unused_recovery_codes"`
+}
+
+type ExportedSession struct {
+	ID         uuid.UUID  `json:"id"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type ExportedAPIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type ExportedSecurityEvent struct {
+	Type       string    `json:"type"`
+	Identifier string    `json:"identifier,omitempty"`
+	Method     string    `json:"method,omitempty"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	Outcome    string    `json:"outcome"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AccountService handles account deletion and personal data export.
+type AccountService struct {
+	userRepo         repositories.UserRepository
+	sessionRepo      repositories.SessionRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	identityRepo     repositories.IdentityRepository
+	recoveryCodeRepo repositories.RecoveryCodeRepository
+	apiKeyRepo       repositories.APIKeyRepository
+	authEventRepo    repositories.AuthEventRepository
+	roleService      *RoleService
+	passwordHasher   hashing.PasswordHasher
+	gracePeriod      time.Duration
+}
+
+func NewAccountService(userRepo repositories.UserRepository, sessionRepo repositories.SessionRepository, refreshTokenRepo repositories.RefreshTokenRepository, identityRepo repositories.IdentityRepository, recoveryCodeRepo repositories.RecoveryCodeRepository, apiKeyRepo repositories.APIKeyRepository, authEventRepo repositories.AuthEventRepository, roleService *RoleService, passwordHasher hashing.PasswordHasher, gracePeriod time.Duration) *AccountService {
+	return &AccountService{
+		userRepo:         userRepo,
+		sessionRepo:      sessionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		identityRepo:     identityRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		apiKeyRepo:       apiKeyRepo,
+		authEventRepo:    authEventRepo,
+		roleService:      roleService,
+		passwordHasher:   passwordHasher,
+		gracePeriod:      gracePeriod,
+	}
+}
+
+// DeleteAccount signs the user out everywhere and soft-deletes the account
+// after re-checking the password. It returns when the data will be purged;
+// there is no way to restore the account before then.
+func (s *AccountService) DeleteAccount(userID string, password string) (time.Time, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if ok, err := s.passwordHasher.Verify(user.Password, password); err != nil || !ok {
+		return time.Time{}, apperrors.ErrInvalidPassword.Wrap(err)
+	}
+
+	if err := s.userRepo.IncrementTokenVersion(user.ID); err != nil {
+		return time.Time{}, fmt.Errorf("failed to delete account: %w", err)
+	}
+	if err := s.refreshTokenRepo.RevokeAllForUser(user.ID); err != nil {
+		return time.Time{}, fmt.Errorf("failed to delete account: %w", err)
+	}
+	if err := s.sessionRepo.RevokeAllForUser(user.ID); err != nil {
+		return time.Time{}, fmt.Errorf("failed to delete account: %w", err)
+	}
+	if err := s.userRepo.SoftDelete(user.ID); err != nil {
+		return time.Time{}, fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	return time.Now().Add(s.gracePeriod), nil
+}
+
+// PurgeDeletedAccounts permanently removes accounts whose grace period has
+// ended.
+func (s *AccountService) PurgeDeletedAccounts(now time.Time) (int64, error) {
+	return s.userRepo.PurgeDeleted(now.Add(-s.gracePeriod))
+}
+
+// RunPurger calls PurgeDeletedAccounts at the given interval until ctx is
+// cancelled.
+func (s *AccountService) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			purged, err := s.PurgeDeletedAccounts(now)
+			if err != nil {
+				log.Printf("Failed to purge deleted accounts: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("Purged %d deleted accounts", purged)
+			}
+		}
+	}
+}
+
+// ExportData collects the user's data and returns it as a zip archive
+// holding a single JSON document.
+func (s *AccountService) ExportData(userID string) ([]byte, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export, err := s.collect(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export data: %w", err)
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	file, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     exportFileName,
+		Method:   zip.Deflate,
+		Modified: export.ExportedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export data: %w", err)
+	}
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return nil, fmt.Errorf("failed to export data: %w", err)
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to export data: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s *AccountService) collect(user *models.User) (*UserDataExport, error) {
+	roles, _, err := s.roleService.GetUserAccess(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := s.identityRepo.ListForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionRepo.ListForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := s.recoveryCodeRepo.CountUnused(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys, err := s.apiKeyRepo.ListForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.authEventRepo.ListForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &UserDataExport{
+		ExportedAt: time.Now().UTC(),
+		Profile: ExportedProfile{
+			ID:         user.ID,
+			Name:       user.Name,
+			Username:   user.Username,
+			Email:      user.Email,
+			VerifiedAt: user.VerifiedAt,
+			CreatedAt:  user.CreatedAt,
+			UpdatedAt:  user.UpdatedAt,
+		},
+		Roles:      roles,
+		Identities: make([]ExportedIdentity, 0, len(identities)),
+		MFA: ExportedMFA{
+			EnabledAt:           user.MFAEnabledAt,
+			UnusedRecoveryCodes: recoveryCodes,
+		},
+		Sessions:       make([]ExportedSession, 0, len(sessions)),
+		APIKeys:        make([]ExportedAPIKey, 0, len(apiKeys)),
+		SecurityEvents: make([]ExportedSecurityEvent, 0, len(events)),
+	}
+
+	for _, identity := range identities {
+		export.Identities = append(export.Identities, ExportedIdentity{
+			Provider:  identity.Provider,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, ExportedSession{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			RevokedAt:  session.RevokedAt,
+		})
+	}
+
+	for _, key := range apiKeys {
+		export.APIKeys = append(export.APIKeys, ExportedAPIKey{
+			ID:         key.ID,
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.Scopes,
+			CreatedAt:  key.CreatedAt,
+			ExpiresAt:  key.ExpiresAt,
+			LastUsedAt: key.LastUsedAt,
+			LastUsedIP: key.LastUsedIP,
+			RevokedAt:  key.RevokedAt,
+		})
+	}
+
+	for _, event := range events {
+		export.SecurityEvents = append(export.SecurityEvents, ExportedSecurityEvent{
+			Type:       event.Type,
+			Identifier: event.Identifier,
+			Method:     event.Method,
+			IPAddress:  event.IPAddress,
+			UserAgent:  event.UserAgent,
+			Outcome:    event.Outcome,
+			Reason:     event.Reason,
+			CreatedAt:  event.CreatedAt,
+		})
+	}
+
+	return export, nil
+}
+
+func (s *AccountService) getUser(userID string) (*models.User, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidUserID.Wrap(err)
+	}
+
+	user, err := s.userRepo.GetByID(userUUID)
+	if err != nil {
+		return nil, apperrors.ErrUserNotFound.Wrap(err)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"fmt"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+type AdminService struct {
+	userRepo       repositories.UserRepository
+	roleService    *RoleService
+	loginThrottler *LoginThrottler
+}
+
+func NewAdminService(userRepo repositories.UserRepository, roleService *RoleService, loginThrottler *LoginThrottler) *AdminService {
+	return &AdminService{
+		userRepo:       userRepo,
+		roleService:    roleService,
+		loginThrottler: loginThrottler,
+	}
+}
+
+type AdminUser struct {
+	User  models.User `json:"user"`
+	Roles []string    `json:"roles"`
+}
+
+func (s *AdminService) ListUsers(offset, limit int) ([]AdminUser, int64, error) {
+	users, total, err := s.userRepo.List(offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	userIDs := make([]uuid.UUID, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	roles, err := s.roleService.GetRoleNames(userIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]AdminUser, len(users))
+	for i, user := range users {
+		result[i] = AdminUser{User: user, Roles: roles[user.ID]}
+	}
+
+	return result, total, nil
+}
+
+// UnlockUser lifts a login lockout caused by too many failed attempts.
+func (s *AdminService) UnlockUser(userID string) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return apperrors.ErrInvalidUserID.Wrap(err)
+	}
+
+	if _, err := s.userRepo.GetByID(userUUID); err != nil {
+		return apperrors.ErrUserNotFound.Wrap(err)
+	}
+
+	return s.loginThrottler.Unlock(userUUID)
+}
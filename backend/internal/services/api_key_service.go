@@ -0,0 +1,255 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+	"mobile-shop-backend/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// apiKeyLastUsedResolution is how stale a key's last used time may get
+// before a request updates it.
+const apiKeyLastUsedResolution = time.Minute
+
+// apiKeyDisplayLength is how much of a key is kept in APIKey.Prefix.
+const apiKeyDisplayLength = 12
+
+// CreatedAPIKey is a new API key together with the key itself, which is
+// never shown again.
+type CreatedAPIKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyPrincipal is the user an API key authenticates as. Scopes are the
+// key's scopes that are still in effect: permission scopes the user no
+// longer holds are dropped. Permissions is the subset that are RBAC
+// permissions.
+type APIKeyPrincipal struct {
+	Key         *models.APIKey
+	User        *models.User
+	Scopes      []string
+	Permissions []string
+}
+
+type APIKeyService struct {
+	apiKeyRepo  repositories.APIKeyRepository
+	userRepo    repositories.UserRepository
+	roleService *RoleService
+}
+
+func NewAPIKeyService(apiKeyRepo repositories.APIKeyRepository, userRepo repositories.UserRepository, roleService *RoleService) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo:  apiKeyRepo,
+		userRepo:    userRepo,
+		roleService: roleService,
+	}
+}
+
+// CreateKey creates an API key for userID on behalf of createdByID, which is
+// the same user or an admin. Every requested scope must be known, and
+// permission scopes must be held by the key's owner.
+func (s *APIKeyService) CreateKey(userID string, createdByID string, req *models.CreateAPIKeyRequest) (*CreatedAPIKey, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidUserID.Wrap(err)
+	}
+	creatorUUID, err := uuid.Parse(createdByID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidUserID.Wrap(err)
+	}
+
+	if _, err := s.userRepo.GetByID(userUUID); err != nil {
+		return nil, apperrors.ErrUserNotFound.Wrap(err)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, apperrors.Validation(errors.New("name is required"))
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, apperrors.ErrInvalidAPIKeyExpiry
+	}
+
+	scopes, err := s.checkScopes(userUUID, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	rawKey := models.APIKeyPrefix + secret
+
+	key := &models.APIKey{
+		UserID:      userUUID,
+		Name:        name,
+		Prefix:      rawKey[:apiKeyDisplayLength],
+		KeyHash:     utils.HashToken(rawKey),
+		Scopes:      scopes,
+		CreatedByID: creatorUUID,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if err := s.apiKeyRepo.Create(key); err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return &CreatedAPIKey{APIKey: *key, Key: rawKey}, nil
+}
+
+// checkScopes returns the requested scopes without duplicates.
+func (s *APIKeyService) checkScopes(userID uuid.UUID, requested []string) ([]string, error) {
+	_, permissions, err := s.roleService.GetUserAccess(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		scope = strings.TrimSpace(scope)
+		if containsString(scopes, scope) {
+			continue
+		}
+		if !containsString(models.APIKeyScopes, scope) {
+			return nil, apperrors.ErrUnknownScope.WithDetails(scope)
+		}
+		if containsString(models.AllPermissions, scope) && !containsString(permissions, scope) {
+			return nil, apperrors.ErrScopeNotGranted.WithDetails(scope)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// ListKeys returns the user's keys that have not been revoked, newest first.
+// Expired keys are included so they can be recognised and cleaned up.
+func (s *APIKeyService) ListKeys(userID string) ([]models.APIKey, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidUserID.Wrap(err)
+	}
+
+	keys, err := s.apiKeyRepo.ListForUser(userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	active := make([]models.APIKey, 0, len(keys))
+	for _, key := range keys {
+		if key.RevokedAt == nil {
+			active = append(active, key)
+		}
+	}
+	return active, nil
+}
+
+// RevokeKey revokes one of the user's keys.
+func (s *APIKeyService) RevokeKey(userID string, keyID string) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return apperrors.ErrInvalidUserID.Wrap(err)
+	}
+	return s.revoke(keyID, func(key *models.APIKey) bool { return key.UserID == userUUID })
+}
+
+// RevokeAnyKey revokes a key regardless of its owner. It is meant for
+// admins.
+func (s *APIKeyService) RevokeAnyKey(keyID string) error {
+	return s.revoke(keyID, func(*models.APIKey) bool { return true })
+}
+
+func (s *APIKeyService) revoke(keyID string, allowed func(*models.APIKey) bool) error {
+	keyUUID, err := uuid.Parse(keyID)
+	if err != nil {
+		return apperrors.ErrInvalidAPIKeyID.Wrap(err)
+	}
+
+	key, err := s.apiKeyRepo.GetByID(keyUUID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return apperrors.ErrAPIKeyNotFound
+		}
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if !allowed(key) || key.RevokedAt != nil {
+		return apperrors.ErrAPIKeyNotFound
+	}
+
+	revoked, err := s.apiKeyRepo.Revoke(key.ID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	if !revoked {
+		return apperrors.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate resolves an API key presented by a client at ip. It returns
+// apperrors.ErrInvalidAPIKey for unknown, revoked or orphaned keys and
+// apperrors.ErrAPIKeyExpired for expired ones.
+func (s *APIKeyService) Authenticate(rawKey string, ip string) (*APIKeyPrincipal, error) {
+	if !strings.HasPrefix(rawKey, models.APIKeyPrefix) {
+		return nil, apperrors.ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetByHash(utils.HashToken(rawKey))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to verify API key: %w", err)
+	}
+	if key.RevokedAt != nil {
+		return nil, apperrors.ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return nil, apperrors.ErrAPIKeyExpired
+	}
+
+	// Deleted accounts are no longer found.
+	user, err := s.userRepo.GetByID(key.UserID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidAPIKey.Wrap(err)
+	}
+
+	_, permissions, err := s.roleService.GetUserAccess(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	principal := &APIKeyPrincipal{Key: key, User: user, Scopes: []string{}, Permissions: []string{}}
+	for _, scope := range key.Scopes {
+		if !containsString(models.AllPermissions, scope) {
+			principal.Scopes = append(principal.Scopes, scope)
+		} else if containsString(permissions, scope) {
+			principal.Scopes = append(principal.Scopes, scope)
+			principal.Permissions = append(principal.Permissions, scope)
+		}
+	}
+
+	if key.LastUsedAt == nil || key.LastUsedAt.Before(now.Add(-apiKeyLastUsedResolution)) {
+		_ = s.apiKeyRepo.Touch(key.ID, ip, now, now.Add(-apiKeyLastUsedResolution))
+	}
+
+	return principal, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
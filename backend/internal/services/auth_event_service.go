@@ -0,0 +1,102 @@
+package services
+
+import (
+	"fmt"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// securityActivityHidden are event types left out of a user's own security
+// activity because they happen on every visit.
+var securityActivityHidden = []string{models.AuthEventProfileView}
+
+// AuthEventService reads the security audit log that AuthService writes.
+type AuthEventService struct {
+	authEventRepo repositories.AuthEventRepository
+}
+
+func NewAuthEventService(authEventRepo repositories.AuthEventRepository) *AuthEventService {
+	return &AuthEventService{authEventRepo: authEventRepo}
+}
+
+// ListEvents returns a page of the events matching query, newest first, and
+// the number of matching events.
+func (s *AuthEventService) ListEvents(query *models.AuthEventQuery, offset, limit int) ([]models.AuthEvent, int64, error) {
+	filter, err := parseAuthEventQuery(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	events, total, err := s.authEventRepo.List(filter, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list auth events: %w", err)
+	}
+	return events, total, nil
+}
+
+// RecentActivity returns the user's latest events.
+func (s *AuthEventService) RecentActivity(userID string, limit int) ([]models.AuthEvent, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidUserID.Wrap(err)
+	}
+
+	events, _, err := s.authEventRepo.List(models.AuthEventFilter{UserID: &userUUID, ExcludeTypes: securityActivityHidden}, 0, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list auth events: %w", err)
+	}
+	return events, nil
+}
+
+func parseAuthEventQuery(query *models.AuthEventQuery) (models.AuthEventFilter, error) {
+	filter := models.AuthEventFilter{
+		Type:      strings.TrimSpace(query.Type),
+		Outcome:   strings.TrimSpace(query.Outcome),
+		IPAddress: strings.TrimSpace(query.IP),
+	}
+
+	if filter.Type != "" && !containsString(models.AuthEventTypes, filter.Type) {
+		return filter, apperrors.ErrValidation.WithDetails("unknown event type " + filter.Type)
+	}
+	switch filter.Outcome {
+	case "", models.AuthOutcomeSuccess, models.AuthOutcomeFailure, models.AuthOutcomeMFARequired:
+	default:
+		return filter, apperrors.ErrValidation.WithDetails("unknown outcome " + filter.Outcome)
+	}
+
+	if query.UserID != "" {
+		userUUID, err := uuid.Parse(query.UserID)
+		if err != nil {
+			return filter, apperrors.ErrInvalidUserID.Wrap(err)
+		}
+		filter.UserID = &userUUID
+	}
+
+	for _, bound := range []struct {
+		name  string
+		value string
+		dest  **time.Time
+	}{
+		{"from", query.From, &filter.From},
+		{"to", query.To, &filter.To},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, bound.value)
+		if err != nil {
+			return filter, apperrors.ErrValidation.WithDetails(bound.name + " must be an RFC 3339 timestamp")
+		}
+		*bound.dest = &t
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, apperrors.ErrValidation.WithDetails("from must be before to")
+	}
+
+	return filter, nil
+}
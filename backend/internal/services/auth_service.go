@@ -2,111 +2,736 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/hashing"
 	"mobile-shop-backend/internal/models"
 	"mobile-shop-backend/internal/repositories"
+	"mobile-shop-backend/internal/signing"
+	"mobile-shop-backend/internal/utils"
+	"mobile-shop-backend/internal/validators"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// TokenConfig sets the lifetime of the tokens issued by AuthService.
+type TokenConfig struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	MFATokenTTL     time.Duration
+	MagicLinkTTL    time.Duration
+}
+
+// DefaultTokenConfig returns short-lived access tokens and month-long
+// refresh tokens.
+func DefaultTokenConfig() TokenConfig {
+	return TokenConfig{
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 30 * 24 * time.Hour,
+		MFATokenTTL:     5 * time.Minute,
+		MagicLinkTTL:    15 * time.Minute,
+	}
+}
+
+// Values of the "typ" claim. Only access tokens are accepted by AuthMiddleware.
+const (
+	TokenTypeAccess     = "access"
+	TokenTypeMFAPending = "mfa_pending"
+	TokenTypeMagicLink  = "magic_link"
+)
+
+// Login methods recorded in the audit log. Logins through an identity
+// provider are recorded as "oidc:<provider>".
+const (
+	LoginMethodPassword  = "password"
+	LoginMethodMagicLink = "magic_link"
+)
+
+// LoginResult is returned by Login. When the account has MFA enabled, Tokens
+// is nil and MFAToken must be exchanged through CompleteMFALogin.
+type LoginResult struct {
+	User     *models.User
+	Tokens   *models.TokenPair
+	MFAToken string
+}
+
 type AuthService struct {
-	userRepo  repositories.UserRepository
-	jwtSecret []byte
+	userRepo         repositories.UserRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	revokedTokenRepo repositories.RevokedTokenRepository
+	sessionRepo      repositories.SessionRepository
+	authEventRepo    repositories.AuthEventRepository
+	roleService      *RoleService
+	loginThrottler   *LoginThrottler
+	mfaService       *MFAService
+	passwordHasher   hashing.PasswordHasher
+	passwordPolicy   *validators.PasswordPolicy
+	keySet           *signing.KeySet
+	tokenConfig      TokenConfig
 }
 
-func NewAuthService(userRepo repositories.UserRepository, jwtSecret []byte) *AuthService {
+func NewAuthService(userRepo repositories.UserRepository, refreshTokenRepo repositories.RefreshTokenRepository, revokedTokenRepo repositories.RevokedTokenRepository, sessionRepo repositories.SessionRepository, authEventRepo repositories.AuthEventRepository, roleService *RoleService, loginThrottler *LoginThrottler, mfaService *MFAService, passwordHasher hashing.PasswordHasher, passwordPolicy *validators.PasswordPolicy, keySet *signing.KeySet, tokenConfig TokenConfig) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		revokedTokenRepo: revokedTokenRepo,
+		sessionRepo:      sessionRepo,
+		authEventRepo:    authEventRepo,
+		roleService:      roleService,
+		loginThrottler:   loginThrottler,
+		mfaService:       mfaService,
+		passwordHasher:   passwordHasher,
+		passwordPolicy:   passwordPolicy,
+		keySet:           keySet,
+		tokenConfig:      tokenConfig,
 	}
 }
 
-func (s *AuthService) Register(req *models.RegisterRequest) (*models.User, string, error) {
-	// Check if email already exists
-	emailExists, err := s.userRepo.EmailExists(req.Email)
-	if err != nil {
-		return nil, "", errors.New("failed to check email existence")
-	}
-	if emailExists {
-		return nil, "", errors.New("email already exists")
-	}
+func (s *AuthService) Register(req *models.RegisterRequest, client ClientInfo) (user *models.User, tokens *models.TokenPair, err error) {
+	email := validators.NormalizeEmail(req.Email)
+	username := validators.NormalizeUsername(req.Username)
 
-	// Check if username already exists
-	usernameExists, err := s.userRepo.UsernameExists(req.Username)
-	if err != nil {
-		return nil, "", errors.New("failed to check username existence")
+	event := &models.AuthEvent{Type: models.AuthEventRegister, Identifier: email}
+	defer func() { s.recordEvent(event, client, err) }()
+
+	if err := s.checkEmailAvailable(email); err != nil {
+		return nil, nil, err
 	}
-	if usernameExists {
-		return nil, "", errors.New("username already exists")
+	if err := s.checkUsernameAvailable(username); err != nil {
+		return nil, nil, err
+	}
+	if err := checkPasswordPolicy(s.passwordPolicy, req.Password, req.Name, username, email); err != nil {
+		return nil, nil, err
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.passwordHasher.Hash(req.Password)
 	if err != nil {
-		return nil, "", errors.New("failed to hash password")
+		return nil, nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
 	// Create user
-	user := &models.User{
+	user = &models.User{
 		Name:     req.Name,
-		Username: req.Username,
-		Email:    req.Email,
-		Password: string(hashedPassword),
+		Username: username,
+		Email:    email,
+		Password: hashedPassword,
 	}
 
 	if err := s.userRepo.Create(user); err != nil {
-		return nil, "", errors.New("failed to create user")
+		return nil, nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	event.UserID = &user.ID
 
-	token, err := s.generateJWT(user.ID.String())
+	tokens, err = s.startSession(user, client)
 	if err != nil {
-		return nil, "", errors.New("failed to generate token")
+		return nil, nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	return user, token, nil
+	return user, tokens, nil
 }
 
-func (s *AuthService) Login(req *models.LoginRequest) (*models.User, string, error) {
-	user, err := s.userRepo.GetByUsername(req.Username)
+// Login checks the credentials and issues a token pair. The account is found
+// by username or email, case-insensitively. The client IP is used
+// to throttle repeated failures from the same address; apperrors.ErrAccountLocked
+// or apperrors.ErrTooManyAttempts is returned while the account or IP is
+// locked out or must wait. Accounts with
+// MFA enabled get a short-lived MFA token instead of a token pair.
+func (s *AuthService) Login(req *models.LoginRequest, client ClientInfo) (result *LoginResult, err error) {
+	// Usernames cannot contain "@", so the identifier is unambiguous.
+	var identifier string
+	if strings.Contains(req.LoginIdentifier(), "@") {
+		identifier = validators.NormalizeEmail(req.LoginIdentifier())
+	} else {
+		identifier = validators.NormalizeUsername(req.LoginIdentifier())
+	}
+
+	event := &models.AuthEvent{Type: models.AuthEventLogin, Identifier: identifier, Method: LoginMethodPassword}
+	defer func() { s.recordEvent(event, client, err) }()
+
+	if err := s.loginThrottler.CheckIP(client.IP); err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	var lookupErr error
+	if strings.Contains(identifier, "@") {
+		user, lookupErr = s.userRepo.GetByEmail(identifier)
+	} else {
+		user, lookupErr = s.userRepo.GetByUsername(identifier)
+	}
+	accountKey := UnknownAccountKey(identifier)
+	if lookupErr == nil {
+		accountKey = AccountKey(user.ID)
+		event.UserID = &user.ID
+	}
+
+	if err := s.loginThrottler.CheckAccount(accountKey); err != nil {
+		return nil, err
+	}
+
+	if lookupErr != nil || !s.verifyPassword(user, req.Password) {
+		if err := s.loginThrottler.RecordFailure(accountKey, client.IP); err != nil {
+			return nil, err
+		}
+		return nil, apperrors.ErrInvalidCredentials
+	}
+
+	// The failure counter is only cleared once the second factor is checked
+	// too, so a leaked password cannot be used to reset it between code guesses.
+	if user.MFAEnabledAt != nil {
+		mfaToken, err := s.generateMFAToken(user)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate token: %w", err)
+		}
+		event.Outcome = models.AuthOutcomeMFARequired
+		return &LoginResult{User: user, MFAToken: mfaToken}, nil
+	}
+
+	if err := s.loginThrottler.RecordSuccess(accountKey); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.startSession(user, client)
 	if err != nil {
-		return nil, "", errors.New("invalid credentials")
+		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, "", errors.New("invalid credentials")
+	return &LoginResult{User: user, Tokens: tokens}, nil
+}
+
+// checkPasswordPolicy returns apperrors.ErrWeakPassword listing every rule
+// of policy that password breaks.
+func checkPasswordPolicy(policy *validators.PasswordPolicy, password string, personalInfo ...string) error {
+	violations, err := policy.Check(password, personalInfo...)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return apperrors.ErrWeakPassword.WithViolations(violations)
 	}
+	return nil
+}
 
-	token, err := s.generateJWT(user.ID.String())
+// verifyPassword checks password against the user's stored hash. After a
+// match, a legacy bcrypt hash or one made with outdated parameters is
+// replaced; a failed upgrade is logged and does not fail the login.
+func (s *AuthService) verifyPassword(user *models.User, password string) bool {
+	ok, err := s.passwordHasher.Verify(user.Password, password)
 	if err != nil {
-		return nil, "", errors.New("failed to generate token")
+		log.Printf("Failed to verify password of user %s: %v", user.ID, err)
+		return false
+	}
+	if !ok || !s.passwordHasher.NeedsRehash(user.Password) {
+		return ok
 	}
 
-	return user, token, nil
+	hashedPassword, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		log.Printf("Failed to rehash password of user %s: %v", user.ID, err)
+		return true
+	}
+	if err := s.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		log.Printf("Failed to store rehashed password of user %s: %v", user.ID, err)
+		return true
+	}
+	user.Password = hashedPassword
+	return true
+}
+
+// LoginWithIdentity signs in a user who was already authenticated another
+// way, such as by an external identity provider; method names it in the
+// audit log. Accounts with MFA enabled still have to pass the second factor
+// through CompleteMFALogin.
+func (s *AuthService) LoginWithIdentity(user *models.User, method string, client ClientInfo) (result *LoginResult, err error) {
+	event := &models.AuthEvent{Type: models.AuthEventLogin, UserID: &user.ID, Identifier: user.Email, Method: method}
+	defer func() { s.recordEvent(event, client, err) }()
+
+	if user.MFAEnabledAt != nil {
+		mfaToken, err := s.generateMFAToken(user)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate token: %w", err)
+		}
+		event.Outcome = models.AuthOutcomeMFARequired
+		return &LoginResult{User: user, MFAToken: mfaToken}, nil
+	}
+
+	tokens, err := s.startSession(user, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &LoginResult{User: user, Tokens: tokens}, nil
+}
+
+// CompleteMFALogin exchanges the MFA token from Login and a TOTP or recovery
+// code for a token pair. Each MFA token can only be exchanged once.
+func (s *AuthService) CompleteMFALogin(mfaToken string, code string, client ClientInfo) (user *models.User, tokens *models.TokenPair, err error) {
+	event := &models.AuthEvent{Type: models.AuthEventMFALogin}
+	defer func() { s.recordEvent(event, client, err) }()
+
+	if err := s.loginThrottler.CheckIP(client.IP); err != nil {
+		return nil, nil, err
+	}
+
+	claims, err := s.parseMFAToken(mfaToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userID, _ := claims["user_id"].(string)
+	jti, _ := claims["jti"].(string)
+	userUUID, err := uuid.Parse(userID)
+	if err != nil || jti == "" {
+		return nil, nil, apperrors.ErrInvalidMFAToken
+	}
+	event.UserID = &userUUID
+
+	revoked, err := s.revokedTokenRepo.IsRevoked(jti)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to verify mfa token: %w", err)
+	}
+	if revoked {
+		return nil, nil, apperrors.ErrInvalidMFAToken
+	}
+
+	user, err = s.userRepo.GetByID(userUUID)
+	if err != nil || user.MFAEnabledAt == nil {
+		return nil, nil, apperrors.ErrInvalidMFAToken
+	}
+
+	accountKey := AccountKey(user.ID)
+	if err := s.loginThrottler.CheckAccount(accountKey); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.mfaService.VerifyCode(user, code); err != nil {
+		if errors.Is(err, apperrors.ErrInvalidMFACode) {
+			if err := s.loginThrottler.RecordFailure(accountKey, client.IP); err != nil {
+				return nil, nil, err
+			}
+			return nil, nil, apperrors.ErrInvalidMFALoginCode
+		}
+		return nil, nil, err
+	}
+
+	expiresAt, _ := claims.GetExpirationTime()
+	if err := s.revokedTokenRepo.Revoke(&models.RevokedToken{
+		JTI:       jti,
+		UserID:    user.ID,
+		ExpiresAt: expiresAt.Time,
+	}); err != nil {
+		return nil, nil, fmt.Errorf("failed to verify mfa token: %w", err)
+	}
+
+	if err := s.loginThrottler.RecordSuccess(accountKey); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err = s.startSession(user, client)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return user, tokens, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair. Each refresh token
+// can be used exactly once; presenting one that was already rotated is
+// treated as theft and ends the session, revoking every token in its family.
+func (s *AuthService) Refresh(refreshToken string, client ClientInfo) (user *models.User, tokens *models.TokenPair, err error) {
+	// Clients refresh every few minutes, so only failures, such as a reused
+	// token, are worth recording.
+	event := &models.AuthEvent{Type: models.AuthEventTokenRefresh}
+	defer func() {
+		if err != nil {
+			s.recordEvent(event, client, err)
+		}
+	}()
+
+	stored, err := s.refreshTokenRepo.GetByHash(utils.HashToken(refreshToken))
+	if err != nil {
+		return nil, nil, apperrors.ErrInvalidRefreshToken.Wrap(err)
+	}
+	event.UserID = &stored.UserID
+
+	if stored.UsedAt != nil || stored.RevokedAt != nil {
+		if err := s.endSession(stored.FamilyID, stored.UserID); err != nil {
+			return nil, nil, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+		return nil, nil, apperrors.ErrRefreshTokenReused
+	}
+
+	session, err := s.sessionRepo.GetByID(stored.FamilyID)
+	if err != nil || session.RevokedAt != nil {
+		return nil, nil, apperrors.ErrInvalidRefreshToken
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, nil, apperrors.ErrRefreshTokenExpired
+	}
+
+	marked, err := s.refreshTokenRepo.MarkUsed(stored.ID, time.Now())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if !marked {
+		// Another request consumed this token between the lookup and the update.
+		if err := s.endSession(stored.FamilyID, stored.UserID); err != nil {
+			return nil, nil, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+		return nil, nil, apperrors.ErrRefreshTokenReused
+	}
+
+	user, err = s.userRepo.GetByID(stored.UserID)
+	if err != nil {
+		return nil, nil, apperrors.ErrInvalidRefreshToken.Wrap(err)
+	}
+
+	tokens, err = s.issueTokens(user, stored.FamilyID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	now := time.Now()
+	if err := s.sessionRepo.Refresh(session.ID, client.IP, truncate(client.UserAgent, maxUserAgentLength), now, now.Add(s.tokenConfig.RefreshTokenTTL)); err != nil {
+		return nil, nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	return user, tokens, nil
+}
+
+// Logout ends the current session and revokes the access token identified
+// by jti. A refresh token, when provided, has its family revoked as well.
+func (s *AuthService) Logout(userID string, sessionID string, jti string, expiresAt time.Time, refreshToken string, client ClientInfo) (err error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return apperrors.ErrInvalidUserID.Wrap(err)
+	}
+
+	event := &models.AuthEvent{Type: models.AuthEventLogout, UserID: &userUUID}
+	defer func() { s.recordEvent(event, client, err) }()
+
+	if jti != "" {
+		if err := s.revokedTokenRepo.Revoke(&models.RevokedToken{
+			JTI:       jti,
+			UserID:    userUUID,
+			ExpiresAt: expiresAt,
+		}); err != nil {
+			return fmt.Errorf("failed to revoke token: %w", err)
+		}
+	}
+
+	if sessionUUID, err := uuid.Parse(sessionID); err == nil {
+		if err := s.endSession(sessionUUID, userUUID); err != nil {
+			return fmt.Errorf("failed to revoke token: %w", err)
+		}
+	}
+
+	if refreshToken != "" {
+		stored, err := s.refreshTokenRepo.GetByHash(utils.HashToken(refreshToken))
+		if err == nil && stored.UserID == userUUID {
+			if err := s.endSession(stored.FamilyID, userUUID); err != nil {
+				return fmt.Errorf("failed to revoke token: %w", err)
+			}
+		}
+	}
+
+	// Denylist entries are useless once the token has expired on its own.
+	_ = s.revokedTokenRepo.DeleteExpired(time.Now())
+
+	return nil
+}
+
+// LogoutAll invalidates every access and refresh token issued to the user by
+// bumping their token version and revoking all refresh tokens and sessions.
+func (s *AuthService) LogoutAll(userID string, client ClientInfo) (err error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return apperrors.ErrInvalidUserID.Wrap(err)
+	}
+
+	event := &models.AuthEvent{Type: models.AuthEventLogoutAll, UserID: &userUUID}
+	defer func() { s.recordEvent(event, client, err) }()
+
+	if err := s.userRepo.IncrementTokenVersion(userUUID); err != nil {
+		return fmt.Errorf("failed to revoke tokens: %w", err)
+	}
+
+	if err := s.refreshTokenRepo.RevokeAllForUser(userUUID); err != nil {
+		return fmt.Errorf("failed to revoke tokens: %w", err)
+	}
+
+	if err := s.sessionRepo.RevokeAllForUser(userUUID); err != nil {
+		return fmt.Errorf("failed to revoke tokens: %w", err)
+	}
+
+	return nil
 }
 
 func (s *AuthService) GetUserByID(userID string) (*models.User, error) {
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		return nil, errors.New("invalid user ID")
+		return nil, apperrors.ErrInvalidUserID.Wrap(err)
 	}
 
 	user, err := s.userRepo.GetByID(userUUID)
 	if err != nil {
-		return nil, errors.New("user not found")
+		return nil, apperrors.ErrUserNotFound.Wrap(err)
 	}
 
 	return user, nil
 }
 
-func (s *AuthService) generateJWT(userID string) (string, error) {
+// GetProfile returns the user's own profile and records that it was viewed.
+func (s *AuthService) GetProfile(userID string, client ClientInfo) (*models.User, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordEvent(&models.AuthEvent{Type: models.AuthEventProfileView, UserID: &user.ID}, client, nil)
+	return user, nil
+}
+
+// UpdateProfile changes the fields present in req. Changing the email needs
+// the current password, and the new address must be verified again; when it
+// changed the previous address is returned so the caller can send the
+// verification link and warn the previous address.
+func (s *AuthService) UpdateProfile(userID string, req *models.UpdateProfileRequest, client ClientInfo) (user *models.User, previousEmail string, err error) {
+	user, err = s.GetUserByID(userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	event := &models.AuthEvent{Type: models.AuthEventProfileUpdate, UserID: &user.ID}
+	defer func() { s.recordEvent(event, client, err) }()
+
+	if req.Name != nil {
+		user.Name = strings.TrimSpace(*req.Name)
+	}
+
+	if req.Username != nil {
+		username := validators.NormalizeUsername(*req.Username)
+		if username != user.Username {
+			if err := s.checkUsernameAvailable(username); err != nil {
+				return nil, "", err
+			}
+			user.Username = username
+		}
+	}
+
+	if req.Email != nil {
+		email := validators.NormalizeEmail(*req.Email)
+		if email != user.Email {
+			if req.CurrentPassword == "" {
+				return nil, "", apperrors.Validation(errors.New("current password is required to change the email"))
+			}
+			if ok, err := s.passwordHasher.Verify(user.Password, req.CurrentPassword); err != nil || !ok {
+				return nil, "", apperrors.ErrInvalidPassword.Wrap(err)
+			}
+			if err := s.checkEmailAvailable(email); err != nil {
+				return nil, "", err
+			}
+			previousEmail = user.Email
+			user.Email = email
+			user.VerifiedAt = nil
+			user.VerificationSentAt = nil
+		}
+	}
+
+	if err := s.userRepo.UpdateProfile(user); err != nil {
+		return nil, "", fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	return user, previousEmail, nil
+}
+
+// ChangePassword replaces the password after checking the current one. Every
+// other session is signed out; the session the change was made from stays
+// signed in.
+func (s *AuthService) ChangePassword(userID string, sessionID string, currentPassword string, newPassword string, client ClientInfo) (err error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	event := &models.AuthEvent{Type: models.AuthEventPasswordChange, UserID: &user.ID}
+	defer func() { s.recordEvent(event, client, err) }()
+
+	sessionUUID, err := uuid.Parse(sessionID)
+	if err != nil {
+		return apperrors.ErrInvalidSessionID.Wrap(err)
+	}
+
+	if ok, err := s.passwordHasher.Verify(user.Password, currentPassword); err != nil || !ok {
+		return apperrors.ErrInvalidPassword.Wrap(err)
+	}
+
+	if err := checkPasswordPolicy(s.passwordPolicy, newPassword, user.Name, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.passwordHasher.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+
+	if err := s.sessionRepo.RevokeOthersForUser(user.ID, sessionUUID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if err := s.refreshTokenRepo.RevokeOthersForUser(user.ID, sessionUUID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+func (s *AuthService) checkEmailAvailable(email string) error {
+	exists, err := s.userRepo.EmailExists(email)
+	if err != nil {
+		return fmt.Errorf("failed to check email existence: %w", err)
+	}
+	if exists {
+		return apperrors.ErrEmailExists
+	}
+	return nil
+}
+
+func (s *AuthService) checkUsernameAvailable(username string) error {
+	exists, err := s.userRepo.UsernameExists(username)
+	if err != nil {
+		return fmt.Errorf("failed to check username existence: %w", err)
+	}
+	if exists {
+		return apperrors.ErrUsernameExists
+	}
+	return nil
+}
+
+// recordEvent appends event to the audit log with the outcome err describes.
+// A failure to write it is only logged; it must not fail the request.
+func (s *AuthService) recordEvent(event *models.AuthEvent, client ClientInfo, err error) {
+	event.IPAddress = client.IP
+	event.UserAgent = truncate(client.UserAgent, maxUserAgentLength)
+	if err != nil {
+		event.Outcome = models.AuthOutcomeFailure
+		event.Reason = apperrors.Code(err)
+	} else if event.Outcome == "" {
+		event.Outcome = models.AuthOutcomeSuccess
+	}
+
+	if err := s.authEventRepo.Create(event); err != nil {
+		log.Printf("Failed to record %s event: %v", event.Type, err)
+	}
+}
+
+// startSession records a newly signed-in device and issues its first token
+// pair. The session ID doubles as the refresh token family ID.
+func (s *AuthService) startSession(user *models.User, client ClientInfo) (*models.TokenPair, error) {
+	now := time.Now()
+	session := &models.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		UserAgent:  truncate(client.UserAgent, maxUserAgentLength),
+		IPAddress:  client.IP,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.tokenConfig.RefreshTokenTTL),
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user, session.ID)
+}
+
+// endSession marks the session revoked and revokes its refresh tokens.
+// Access tokens carrying its ID are rejected by AuthMiddleware from then on.
+func (s *AuthService) endSession(sessionID uuid.UUID, userID uuid.UUID) error {
+	if _, err := s.sessionRepo.Revoke(sessionID, userID); err != nil {
+		return err
+	}
+	return s.refreshTokenRepo.RevokeFamily(sessionID)
+}
+
+// issueTokens creates a short-lived access token and a new refresh token
+// belonging to the given family.
+func (s *AuthService) issueTokens(user *models.User, familyID uuid.UUID) (*models.TokenPair, error) {
+	accessToken, err := s.generateJWT(user, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshTokenRepo.Create(&models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.tokenConfig.RefreshTokenTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.tokenConfig.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// generateJWT issues an access token. Roles and permissions are embedded as
+// claims; changing a user's roles bumps their token version so stale claims
+// stop being accepted.
+func (s *AuthService) generateJWT(user *models.User, sessionID uuid.UUID) (string, error) {
+	roles, permissions, err := s.roleService.GetUserAccess(user.ID)
+	if err != nil {
+		return "", err
+	}
+
 	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(), // 24 hours
+		"user_id": user.ID.String(),
+		"typ":     TokenTypeAccess,
+		"jti":     uuid.New().String(),
+		"sid":     sessionID.String(),
+		"ver":     user.TokenVersion,
+		"roles":   roles,
+		"perms":   permissions,
+		"exp":     time.Now().Add(s.tokenConfig.AccessTokenTTL).Unix(),
 		"iat":     time.Now().Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.jwtSecret)
+	return s.keySet.Sign(claims)
+}
+
+func (s *AuthService) generateMFAToken(user *models.User) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": user.ID.String(),
+		"typ":     TokenTypeMFAPending,
+		"jti":     uuid.New().String(),
+		"exp":     time.Now().Add(s.tokenConfig.MFATokenTTL).Unix(),
+		"iat":     time.Now().Unix(),
+	}
+
+	return s.keySet.SignFor(signing.PurposeMFA, claims)
+}
+
+func (s *AuthService) parseMFAToken(tokenString string) (jwt.MapClaims, error) {
+	claims, err := s.keySet.ParseFor(signing.PurposeMFA, tokenString)
+	if err != nil || claims["typ"] != TokenTypeMFAPending {
+		return nil, apperrors.ErrInvalidMFAToken
+	}
+
+	return claims, nil
 }
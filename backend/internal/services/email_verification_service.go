@@ -0,0 +1,174 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/mail"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	emailVerificationTTL       = 48 * time.Hour
+	verificationResendInterval = time.Minute
+)
+
+type EmailVerificationService struct {
+	userRepo   repositories.UserRepository
+	mailer     mail.Mailer
+	signingKey []byte
+	appURL     string
+}
+
+func NewEmailVerificationService(userRepo repositories.UserRepository, mailer mail.Mailer, signingKey []byte, appURL string) *EmailVerificationService {
+	return &EmailVerificationService{
+		userRepo:   userRepo,
+		mailer:     mailer,
+		signingKey: signingKey,
+		appURL:     appURL,
+	}
+}
+
+// SendVerification emails a signed verification link to the user.
+func (s *EmailVerificationService) SendVerification(user *models.User) error {
+	if user.VerifiedAt != nil {
+		return apperrors.ErrEmailAlreadyVerified
+	}
+
+	token := s.signToken(user.ID, user.Email, time.Now().Add(emailVerificationTTL))
+	link := s.appURL + "/verify-email?token=" + url.QueryEscape(token)
+
+	if err := s.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Verify your MobileShop email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\n"+
+			"The link is valid for 48 hours.", user.Name, link),
+	}); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	if err := s.userRepo.SetVerificationSentAt(user.ID, time.Now()); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	return nil
+}
+
+// NotifyEmailChanged tells the previous address that the account's email
+// was changed, so an unexpected change does not go unnoticed.
+func (s *EmailVerificationService) NotifyEmailChanged(user *models.User, previousEmail string) error {
+	if err := s.mailer.Send(mail.Message{
+		To:      previousEmail,
+		Subject: "Your MobileShop email address was changed",
+		Body: fmt.Sprintf("Hi %s,\n\nThe email address of your MobileShop account was changed to %s.\n\n"+
+			"If you did not make this change, reset your password and contact support right away.", user.Name, user.Email),
+	}); err != nil {
+		return fmt.Errorf("failed to send email change notice: %w", err)
+	}
+	return nil
+}
+
+// ResendVerification sends a fresh link, at most once per resend interval.
+func (s *EmailVerificationService) ResendVerification(userID string) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return apperrors.ErrInvalidUserID.Wrap(err)
+	}
+
+	user, err := s.userRepo.GetByID(userUUID)
+	if err != nil {
+		return apperrors.ErrUserNotFound.Wrap(err)
+	}
+
+	if user.VerificationSentAt != nil && time.Since(*user.VerificationSentAt) < verificationResendInterval {
+		return apperrors.ErrVerificationThrottled
+	}
+
+	return s.SendVerification(user)
+}
+
+// Verify marks the email address in token as verified. The token is bound to
+// the address it was issued for, so it stops working if the email changes.
+func (s *EmailVerificationService) Verify(token string) (*models.User, error) {
+	userID, email, err := s.parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil || !strings.EqualFold(user.Email, email) {
+		return nil, apperrors.ErrInvalidVerificationToken
+	}
+
+	if user.VerifiedAt != nil {
+		return user, nil
+	}
+
+	now := time.Now()
+	if err := s.userRepo.MarkEmailVerified(user.ID, now); err != nil {
+		return nil, fmt.Errorf("failed to verify email: %w", err)
+	}
+	user.VerifiedAt = &now
+
+	return user, nil
+}
+
+// signToken encodes "userID|expiry|email" and appends an HMAC-SHA256
+// signature over it.
+func (s *EmailVerificationService) signToken(userID uuid.UUID, email string, expiresAt time.Time) string {
+	payload := userID.String() + "|" + strconv.FormatInt(expiresAt.Unix(), 10) + "|" + email
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded))
+}
+
+func (s *EmailVerificationService) parseToken(token string) (uuid.UUID, string, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return uuid.Nil, "", apperrors.ErrInvalidVerificationToken
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.sign(encoded)) {
+		return uuid.Nil, "", apperrors.ErrInvalidVerificationToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return uuid.Nil, "", apperrors.ErrInvalidVerificationToken.Wrap(err)
+	}
+
+	parts := strings.SplitN(string(payload), "|", 3)
+	if len(parts) != 3 {
+		return uuid.Nil, "", apperrors.ErrInvalidVerificationToken
+	}
+
+	userID, err := uuid.Parse(parts[0])
+	if err != nil {
+		return uuid.Nil, "", apperrors.ErrInvalidVerificationToken.Wrap(err)
+	}
+
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return uuid.Nil, "", apperrors.ErrInvalidVerificationToken.Wrap(err)
+	}
+	if time.Now().Unix() > expiresAt {
+		return uuid.Nil, "", apperrors.ErrVerificationTokenExpired
+	}
+
+	return userID, parts[2], nil
+}
+
+func (s *EmailVerificationService) sign(data string) []byte {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte("email-verification:" + data))
+	return mac.Sum(nil)
+}
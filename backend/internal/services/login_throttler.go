@@ -0,0 +1,170 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginThrottleConfig controls how failed logins are slowed down and locked out.
+type LoginThrottleConfig struct {
+	// FailureWindow is how long a failure counts towards the limits below.
+	FailureWindow time.Duration
+	// DelayAfter is the number of failures after which every further attempt
+	// must wait BaseDelay, doubling with each failure up to MaxDelay.
+	DelayAfter int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	// MaxAccountFailures locks the account for LockoutDuration.
+	MaxAccountFailures int
+	// MaxIPFailures blocks the client IP for LockoutDuration.
+	MaxIPFailures   int
+	LockoutDuration time.Duration
+}
+
+func DefaultLoginThrottleConfig() LoginThrottleConfig {
+	return LoginThrottleConfig{
+		FailureWindow:      15 * time.Minute,
+		DelayAfter:         3,
+		BaseDelay:          time.Second,
+		MaxDelay:           30 * time.Second,
+		MaxAccountFailures: 10,
+		MaxIPFailures:      50,
+		LockoutDuration:    15 * time.Minute,
+	}
+}
+
+// LoginThrottler tracks failed logins per account and per client IP.
+type LoginThrottler struct {
+	attemptRepo repositories.LoginAttemptRepository
+	config      LoginThrottleConfig
+}
+
+func NewLoginThrottler(attemptRepo repositories.LoginAttemptRepository, config LoginThrottleConfig) *LoginThrottler {
+	return &LoginThrottler{
+		attemptRepo: attemptRepo,
+		config:      config,
+	}
+}
+
+// AccountKey identifies an existing account. Failures are counted against the
+// account no matter which identifier was used to log in.
+func AccountKey(userID uuid.UUID) string {
+	return models.AccountLoginAttemptKey(userID)
+}
+
+// UnknownAccountKey identifies login attempts for identifiers that do not
+// match any account, so probing unknown names is throttled the same way.
+func UnknownAccountKey(identifier string) string {
+	return models.UnknownLoginAttemptKey(identifier)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// CheckIP refuses the attempt if the client IP is blocked or must wait.
+func (t *LoginThrottler) CheckIP(ip string) error {
+	if ip == "" {
+		return nil
+	}
+	return t.check(ipKey(ip), false)
+}
+
+// CheckAccount refuses the attempt if the account is locked or must wait.
+func (t *LoginThrottler) CheckAccount(key string) error {
+	return t.check(key, true)
+}
+
+func (t *LoginThrottler) check(key string, isAccount bool) error {
+	attempt, err := t.attemptRepo.Get(key)
+	if err != nil {
+		return fmt.Errorf("failed to check login attempts: %w", err)
+	}
+
+	now := time.Now()
+	if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+		if isAccount {
+			return apperrors.ErrAccountLocked.WithRetryAfter(attempt.LockedUntil.Sub(now))
+		}
+		return apperrors.ErrTooManyAttempts.WithRetryAfter(attempt.LockedUntil.Sub(now))
+	}
+
+	if attempt.LastFailureAt.Before(now.Add(-t.config.FailureWindow)) {
+		return nil
+	}
+
+	if delay := t.delayFor(attempt.Failures); delay > 0 {
+		if wait := attempt.LastFailureAt.Add(delay).Sub(now); wait > 0 {
+			return apperrors.ErrTooManyAttempts.WithRetryAfter(wait)
+		}
+	}
+
+	return nil
+}
+
+// RecordFailure counts a failed login against the account key and client IP
+// and applies a lockout once a limit is reached.
+func (t *LoginThrottler) RecordFailure(accountKey string, ip string) error {
+	now := time.Now()
+
+	attempt, err := t.attemptRepo.RecordFailure(accountKey, now, t.config.FailureWindow)
+	if err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	if attempt.Failures >= t.config.MaxAccountFailures {
+		if err := t.attemptRepo.Lock(accountKey, now.Add(t.config.LockoutDuration)); err != nil {
+			return fmt.Errorf("failed to record login attempt: %w", err)
+		}
+	}
+
+	if ip == "" {
+		return nil
+	}
+
+	attempt, err = t.attemptRepo.RecordFailure(ipKey(ip), now, t.config.FailureWindow)
+	if err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	if attempt.Failures >= t.config.MaxIPFailures {
+		if err := t.attemptRepo.Lock(ipKey(ip), now.Add(t.config.LockoutDuration)); err != nil {
+			return fmt.Errorf("failed to record login attempt: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RecordSuccess clears the account's failure counter. The IP counter is kept
+// so one valid account cannot be used to reset an attacker's IP budget.
+func (t *LoginThrottler) RecordSuccess(accountKey string) error {
+	if err := t.attemptRepo.Reset(accountKey); err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+	return nil
+}
+
+// Unlock lifts a lockout on the account and clears its failure counter.
+func (t *LoginThrottler) Unlock(userID uuid.UUID) error {
+	if err := t.attemptRepo.Reset(AccountKey(userID)); err != nil {
+		return fmt.Errorf("failed to unlock account: %w", err)
+	}
+	return nil
+}
+
+func (t *LoginThrottler) delayFor(failures int) time.Duration {
+	if failures < t.config.DelayAfter {
+		return 0
+	}
+	exponent := float64(failures - t.config.DelayAfter)
+	delay := time.Duration(float64(t.config.BaseDelay) * math.Pow(2, exponent))
+	if delay > t.config.MaxDelay || delay <= 0 {
+		return t.config.MaxDelay
+	}
+	return delay
+}
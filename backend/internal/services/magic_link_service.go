@@ -0,0 +1,143 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/mail"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+	"mobile-shop-backend/internal/signing"
+	"mobile-shop-backend/internal/validators"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// magicLinkResendInterval is how often a new link is sent to the same
+// account; requests in between are silently dropped.
+const magicLinkResendInterval = time.Minute
+
+// MagicLinkService signs users in through a link emailed to them. The link
+// carries a token signed with the JWT keys; its jti is recorded so each link
+// works once, and only the most recent link of a user works at all.
+type MagicLinkService struct {
+	userRepo      repositories.UserRepository
+	magicLinkRepo repositories.MagicLinkRepository
+	authService   *AuthService
+	keySet        *signing.KeySet
+	mailer        mail.Mailer
+	ttl           time.Duration
+	appURL        string
+}
+
+func NewMagicLinkService(userRepo repositories.UserRepository, magicLinkRepo repositories.MagicLinkRepository, authService *AuthService, keySet *signing.KeySet, mailer mail.Mailer, ttl time.Duration, appURL string) *MagicLinkService {
+	return &MagicLinkService{
+		userRepo:      userRepo,
+		magicLinkRepo: magicLinkRepo,
+		authService:   authService,
+		keySet:        keySet,
+		mailer:        mailer,
+		ttl:           ttl,
+		appURL:        appURL,
+	}
+}
+
+// RequestLink emails a sign-in link to the account registered with email.
+// Unknown addresses are ignored so callers cannot probe for accounts, and for
+// the same reason a failed delivery is only logged.
+func (s *MagicLinkService) RequestLink(email string) error {
+	user, err := s.userRepo.GetByEmail(validators.NormalizeEmail(email))
+	if err != nil {
+		return nil
+	}
+
+	if latest, err := s.magicLinkRepo.GetLatestForUser(user.ID); err == nil && time.Since(latest.CreatedAt) < magicLinkResendInterval {
+		return nil
+	}
+
+	// Only the most recent link should work.
+	if err := s.magicLinkRepo.DeleteForUser(user.ID); err != nil {
+		return fmt.Errorf("failed to create sign-in link: %w", err)
+	}
+
+	now := time.Now()
+	link := &models.MagicLink{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		ExpiresAt: now.Add(s.ttl),
+	}
+	token, err := s.keySet.SignFor(signing.PurposeMagicLink, jwt.MapClaims{
+		"user_id": user.ID.String(),
+		"typ":     TokenTypeMagicLink,
+		"jti":     link.ID.String(),
+		"exp":     link.ExpiresAt.Unix(),
+		"iat":     now.Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create sign-in link: %w", err)
+	}
+	if err := s.magicLinkRepo.Create(link); err != nil {
+		return fmt.Errorf("failed to create sign-in link: %w", err)
+	}
+
+	signInURL := s.appURL + "/magic-link?token=" + url.QueryEscape(token)
+	if err := s.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Your MobileShop sign-in link",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen the link below to sign in to MobileShop:\n\n%s\n\n"+
+			"The link works once and expires in %d minutes. "+
+			"If you did not ask for it, you can ignore this email.", user.Name, signInURL, int(s.ttl.Minutes())),
+	}); err != nil {
+		log.Printf("Failed to send sign-in link to user %s: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+// ConsumeLink signs the user in with a token from RequestLink. The result is
+// the same as a password login's, including the second step for accounts
+// with MFA enabled. Opening the link proves the user owns the email address,
+// so an unverified address is marked verified.
+func (s *MagicLinkService) ConsumeLink(token string, client ClientInfo) (*LoginResult, error) {
+	claims, err := s.keySet.ParseFor(signing.PurposeMagicLink, token)
+	if err != nil || claims["typ"] != TokenTypeMagicLink {
+		return nil, apperrors.ErrInvalidMagicLink
+	}
+
+	userID, _ := claims["user_id"].(string)
+	jti, _ := claims["jti"].(string)
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidMagicLink
+	}
+	linkID, err := uuid.Parse(jti)
+	if err != nil {
+		return nil, apperrors.ErrInvalidMagicLink
+	}
+
+	used, err := s.magicLinkRepo.MarkUsed(linkID, userUUID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume sign-in link: %w", err)
+	}
+	if !used {
+		return nil, apperrors.ErrInvalidMagicLink
+	}
+
+	user, err := s.userRepo.GetByID(userUUID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidMagicLink.Wrap(err)
+	}
+
+	if user.VerifiedAt == nil {
+		now := time.Now()
+		if err := s.userRepo.MarkEmailVerified(user.ID, now); err != nil {
+			return nil, fmt.Errorf("failed to consume sign-in link: %w", err)
+		}
+		user.VerifiedAt = &now
+	}
+
+	return s.authService.LoginWithIdentity(user, LoginMethodMagicLink, client)
+}
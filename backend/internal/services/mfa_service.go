@@ -0,0 +1,259 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/hashing"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+	"mobile-shop-backend/internal/totp"
+	"mobile-shop-backend/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	recoveryCodeCount = 10
+	// totpSkew accepts codes from one period before and after the current one
+	// to tolerate clock drift on the user's device.
+	totpSkew = 1
+)
+
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type MFAService struct {
+	userRepo         repositories.UserRepository
+	recoveryCodeRepo repositories.RecoveryCodeRepository
+	loginThrottler   *LoginThrottler
+	passwordHasher   hashing.PasswordHasher
+	issuer           string
+}
+
+func NewMFAService(userRepo repositories.UserRepository, recoveryCodeRepo repositories.RecoveryCodeRepository, loginThrottler *LoginThrottler, passwordHasher hashing.PasswordHasher, issuer string) *MFAService {
+	return &MFAService{
+		userRepo:         userRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		loginThrottler:   loginThrottler,
+		passwordHasher:   passwordHasher,
+		issuer:           issuer,
+	}
+}
+
+// BeginEnrollment generates a new TOTP secret for the user. MFA stays off
+// until the secret is confirmed with a valid code.
+func (s *MFAService) BeginEnrollment(userID string) (*MFAEnrollment, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFAEnabledAt != nil {
+		return nil, apperrors.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	if err := s.userRepo.UpdateMFA(user.ID, secret, nil); err != nil {
+		return nil, fmt.Errorf("failed to start enrollment: %w", err)
+	}
+
+	return &MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmEnrollment enables MFA once the user proves their authenticator
+// produces valid codes, and returns a fresh set of recovery codes.
+func (s *MFAService) ConfirmEnrollment(userID string, code string) ([]string, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFAEnabledAt != nil {
+		return nil, apperrors.ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, apperrors.ErrMFAEnrollmentNotStarted
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, apperrors.ErrInvalidMFACode
+	}
+
+	now := time.Now()
+	if err := s.userRepo.UpdateMFA(user.ID, user.TOTPSecret, &now); err != nil {
+		return nil, fmt.Errorf("failed to enable mfa: %w", err)
+	}
+	if _, err := s.userRepo.AdvanceTOTPStep(user.ID, step); err != nil {
+		return nil, fmt.Errorf("failed to enable mfa: %w", err)
+	}
+
+	return s.replaceRecoveryCodes(user.ID)
+}
+
+// Disable turns MFA off after re-checking the password and a current code.
+// Wrong passwords and codes count towards the account's login lockout.
+func (s *MFAService) Disable(userID string, password string, code string) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.MFAEnabledAt == nil {
+		return apperrors.ErrMFANotEnabled
+	}
+
+	accountKey := AccountKey(user.ID)
+	if err := s.loginThrottler.CheckAccount(accountKey); err != nil {
+		return err
+	}
+
+	if ok, err := s.passwordHasher.Verify(user.Password, password); err != nil || !ok {
+		if err := s.loginThrottler.RecordFailure(accountKey, ""); err != nil {
+			return err
+		}
+		return apperrors.ErrInvalidPassword.Wrap(err)
+	}
+
+	if err := s.verifyThrottledCode(user, code); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdateMFA(user.ID, "", nil); err != nil {
+		return fmt.Errorf("failed to disable mfa: %w", err)
+	}
+	if err := s.recoveryCodeRepo.DeleteForUser(user.ID); err != nil {
+		return fmt.Errorf("failed to disable mfa: %w", err)
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes invalidates the existing recovery codes and
+// returns a new set. Wrong codes count towards the account's login lockout.
+func (s *MFAService) RegenerateRecoveryCodes(userID string, code string) ([]string, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFAEnabledAt == nil {
+		return nil, apperrors.ErrMFANotEnabled
+	}
+
+	if err := s.loginThrottler.CheckAccount(AccountKey(user.ID)); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyThrottledCode(user, code); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(user.ID)
+}
+
+// VerifyCode accepts either a current TOTP code or an unused recovery code.
+// Each TOTP code and each recovery code can only be used once.
+func (s *MFAService) VerifyCode(user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew); ok {
+		advanced, err := s.userRepo.AdvanceTOTPStep(user.ID, step)
+		if err != nil {
+			return fmt.Errorf("failed to verify mfa code: %w", err)
+		}
+		if !advanced {
+			return apperrors.ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := s.recoveryCodeRepo.Use(user.ID, utils.HashToken(normalizeRecoveryCode(code)), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to verify mfa code: %w", err)
+	}
+	if !used {
+		return apperrors.ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+// verifyThrottledCode is VerifyCode for callers that already passed
+// CheckAccount: a wrong code is recorded as a failed login and a right one
+// clears the account's failures, the same as in CompleteMFALogin.
+func (s *MFAService) verifyThrottledCode(user *models.User, code string) error {
+	accountKey := AccountKey(user.ID)
+	if err := s.VerifyCode(user, code); err != nil {
+		if errors.Is(err, apperrors.ErrInvalidMFACode) {
+			if err := s.loginThrottler.RecordFailure(accountKey, ""); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+	return s.loginThrottler.RecordSuccess(accountKey)
+}
+
+func (s *MFAService) replaceRecoveryCodes(userID uuid.UUID) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+		}
+		codes[i] = code
+		hashes[i] = utils.HashToken(normalizeRecoveryCode(code))
+	}
+
+	if err := s.recoveryCodeRepo.ReplaceForUser(userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+func (s *MFAService) getUser(userID string) (*models.User, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidUserID.Wrap(err)
+	}
+
+	user, err := s.userRepo.GetByID(userUUID)
+	if err != nil {
+		return nil, apperrors.ErrUserNotFound.Wrap(err)
+	}
+
+	return user, nil
+}
+
+// generateRecoveryCode returns a code like "k7qm-2xvd-p4na" with 60 bits of entropy.
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:12]
+	return raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12], nil
+}
+
+// normalizeRecoveryCode lets users type recovery codes without dashes or in
+// upper case.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/oidc"
+	"mobile-shop-backend/internal/repositories"
+	"mobile-shop-backend/internal/utils"
+	"mobile-shop-backend/internal/validators"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const oauthStateTTL = 10 * time.Minute
+
+var usernameDisallowedChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// OIDCService signs users in through external OpenID Connect providers.
+// Identities are matched by provider and subject first; a first sign-in is
+// linked to an existing account with the same verified email, or creates a
+// new account.
+type OIDCService struct {
+	providers    map[string]*oidc.Provider
+	stateRepo    repositories.OAuthStateRepository
+	identityRepo repositories.IdentityRepository
+	userRepo     repositories.UserRepository
+	authService  *AuthService
+}
+
+func NewOIDCService(providers []*oidc.Provider, stateRepo repositories.OAuthStateRepository, identityRepo repositories.IdentityRepository, userRepo repositories.UserRepository, authService *AuthService) *OIDCService {
+	byName := make(map[string]*oidc.Provider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+
+	return &OIDCService{
+		providers:    byName,
+		stateRepo:    stateRepo,
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		authService:  authService,
+	}
+}
+
+// ProviderNames lists the configured providers in alphabetical order.
+func (s *OIDCService) ProviderNames() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginLogin creates a PKCE verifier, nonce and state for the provider and
+// returns the authorization URL the browser should be sent to.
+func (s *OIDCService) BeginLogin(ctx context.Context, providerName string) (string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", apperrors.ErrUnknownProvider
+	}
+
+	state, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to start login: %w", err)
+	}
+	codeVerifier, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to start login: %w", err)
+	}
+	nonce, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return "", fmt.Errorf("failed to start login: %w", err)
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallenge(codeVerifier))
+	if err != nil {
+		return "", apperrors.ErrProviderError.Wrap(fmt.Errorf("provider %s: %w", providerName, err))
+	}
+
+	// Abandoned logins are cleaned up opportunistically.
+	_ = s.stateRepo.DeleteExpired(time.Now())
+
+	if err := s.stateRepo.Create(&models.OAuthState{
+		StateHash:    utils.HashToken(state),
+		Provider:     providerName,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(oauthStateTTL),
+	}); err != nil {
+		return "", fmt.Errorf("failed to start login: %w", err)
+	}
+
+	return authURL, nil
+}
+
+// CompleteLogin handles the provider callback: it checks the state, redeems
+// the code and signs in the linked user.
+func (s *OIDCService) CompleteLogin(ctx context.Context, providerName, state, code string, client ClientInfo) (*LoginResult, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, apperrors.ErrUnknownProvider
+	}
+
+	stored, err := s.stateRepo.Consume(utils.HashToken(state))
+	if err != nil || stored.Provider != providerName || time.Now().After(stored.ExpiresAt) {
+		return nil, apperrors.ErrInvalidOAuthState
+	}
+
+	claims, err := provider.Exchange(ctx, code, stored.CodeVerifier, stored.Nonce)
+	if err != nil {
+		return nil, apperrors.ErrProviderError.Wrap(fmt.Errorf("provider %s: %w", providerName, err))
+	}
+
+	user, err := s.resolveUser(providerName, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.authService.LoginWithIdentity(user, "oidc:"+providerName, client)
+}
+
+func (s *OIDCService) resolveUser(providerName string, claims *oidc.Claims) (*models.User, error) {
+	if identity, err := s.identityRepo.GetByProviderSubject(providerName, claims.Subject); err == nil {
+		user, err := s.userRepo.GetByID(identity.UserID)
+		if err != nil {
+			return nil, apperrors.ErrUserNotFound.Wrap(err)
+		}
+		return user, nil
+	}
+
+	// Linking by email is only safe when both sides have proven ownership of
+	// the address; otherwise someone could pre-register a victim's email and
+	// inherit their provider sign-ins.
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, apperrors.ErrProviderEmailNotVerified
+	}
+	if err := validators.ValidateEmail(claims.Email); err != nil {
+		return nil, apperrors.ErrProviderEmailInvalid.Wrap(err)
+	}
+
+	user, err := s.userRepo.GetByEmail(claims.Email)
+	if err == nil {
+		if user.VerifiedAt == nil {
+			return nil, apperrors.ErrAccountEmailNotVerified
+		}
+	} else {
+		user, err = s.createUser(claims)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.identityRepo.Create(&models.Identity{
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	return user, nil
+}
+
+// createUser registers an account for a first-time provider sign-in. The
+// email counts as verified and the password is random; the user can set one
+// through the password reset flow.
+func (s *OIDCService) createUser(claims *oidc.Claims) (*models.User, error) {
+	username, err := s.availableUsername(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	hashedPassword, err := s.authService.passwordHasher.Hash(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	name := strings.TrimSpace(claims.Name)
+	if len(name) < 2 {
+		name = username
+	}
+	if len(name) > 50 {
+		// Names are limited to 50 bytes; cut at the last rune that fits so a
+		// multi-byte character is not split.
+		cut := 0
+		for i := range name {
+			if i > 50 {
+				break
+			}
+			cut = i
+		}
+		name = strings.TrimSpace(name[:cut])
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Name:       name,
+		Username:   username,
+		Email:      validators.NormalizeEmail(claims.Email),
+		Password:   hashedPassword,
+		VerifiedAt: &now,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return user, nil
+}
+
+// availableUsername derives a username from the provider's preferred
+// username or the email local part, adding a numeric suffix when taken. The
+// email must have been validated.
+func (s *OIDCService) availableUsername(claims *oidc.Claims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base = claims.Email[:strings.LastIndex(claims.Email, "@")]
+	}
+	base = strings.Trim(usernameDisallowedChars.ReplaceAllString(validators.NormalizeUsername(base), "_"), "_")
+	if len(base) > 24 {
+		base = base[:24]
+	}
+	if len(base) < 3 {
+		base = "user"
+	}
+
+	candidate := base
+	for attempt := 0; attempt < 5; attempt++ {
+		exists, err := s.userRepo.UsernameExists(candidate)
+		if err != nil {
+			return "", fmt.Errorf("failed to check username existence: %w", err)
+		}
+		if !exists {
+			return candidate, nil
+		}
+
+		suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", fmt.Errorf("failed to create user: %w", err)
+		}
+		candidate = base + "_" + suffix.String()
+	}
+
+	return "", errors.New("failed to create user")
+}
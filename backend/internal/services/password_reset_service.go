@@ -0,0 +1,154 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/hashing"
+	"mobile-shop-backend/internal/mail"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+	"mobile-shop-backend/internal/utils"
+	"mobile-shop-backend/internal/validators"
+	"net/url"
+	"time"
+)
+
+const passwordResetTTL = time.Hour
+
+// passwordResetResendInterval is how often a new reset link is sent to the
+// same account; requests in between are silently dropped.
+const passwordResetResendInterval = time.Minute
+
+type PasswordResetService struct {
+	userRepo         repositories.UserRepository
+	resetRepo        repositories.PasswordResetRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	sessionRepo      repositories.SessionRepository
+	loginThrottler   *LoginThrottler
+	passwordHasher   hashing.PasswordHasher
+	passwordPolicy   *validators.PasswordPolicy
+	mailer           mail.Mailer
+	appURL           string
+}
+
+func NewPasswordResetService(userRepo repositories.UserRepository, resetRepo repositories.PasswordResetRepository, refreshTokenRepo repositories.RefreshTokenRepository, sessionRepo repositories.SessionRepository, loginThrottler *LoginThrottler, passwordHasher hashing.PasswordHasher, passwordPolicy *validators.PasswordPolicy, mailer mail.Mailer, appURL string) *PasswordResetService {
+	return &PasswordResetService{
+		userRepo:         userRepo,
+		resetRepo:        resetRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		sessionRepo:      sessionRepo,
+		loginThrottler:   loginThrottler,
+		passwordHasher:   passwordHasher,
+		passwordPolicy:   passwordPolicy,
+		mailer:           mailer,
+		appURL:           appURL,
+	}
+}
+
+// RequestReset emails a password reset link to the account registered with
+// email. Unknown addresses are ignored so callers cannot probe for accounts,
+// and for the same reason a failed delivery is only logged.
+func (s *PasswordResetService) RequestReset(email string) error {
+	user, err := s.userRepo.GetByEmail(email)
+	if err != nil {
+		return nil
+	}
+
+	if latest, err := s.resetRepo.GetLatestForUser(user.ID); err == nil && time.Since(latest.CreatedAt) < passwordResetResendInterval {
+		return nil
+	}
+
+	// Only the most recent link should work.
+	if err := s.resetRepo.DeleteForUser(user.ID); err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+
+	if err := s.resetRepo.Create(&models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}); err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+
+	link := s.appURL + "/reset-password?token=" + url.QueryEscape(token)
+	if err := s.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Reset your MobileShop password",
+		Body: fmt.Sprintf("Hi %s,\n\nWe received a request to reset your password. "+
+			"Use the link below within the next hour to choose a new one:\n\n%s\n\n"+
+			"If you did not ask for this, you can ignore this email.", user.Name, link),
+	}); err != nil {
+		log.Printf("Failed to send reset email to user %s: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password using a token from RequestReset, signs
+// the user out everywhere and lifts any login lockout on the account.
+func (s *PasswordResetService) ResetPassword(token string, newPassword string) error {
+	stored, err := s.resetRepo.GetByHash(utils.HashToken(token))
+	if err != nil {
+		return apperrors.ErrInvalidResetToken.Wrap(err)
+	}
+
+	if stored.UsedAt != nil {
+		return apperrors.ErrInvalidResetToken
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return apperrors.ErrResetTokenExpired
+	}
+
+	// Checked before the token is used up, so the user can pick another
+	// password with the same link.
+	user, err := s.userRepo.GetByID(stored.UserID)
+	if err != nil {
+		return apperrors.ErrInvalidResetToken.Wrap(err)
+	}
+	if err := checkPasswordPolicy(s.passwordPolicy, newPassword, user.Name, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	marked, err := s.resetRepo.MarkUsed(stored.ID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+	if !marked {
+		return apperrors.ErrInvalidResetToken
+	}
+
+	hashedPassword, err := s.passwordHasher.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.userRepo.UpdatePassword(stored.UserID, hashedPassword); err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	if err := s.userRepo.IncrementTokenVersion(stored.UserID); err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	if err := s.refreshTokenRepo.RevokeAllForUser(stored.UserID); err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	if err := s.sessionRepo.RevokeAllForUser(stored.UserID); err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	if err := s.loginThrottler.Unlock(stored.UserID); err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	return nil
+}
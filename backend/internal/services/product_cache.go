@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"mobile-shop-backend/internal/cache"
+	"mobile-shop-backend/internal/models"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// productCacheKeyPrefix namespaces the cache keys; bump the version when
+// the cached format changes.
+const productCacheKeyPrefix = "catalog:v1:"
+
+// ProductCacheConfig controls how long cached catalog responses are used.
+type ProductCacheConfig struct {
+	// TTL is how long a response is served without asking the source.
+	TTL time.Duration
+	// StaleTTL is how long after TTL a response is still served while it
+	// is refreshed in the background.
+	StaleTTL time.Duration
+}
+
+func DefaultProductCacheConfig() ProductCacheConfig {
+	return ProductCacheConfig{
+		TTL:      time.Minute,
+		StaleTTL: 5 * time.Minute,
+	}
+}
+
+// ProductCacheStats counts how requests to a CachedProductSource were
+// answered.
+type ProductCacheStats struct {
+	Hits      int64 `json:"hits"`
+	StaleHits int64 `json:"stale_hits"`
+	Misses    int64 `json:"misses"`
+	// Errors counts failed cache reads and writes; the request is then
+	// served from the source.
+	Errors int64 `json:"errors"`
+}
+
+// cachedResponse is what is stored in the cache.
+type cachedResponse struct {
+	StoredAt time.Time       `json:"stored_at"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// CachedProductSource caches the responses of another ProductSource.
+// Concurrent misses for the same key share a single call to the source.
+// Errors are not cached, and a failing cache store only costs the caching.
+type CachedProductSource struct {
+	source ProductSource
+	store  cache.Store
+	config ProductCacheConfig
+	calls  singleflight.Group
+
+	hits      atomic.Int64
+	staleHits atomic.Int64
+	misses    atomic.Int64
+	errors    atomic.Int64
+}
+
+func NewCachedProductSource(source ProductSource, store cache.Store, config ProductCacheConfig) *CachedProductSource {
+	return &CachedProductSource{source: source, store: store, config: config}
+}
+
+func (s *CachedProductSource) ListProducts(ctx context.Context, query models.ProductQuery) (*models.ProductPage, error) {
+	var page models.ProductPage
+	err := s.get(ctx, productsCacheKey(query), &page, func(ctx context.Context) (interface{}, error) {
+		return s.source.ListProducts(ctx, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (s *CachedProductSource) ListCategories(ctx context.Context) ([]string, error) {
+	var categories []string
+	err := s.get(ctx, productCacheKeyPrefix+"categories", &categories, func(ctx context.Context) (interface{}, error) {
+		return s.source.ListCategories(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (s *CachedProductSource) GetProduct(ctx context.Context, id int) (*models.ProductDetail, error) {
+	var product models.ProductDetail
+	err := s.get(ctx, productCacheKeyPrefix+"product:"+strconv.Itoa(id), &product, func(ctx context.Context) (interface{}, error) {
+		return s.source.GetProduct(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// Stats returns the counters since the source was created.
+func (s *CachedProductSource) Stats() ProductCacheStats {
+	return ProductCacheStats{
+		Hits:      s.hits.Load(),
+		StaleHits: s.staleHits.Load(),
+		Misses:    s.misses.Load(),
+		Errors:    s.errors.Load(),
+	}
+}
+
+// get decodes the response cached under key into dest, calling fetch when
+// there is none. A stale response is returned as is and refreshed in the
+// background.
+func (s *CachedProductSource) get(ctx context.Context, key string, dest interface{}, fetch func(context.Context) (interface{}, error)) error {
+	if cached, ok := s.read(ctx, key); ok && json.Unmarshal(cached.Value, dest) == nil {
+		if time.Since(cached.StoredAt) < s.config.TTL {
+			s.hits.Add(1)
+			return nil
+		}
+		s.staleHits.Add(1)
+		s.calls.DoChan(key, func() (interface{}, error) {
+			data, err := s.refresh(context.Background(), key, fetch)
+			if err != nil {
+				log.Printf("Warning: failed to refresh cached %s: %v", key, err)
+			}
+			return data, err
+		})
+		return nil
+	}
+
+	s.misses.Add(1)
+	// The call is shared, so it must not end when this caller gives up.
+	results := s.calls.DoChan(key, func() (interface{}, error) {
+		return s.refresh(context.WithoutCancel(ctx), key, fetch)
+	})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case result := <-results:
+		if result.Err != nil {
+			return result.Err
+		}
+		return json.Unmarshal(result.Val.([]byte), dest)
+	}
+}
+
+func (s *CachedProductSource) read(ctx context.Context, key string) (*cachedResponse, bool) {
+	data, ok, err := s.store.Get(ctx, key)
+	if err != nil {
+		s.errors.Add(1)
+		log.Printf("Warning: failed to read %s from the product cache: %v", key, err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		s.errors.Add(1)
+		return nil, false
+	}
+	return &cached, true
+}
+
+// refresh calls fetch and caches its result, returning it encoded.
+func (s *CachedProductSource) refresh(ctx context.Context, key string, fetch func(context.Context) (interface{}, error)) ([]byte, error) {
+	value, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := json.Marshal(cachedResponse{StoredAt: time.Now(), Value: data})
+	if err == nil {
+		err = s.store.Set(ctx, key, entry, s.config.TTL+s.config.StaleTTL)
+	}
+	if err != nil {
+		s.errors.Add(1)
+		log.Printf("Warning: failed to write %s to the product cache: %v", key, err)
+	}
+	return data, nil
+}
+
+// productsCacheKey identifies the response to query. Searches match
+// regardless of case, so the search term is lower-cased; the parameters are
+// encoded in a fixed order.
+func productsCacheKey(query models.ProductQuery) string {
+	params := url.Values{}
+	params.Set("search", strings.ToLower(query.Search))
+	params.Set("category", query.Category)
+	params.Set("sort", query.SortBy)
+	params.Set("desc", strconv.FormatBool(query.SortDesc))
+	if query.PriceMin != nil {
+		params.Set("min", strconv.FormatFloat(*query.PriceMin, 'f', -1, 64))
+	}
+	if query.PriceMax != nil {
+		params.Set("max", strconv.FormatFloat(*query.PriceMax, 'f', -1, 64))
+	}
+	params.Set("limit", strconv.Itoa(query.Limit))
+	params.Set("skip", strconv.Itoa(query.Skip))
+	return productCacheKeyPrefix + "products?" + params.Encode()
+}
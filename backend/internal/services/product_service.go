@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// ProductSource is where the catalog comes from. ProductService serves the
+// shop's own catalog from the database; catalog.HTTPSource proxies an
+// upstream API.
+type ProductSource interface {
+	ListProducts(ctx context.Context, query models.ProductQuery) (*models.ProductPage, error)
+	ListCategories(ctx context.Context) ([]string, error)
+	// GetProduct returns apperrors.ErrProductNotFound for unknown IDs.
+	GetProduct(ctx context.Context, id int) (*models.ProductDetail, error)
+}
+
+type ProductService struct {
+	productRepo repositories.ProductRepository
+}
+
+func NewProductService(productRepo repositories.ProductRepository) *ProductService {
+	return &ProductService{productRepo: productRepo}
+}
+
+// ListProducts returns the page of the catalog selected by query. Products
+// are sorted by title unless query.SortBy names another sort column.
+func (s *ProductService) ListProducts(_ context.Context, query models.ProductQuery) (*models.ProductPage, error) {
+	switch query.SortBy {
+	case models.ProductSortTitle, models.ProductSortPrice, models.ProductSortRating:
+	default:
+		query.SortBy = models.ProductSortTitle
+	}
+
+	products, total, err := s.productRepo.List(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+	if products == nil {
+		products = []models.Product{}
+	}
+
+	return &models.ProductPage{
+		Products: products,
+		Total:    total,
+		Skip:     query.Skip,
+		Limit:    query.Limit,
+	}, nil
+}
+
+// GetProduct returns the product with id and what its page shows with it.
+func (s *ProductService) GetProduct(_ context.Context, id int) (*models.ProductDetail, error) {
+	product, err := s.productRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	counts, err := s.productRepo.RatingCounts(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count product ratings: %w", err)
+	}
+	breakdown := models.NewRatingBreakdown()
+	for rating, count := range counts {
+		breakdown.Add(rating, count)
+	}
+
+	reviews, err := s.productRepo.Reviews(id, models.ProductDetailReviews)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product reviews: %w", err)
+	}
+	related, err := s.productRepo.Related(product, models.ProductDetailRelated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list related products: %w", err)
+	}
+	if reviews == nil {
+		reviews = []models.ProductReview{}
+	}
+	if related == nil {
+		related = []models.Product{}
+	}
+
+	return &models.ProductDetail{
+		Product:         *product,
+		RatingBreakdown: breakdown,
+		Reviews:         reviews,
+		Related:         related,
+	}, nil
+}
+
+// ListCategories returns the categories that have products.
+func (s *ProductService) ListCategories(_ context.Context) ([]string, error) {
+	categories, err := s.productRepo.Categories()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	return categories, nil
+}
+
+// SeedCatalog fills an empty catalog with products and their reviews. A
+// catalog that already has products is left alone.
+func (s *ProductService) SeedCatalog(products []models.Product, reviews []models.ProductReview) error {
+	count, err := s.productRepo.Count()
+	if err != nil {
+		return fmt.Errorf("failed to count products: %v", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	if err := s.productRepo.CreateBatch(products, reviews); err != nil {
+		return fmt.Errorf("failed to seed products: %v", err)
+	}
+	return nil
+}
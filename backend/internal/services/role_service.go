@@ -0,0 +1,170 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type RoleService struct {
+	roleRepo repositories.RoleRepository
+	userRepo repositories.UserRepository
+}
+
+func NewRoleService(roleRepo repositories.RoleRepository, userRepo repositories.UserRepository) *RoleService {
+	return &RoleService{
+		roleRepo: roleRepo,
+		userRepo: userRepo,
+	}
+}
+
+// SeedDefaults creates the built-in roles and syncs their permissions.
+func (s *RoleService) SeedDefaults() error {
+	for name, permissions := range models.DefaultRoles {
+		if err := s.roleRepo.EnsureRole(name, permissions); err != nil {
+			return fmt.Errorf("failed to seed role %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// BootstrapAdmin grants the admin role to the user whose username or email
+// matches identifier, but only while no admin exists yet. It is a no-op once
+// the first admin has been created. An email only matches once it has been
+// verified, so whoever registers the address first cannot claim the role.
+func (s *RoleService) BootstrapAdmin(identifier string) error {
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		return nil
+	}
+
+	count, err := s.roleRepo.CountUsersWithRole(models.RoleAdmin)
+	if err != nil {
+		return fmt.Errorf("failed to count admins: %v", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	user, err := s.userRepo.GetByUsername(identifier)
+	if err != nil {
+		user, err = s.userRepo.GetByEmail(identifier)
+		if err == nil && user.VerifiedAt == nil {
+			return fmt.Errorf("bootstrap admin %q has not verified its email; verify it or use the username", identifier)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("bootstrap admin %q not found; register the account first", identifier)
+	}
+
+	if _, err := s.SetUserRoles(user.ID.String(), []string{models.RoleAdmin}); err != nil {
+		return err
+	}
+
+	log.Printf("Granted the admin role to %s", user.Username)
+	return nil
+}
+
+func (s *RoleService) ListRoles() ([]models.Role, error) {
+	roles, err := s.roleRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	return roles, nil
+}
+
+// GetUserAccess returns the names of the user's roles and the union of their
+// permissions, both sorted.
+func (s *RoleService) GetUserAccess(userID uuid.UUID) ([]string, []string, error) {
+	roles, err := s.roleRepo.GetUserRoles(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load roles: %w", err)
+	}
+
+	roleNames := make([]string, 0, len(roles))
+	permissionSet := make(map[string]struct{})
+	for _, role := range roles {
+		roleNames = append(roleNames, role.Name)
+		for _, permission := range role.Permissions {
+			permissionSet[permission.Name] = struct{}{}
+		}
+	}
+
+	permissions := make([]string, 0, len(permissionSet))
+	for permission := range permissionSet {
+		permissions = append(permissions, permission)
+	}
+	sort.Strings(roleNames)
+	sort.Strings(permissions)
+
+	return roleNames, permissions, nil
+}
+
+// GetRoleNames returns the sorted role names of each of the users; every
+// user is present, with an empty list when they have no roles.
+func (s *RoleService) GetRoleNames(userIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	found, err := s.roleRepo.GetRoleNamesForUsers(userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load roles: %w", err)
+	}
+
+	roleNames := make(map[uuid.UUID][]string, len(userIDs))
+	for _, userID := range userIDs {
+		names := append([]string{}, found[userID]...)
+		sort.Strings(names)
+		roleNames[userID] = names
+	}
+	return roleNames, nil
+}
+
+// SetUserRoles replaces the user's roles. Existing tokens carry the old roles
+// as claims, so the user's token version is bumped to force a re-login.
+func (s *RoleService) SetUserRoles(userID string, roleNames []string) ([]string, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidUserID.Wrap(err)
+	}
+
+	if _, err := s.userRepo.GetByID(userUUID); err != nil {
+		return nil, apperrors.ErrUserNotFound.Wrap(err)
+	}
+
+	roles, err := s.roleRepo.GetByNames(roleNames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load roles: %w", err)
+	}
+
+	found := make(map[string]bool, len(roles))
+	roleIDs := make([]uint, 0, len(roles))
+	for _, role := range roles {
+		found[role.Name] = true
+		roleIDs = append(roleIDs, role.ID)
+	}
+	for _, name := range roleNames {
+		if !found[name] {
+			return nil, apperrors.ErrUnknownRole
+		}
+	}
+
+	if err := s.roleRepo.SetUserRoles(userUUID, roleIDs); err != nil {
+		return nil, fmt.Errorf("failed to update roles: %w", err)
+	}
+
+	if err := s.userRepo.IncrementTokenVersion(userUUID); err != nil {
+		return nil, fmt.Errorf("failed to update roles: %w", err)
+	}
+
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"fmt"
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const maxUserAgentLength = 512
+
+// ClientInfo describes the device a request comes from. It is recorded on
+// the session created at login.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// SessionView is a session as shown to its owner.
+type SessionView struct {
+	models.Session
+	Current bool `json:"current"`
+}
+
+type SessionService struct {
+	sessionRepo      repositories.SessionRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+}
+
+func NewSessionService(sessionRepo repositories.SessionRepository, refreshTokenRepo repositories.RefreshTokenRepository) *SessionService {
+	return &SessionService{
+		sessionRepo:      sessionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+	}
+}
+
+// ListSessions returns the user's active sessions, flagging the one the
+// request was made from.
+func (s *SessionService) ListSessions(userID string, currentSessionID string) ([]SessionView, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperrors.ErrInvalidUserID.Wrap(err)
+	}
+
+	sessions, err := s.sessionRepo.ListActiveForUser(userUUID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	views := make([]SessionView, 0, len(sessions))
+	for _, session := range sessions {
+		views = append(views, SessionView{
+			Session: session,
+			Current: session.ID.String() == currentSessionID,
+		})
+	}
+	return views, nil
+}
+
+// RevokeSession signs the user out of one of their sessions. Its refresh
+// tokens stop working immediately and AuthMiddleware rejects its access
+// tokens.
+func (s *SessionService) RevokeSession(userID string, sessionID string) error {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return apperrors.ErrInvalidUserID.Wrap(err)
+	}
+	sessionUUID, err := uuid.Parse(sessionID)
+	if err != nil {
+		return apperrors.ErrInvalidSessionID.Wrap(err)
+	}
+
+	revoked, err := s.sessionRepo.Revoke(sessionUUID, userUUID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if !revoked {
+		return apperrors.ErrSessionNotFound
+	}
+
+	if err := s.refreshTokenRepo.RevokeFamily(sessionUUID); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,294 @@
+// Package signing issues and verifies the API's JWTs with asymmetric keys.
+// A KeySet signs with one active key and accepts tokens from every key it
+// holds, so keys can be rotated without logging everyone out: publish the new
+// key, switch signing to it, and drop the old one once its tokens expired.
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minRSAKeyBits = 2048
+
+// Purposes of the tokens that are not access tokens. Each is signed for its
+// own audience, so a verifier that expects access tokens, including one using
+// the published JWKS, rejects them.
+const (
+	PurposeMFA       = "mfa"
+	PurposeMagicLink = "magic-link"
+)
+
+// Key is a signing or verification key. Private is nil for keys that are
+// only used to verify tokens issued before a rotation.
+type Key struct {
+	ID        string
+	Algorithm string
+	Public    crypto.PublicKey
+	Private   crypto.PrivateKey
+}
+
+// KeySet signs tokens with its active key and verifies them against all keys.
+type KeySet struct {
+	issuer   string
+	audience string
+	active   *Key
+	keys     map[string]*Key
+	methods  []string
+}
+
+// NewKeySet creates a key set. The signing key must include its private
+// half; verification keys are accepted in addition to it.
+func NewKeySet(issuer, audience string, signingKey *Key, verificationKeys ...*Key) (*KeySet, error) {
+	if signingKey == nil || signingKey.Private == nil {
+		return nil, errors.New("signing key must include a private key")
+	}
+	if issuer == "" || audience == "" {
+		return nil, errors.New("issuer and audience are required")
+	}
+
+	s := &KeySet{
+		issuer:   issuer,
+		audience: audience,
+		active:   signingKey,
+		keys:     map[string]*Key{},
+	}
+
+	seen := map[string]bool{}
+	for _, key := range append([]*Key{signingKey}, verificationKeys...) {
+		if _, exists := s.keys[key.ID]; exists {
+			continue
+		}
+		s.keys[key.ID] = key
+		if !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			s.methods = append(s.methods, key.Algorithm)
+		}
+	}
+
+	return s, nil
+}
+
+// Issuer returns the value of the "iss" claim.
+func (s *KeySet) Issuer() string {
+	return s.issuer
+}
+
+// Audience returns the value of the "aud" claim.
+func (s *KeySet) Audience() string {
+	return s.audience
+}
+
+// Sign signs an access token: it adds the issuer and audience to the claims
+// and signs them with the active key, naming it in the "kid" header.
+func (s *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	return s.sign(s.audience, claims)
+}
+
+// SignFor signs a token for the given purpose, such as PurposeMFA, with the
+// audience AudienceFor(purpose).
+func (s *KeySet) SignFor(purpose string, claims jwt.MapClaims) (string, error) {
+	return s.sign(s.AudienceFor(purpose), claims)
+}
+
+// Parse verifies an access token and returns its claims. The "kid" header
+// must name a known key and the "alg" header must match that key's
+// algorithm, so a token can never pick its own verification method. Issuer,
+// audience and expiry are required.
+func (s *KeySet) Parse(tokenString string) (jwt.MapClaims, error) {
+	return s.parse(s.audience, tokenString)
+}
+
+// ParseFor verifies a token signed with SignFor for the same purpose.
+func (s *KeySet) ParseFor(purpose, tokenString string) (jwt.MapClaims, error) {
+	return s.parse(s.AudienceFor(purpose), tokenString)
+}
+
+// AudienceFor returns the "aud" claim of tokens signed for purpose.
+func (s *KeySet) AudienceFor(purpose string) string {
+	return s.audience + ":" + purpose
+}
+
+func (s *KeySet) sign(audience string, claims jwt.MapClaims) (string, error) {
+	claims["iss"] = s.issuer
+	claims["aud"] = audience
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(s.active.Algorithm), claims)
+	token.Header["kid"] = s.active.ID
+	return token.SignedString(s.active.Private)
+}
+
+func (s *KeySet) parse(audience, tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := s.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing algorithm %q", token.Method.Alg())
+		}
+		return key.Public, nil
+	},
+		jwt.WithValidMethods(s.methods),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// JSONWebKey is the public half of a key as published in the JWKS document.
+type JSONWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns every verification key, active key first.
+func (s *KeySet) JWKS() JWKS {
+	ids := make([]string, 0, len(s.keys))
+	for id := range s.keys {
+		if id != s.active.ID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	jwks := JWKS{Keys: []JSONWebKey{s.active.jwk()}}
+	for _, id := range ids {
+		jwks.Keys = append(jwks.Keys, s.keys[id].jwk())
+	}
+	return jwks
+}
+
+func (k *Key) jwk() JSONWebKey {
+	jwk := JSONWebKey{Kid: k.ID, Alg: k.Algorithm, Use: "sig"}
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// ParseKeyPEM reads an RSA or Ed25519 key from PEM. Private keys may be
+// PKCS#8 or PKCS#1; public keys must be PKIX. RSA keys sign with RS256 and
+// Ed25519 keys with EdDSA. The key ID is the RFC 7638 thumbprint.
+func ParseKeyPEM(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var private crypto.PrivateKey
+	var public crypto.PublicKey
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		private = parsed
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		private = parsed
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		public = parsed
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+
+	switch key := private.(type) {
+	case *rsa.PrivateKey:
+		public = &key.PublicKey
+	case ed25519.PrivateKey:
+		public = key.Public()
+	case nil:
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+
+	return newKey(public, private)
+}
+
+// GenerateEd25519Key creates a new random Ed25519 signing key.
+func GenerateEd25519Key() (*Key, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newKey(public, private)
+}
+
+func newKey(public crypto.PublicKey, private crypto.PrivateKey) (*Key, error) {
+	key := &Key{Public: public, Private: private}
+
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA keys must be at least %d bits", minRSAKeyBits)
+		}
+		key.Algorithm = jwt.SigningMethodRS256.Alg()
+	case ed25519.PublicKey:
+		key.Algorithm = jwt.SigningMethodEdDSA.Alg()
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+
+	key.ID = thumbprint(key.jwk())
+	return key, nil
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint: the SHA-256 of the
+// required members in lexicographic order.
+func thumbprint(jwk JSONWebKey) string {
+	var canonical string
+	switch jwk.Kty {
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, jwk.Crv, jwk.X)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,65 @@
+package signing
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// Config locates the keys and sets the claims every token must carry.
+type Config struct {
+	// SigningKeyFile is the PEM private key used to sign new tokens.
+	SigningKeyFile string
+	// VerificationKeyFiles are PEM keys that are still accepted, typically
+	// the previous signing key during a rotation.
+	VerificationKeyFiles []string
+	Issuer               string
+	Audience             string
+}
+
+// LoadKeySet reads the configured keys. Without a signing key file an
+// ephemeral Ed25519 key is generated, which invalidates all tokens on
+// restart and is only meant for local development.
+func LoadKeySet(cfg Config) (*KeySet, error) {
+	var signingKey *Key
+	if cfg.SigningKeyFile != "" {
+		key, err := loadKeyFile(cfg.SigningKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if key.Private == nil {
+			return nil, fmt.Errorf("%s does not contain a private key", cfg.SigningKeyFile)
+		}
+		signingKey = key
+	} else {
+		key, err := GenerateEd25519Key()
+		if err != nil {
+			return nil, err
+		}
+		log.Println("Warning: no JWT signing key configured, using an ephemeral key; tokens will not survive a restart")
+		signingKey = key
+	}
+
+	var verificationKeys []*Key
+	for _, path := range cfg.VerificationKeyFiles {
+		key, err := loadKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+
+	return NewKeySet(cfg.Issuer, cfg.Audience, signingKey, verificationKeys...)
+}
+
+func loadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %v", path, err)
+	}
+	key, err := ParseKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key %s: %v", path, err)
+	}
+	return key, nil
+}
@@ -1,15 +1,17 @@
 package utils
 
 import (
+	"mobile-shop-backend/internal/apperrors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Code    string `json:"code,omitempty"`
-	Details string `json:"details,omitempty"`
+	Error      string                `json:"error"`
+	Code       string                `json:"code,omitempty"`
+	Details    string                `json:"details,omitempty"`
+	Violations []apperrors.Violation `json:"violations,omitempty"`
 }
 
 type SuccessResponse struct {
@@ -3,10 +3,13 @@ package validators
 import (
 	"errors"
 	"mobile-shop-backend/internal/models"
+	"net/mail"
 	"regexp"
 	"strings"
 )
 
+var emailDomainRegex = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z]{2,63}$`)
+
 func ValidateRegisterRequest(req *models.RegisterRequest) error {
 	if err := validateName(req.Name); err != nil {
 		return err
@@ -16,7 +19,7 @@ func ValidateRegisterRequest(req *models.RegisterRequest) error {
 		return err
 	}
 
-	if err := validateEmail(req.Email); err != nil {
+	if err := ValidateEmail(req.Email); err != nil {
 		return err
 	}
 
@@ -28,25 +31,85 @@ func ValidateRegisterRequest(req *models.RegisterRequest) error {
 }
 
 func ValidateLoginRequest(req *models.LoginRequest) error {
-	if strings.TrimSpace(req.Username) == "" {
-		return errors.New("username is required")
+	identifier := strings.TrimSpace(req.LoginIdentifier())
+	if identifier == "" {
+		return errors.New("username or email is required")
 	}
 
 	if strings.TrimSpace(req.Password) == "" {
 		return errors.New("password is required")
 	}
 
-	if len(req.Username) < 3 {
-		return errors.New("username must be at least 3 characters long")
+	if len(identifier) < 3 {
+		return errors.New("username or email must be at least 3 characters long")
+	}
+
+	return nil
+}
+
+func ValidateResetPasswordRequest(req *models.ResetPasswordRequest) error {
+	if strings.TrimSpace(req.Token) == "" {
+		return errors.New("reset token is required")
+	}
+
+	return validatePassword(req.Password)
+}
+
+func ValidateUpdateProfileRequest(req *models.UpdateProfileRequest) error {
+	if req.Name == nil && req.Username == nil && req.Email == nil {
+		return errors.New("nothing to update")
+	}
+
+	if req.Name != nil {
+		if err := validateName(*req.Name); err != nil {
+			return err
+		}
+	}
+
+	if req.Username != nil {
+		if err := validateUsername(*req.Username); err != nil {
+			return err
+		}
 	}
 
-	if len(req.Password) < 6 {
-		return errors.New("password must be at least 6 characters long")
+	if req.Email != nil {
+		if err := ValidateEmail(*req.Email); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+func ValidateChangePasswordRequest(req *models.ChangePasswordRequest) error {
+	if req.CurrentPassword == "" {
+		return errors.New("current password is required")
+	}
+
+	if err := validatePassword(req.NewPassword); err != nil {
+		return err
+	}
+
+	if req.NewPassword == req.CurrentPassword {
+		return errors.New("new password must be different from the current password")
+	}
+
+	return nil
+}
+
+// NormalizeEmail returns the canonical form of an email address. Addresses
+// are compared case-insensitively, so John@Example.com and john@example.com
+// belong to the same account.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NormalizeUsername returns the canonical form of a username. Like emails,
+// usernames are case-insensitive.
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
 func validateName(name string) error {
 	name = strings.TrimSpace(name)
 	if name == "" {
@@ -82,19 +145,27 @@ func validateUsername(username string) error {
 	return nil
 }
 
-func validateEmail(email string) error {
+// ValidateEmail checks that email is a plain address with a dotted domain.
+func ValidateEmail(email string) error {
 	email = strings.TrimSpace(email)
 	if email == "" {
 		return errors.New("email is required")
 	}
 
-	emailRegex := regexp.MustCompile(`^\S+@\S+$`)
-	if !emailRegex.MatchString(email) {
+	if len(email) > 100 {
+		return errors.New("email must be no more than 100 characters long")
+	}
+
+	// Reject display names ("John <john@example.com>") and anything that
+	// net/mail rewrites, then require a dotted domain.
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
 		return errors.New("invalid email")
 	}
 
-	if len(email) > 100 {
-		return errors.New("email must be no more than 100 characters long")
+	domain := email[strings.LastIndex(email, "@")+1:]
+	if !emailDomainRegex.MatchString(domain) {
+		return errors.New("invalid email")
 	}
 
 	return nil
@@ -105,10 +176,7 @@ func validatePassword(password string) error {
 		return errors.New("password is required")
 	}
 
-	if len(password) < 6 {
-		return errors.New("password must be at least 6 characters long")
-	}
-
+	// Everything else is up to the configured PasswordPolicy.
 	if len(password) > 100 {
 		return errors.New("password must be no more than 100 characters long")
 	}
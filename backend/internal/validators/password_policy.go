@@ -0,0 +1,251 @@
+package validators
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"mobile-shop-backend/internal/apperrors"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Password policy rules, as reported in apperrors.Violation.Rule.
+const (
+	PasswordRuleMinLength    = "min_length"
+	PasswordRuleLowercase    = "lowercase"
+	PasswordRuleUppercase    = "uppercase"
+	PasswordRuleDigit        = "digit"
+	PasswordRuleSymbol       = "symbol"
+	PasswordRulePersonalInfo = "personal_info"
+	PasswordRuleStrength     = "strength"
+	PasswordRuleBreached     = "breached"
+)
+
+// CharClass is a kind of character a policy can require.
+type CharClass string
+
+const (
+	CharClassLower  CharClass = "lower"
+	CharClassUpper  CharClass = "upper"
+	CharClassDigit  CharClass = "digit"
+	CharClassSymbol CharClass = "symbol"
+)
+
+// ParseCharClass returns the CharClass named s.
+func ParseCharClass(s string) (CharClass, error) {
+	switch class := CharClass(strings.ToLower(strings.TrimSpace(s))); class {
+	case CharClassLower, CharClassUpper, CharClassDigit, CharClassSymbol:
+		return class, nil
+	default:
+		return "", fmt.Errorf("unknown character class %q", s)
+	}
+}
+
+// PasswordPolicy decides which new passwords are accepted. Zero values turn
+// the corresponding rule off.
+type PasswordPolicy struct {
+	MinLength       int
+	RequiredClasses []CharClass
+	// DisallowPersonalInfo rejects passwords containing the user's name,
+	// username or email address.
+	DisallowPersonalInfo bool
+	// MinStrength is the lowest PasswordStrength score accepted, 0-4.
+	MinStrength int
+	// Breached, when set, rejects passwords found in a breach corpus.
+	Breached BreachedPasswords
+}
+
+// DefaultPasswordPolicy returns the policy used unless configured otherwise.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:            8,
+		DisallowPersonalInfo: true,
+		MinStrength:          2,
+	}
+}
+
+// Check returns every rule password breaks. personalInfo holds the user's
+// name, username and email. An error means the breach corpus could not be
+// consulted.
+func (p *PasswordPolicy) Check(password string, personalInfo ...string) ([]apperrors.Violation, error) {
+	var violations []apperrors.Violation
+	add := func(rule, message string) {
+		violations = append(violations, apperrors.Violation{Rule: rule, Message: message})
+	}
+
+	if len([]rune(password)) < p.MinLength {
+		add(PasswordRuleMinLength, fmt.Sprintf("password must be at least %d characters long", p.MinLength))
+	}
+
+	for _, class := range p.RequiredClasses {
+		if !containsClass(password, class) {
+			rule, message := classRule(class)
+			add(rule, message)
+		}
+	}
+
+	if p.DisallowPersonalInfo && containsPersonalInfo(password, personalInfo) {
+		add(PasswordRulePersonalInfo, "password must not contain your name, username or email address")
+	}
+
+	if p.MinStrength > 0 && PasswordStrength(password, personalInfo...) < p.MinStrength {
+		add(PasswordRuleStrength, "password is too easy to guess; avoid common words, sequences and repeated characters")
+	}
+
+	if p.Breached != nil {
+		breached, err := IsBreached(p.Breached, password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check breached passwords: %w", err)
+		}
+		if breached {
+			add(PasswordRuleBreached, "password has appeared in a data breach; choose a different one")
+		}
+	}
+
+	return violations, nil
+}
+
+func containsClass(password string, class CharClass) bool {
+	for _, r := range password {
+		switch class {
+		case CharClassLower:
+			if unicode.IsLower(r) {
+				return true
+			}
+		case CharClassUpper:
+			if unicode.IsUpper(r) {
+				return true
+			}
+		case CharClassDigit:
+			if unicode.IsDigit(r) {
+				return true
+			}
+		case CharClassSymbol:
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func classRule(class CharClass) (string, string) {
+	switch class {
+	case CharClassLower:
+		return PasswordRuleLowercase, "password must contain a lowercase letter"
+	case CharClassUpper:
+		return PasswordRuleUppercase, "password must contain an uppercase letter"
+	case CharClassDigit:
+		return PasswordRuleDigit, "password must contain a digit"
+	default:
+		return PasswordRuleSymbol, "password must contain a symbol"
+	}
+}
+
+func containsPersonalInfo(password string, personalInfo []string) bool {
+	lower := strings.ToLower(password)
+	for _, info := range personalInfo {
+		for _, token := range personalTokens(info) {
+			if len(token) >= 3 && strings.Contains(lower, token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BreachedPasswords looks up breached password hashes using the k-anonymity
+// range scheme of Have I Been Pwned: only the first five hex characters of
+// the password's SHA-1 are passed in, and the matching suffix is picked out
+// by the caller.
+type BreachedPasswords interface {
+	// Range returns the remaining 35 hex characters of every breached hash
+	// starting with prefix, mapped to how often it was seen.
+	Range(prefix string) (map[string]int, error)
+}
+
+// IsBreached reports whether password appears in source.
+func IsBreached(source BreachedPasswords, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := source.Range(hash[:5])
+	if err != nil {
+		return false, err
+	}
+	return suffixes[hash[5:]] > 0, nil
+}
+
+// BreachCorpus is a BreachedPasswords held in memory, loaded from a local
+// copy of a breach corpus.
+type BreachCorpus struct {
+	ranges map[string]map[string]int
+}
+
+// LoadBreachCorpus reads a corpus with one "SHA1HEX" or "SHA1HEX:COUNT"
+// entry per line, the format of the Pwned Passwords downloads. Blank lines
+// and lines starting with # are ignored.
+func LoadBreachCorpus(path string) (*BreachCorpus, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	corpus := &BreachCorpus{ranges: make(map[string]map[string]int)}
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		hash, countText, hasCount := strings.Cut(line, ":")
+		count := 1
+		if hasCount {
+			if count, err = strconv.Atoi(strings.TrimSpace(countText)); err != nil || count < 1 {
+				return nil, fmt.Errorf("%s:%d: invalid count %q", path, lineNo, countText)
+			}
+		}
+		if err := corpus.add(hash, count); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return corpus, nil
+}
+
+func (c *BreachCorpus) add(hash string, count int) error {
+	hash = strings.ToUpper(strings.TrimSpace(hash))
+	if len(hash) != sha1.Size*2 {
+		return fmt.Errorf("invalid SHA-1 hash %q", hash)
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return fmt.Errorf("invalid SHA-1 hash %q", hash)
+	}
+
+	prefix, suffix := hash[:5], hash[5:]
+	if c.ranges[prefix] == nil {
+		c.ranges[prefix] = make(map[string]int)
+	}
+	c.ranges[prefix][suffix] += count
+	return nil
+}
+
+func (c *BreachCorpus) Range(prefix string) (map[string]int, error) {
+	return c.ranges[strings.ToUpper(prefix)], nil
+}
+
+// Len returns the number of distinct hashes in the corpus.
+func (c *BreachCorpus) Len() int {
+	n := 0
+	for _, suffixes := range c.ranges {
+		n += len(suffixes)
+	}
+	return n
+}
@@ -0,0 +1,298 @@
+package validators
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// commonPasswords are the most used passwords and password words, most
+// common first. A match costs an attacker about as many guesses as its rank.
+var commonPasswords = []string{
+	"password", "123456", "qwerty", "letmein", "welcome", "admin", "login",
+	"abc123", "iloveyou", "monkey", "dragon", "master", "sunshine", "princess",
+	"football", "baseball", "shadow", "superman", "batman", "trustno1",
+	"hello", "freedom", "whatever", "starwars", "passw0rd", "michael",
+	"jennifer", "jordan", "hunter", "ranger", "buster", "soccer", "hockey",
+	"killer", "george", "charlie", "andrew", "thomas", "jessica", "pepper",
+	"ginger", "summer", "winter", "spring", "autumn", "flower", "cookie",
+	"cheese", "banana", "orange", "purple", "secret", "access", "mustang",
+	"matrix", "computer", "internet", "samsung", "apple", "google", "mobile",
+	"phone", "shop", "mobileshop", "changeme", "default", "guest", "root",
+	"test", "user", "love", "baby", "angel", "family", "friend", "money",
+	"lucky", "happy", "qazwsx", "zaq1", "asdf", "pass", "secure", "private",
+	"summer", "blink", "tigger", "chelsea", "liverpool", "arsenal", "yankees",
+	"dallas", "maggie", "daniel", "ashley", "nicole", "robert", "matthew",
+	"london", "paris", "berlin", "hello123", "admin123", "welcome1",
+	"password1", "iloveu", "qwertyuiop", "zxcvbn", "monday", "friday",
+}
+
+var commonPasswordRanks = func() map[string]int {
+	ranks := make(map[string]int, len(commonPasswords))
+	for i, word := range commonPasswords {
+		if _, ok := ranks[word]; !ok {
+			ranks[word] = i + 1
+		}
+	}
+	return ranks
+}()
+
+// keyboardRows are runs of adjacent keys on a QWERTY keyboard.
+var keyboardRows = []string{"qwertyuiop", "asdfghjkl", "zxcvbnm", "1234567890", "!@#$%^&*()"}
+
+var leetSubstitutions = strings.NewReplacer(
+	"4", "a", "@", "a", "8", "b", "3", "e", "6", "g", "1", "i", "!", "i",
+	"0", "o", "5", "s", "$", "s", "7", "t", "+", "t", "2", "z",
+)
+
+// bruteForceGuessesPerChar is the cost of a character that is not part of
+// any recognised pattern.
+const bruteForceGuessesPerChar = 10
+
+// PasswordStrength scores how hard password is to guess, from 0 (guessed
+// almost immediately) to 4 (very hard), in the manner of zxcvbn. The password
+// is split into the cheapest sequence of recognisable patterns - common
+// passwords, the user's own details, repeated characters, sequences,
+// keyboard runs and years - and anything left over is counted as brute
+// force. userInputs such as the username and email are treated as the most
+// likely guesses of all.
+func PasswordStrength(password string, userInputs ...string) int {
+	log10Guesses := estimateLog10Guesses(password, userInputs)
+	switch {
+	case log10Guesses < 3:
+		return 0
+	case log10Guesses < 6:
+		return 1
+	case log10Guesses < 8:
+		return 2
+	case log10Guesses < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// patternMatch covers password[start:end] and costs guesses attempts.
+type patternMatch struct {
+	start, end int
+	guesses    float64
+}
+
+// estimateLog10Guesses finds the segmentation of password into pattern
+// matches and brute-forced characters that needs the fewest guesses.
+func estimateLog10Guesses(password string, userInputs []string) float64 {
+	chars := []rune(password)
+	n := len(chars)
+	if n == 0 {
+		return 0
+	}
+
+	matchesByEnd := make([][]patternMatch, n+1)
+	for _, m := range findPatterns(chars, userInputs) {
+		matchesByEnd[m.end] = append(matchesByEnd[m.end], m)
+	}
+
+	best := make([]float64, n+1)
+	for end := 1; end <= n; end++ {
+		best[end] = best[end-1] + math.Log10(bruteForceGuessesPerChar)
+		for _, m := range matchesByEnd[end] {
+			if cost := best[m.start] + math.Log10(m.guesses); cost < best[end] {
+				best[end] = cost
+			}
+		}
+	}
+	return best[n]
+}
+
+func findPatterns(chars []rune, userInputs []string) []patternMatch {
+	var matches []patternMatch
+	matches = append(matches, dictionaryMatches(chars, userInputs)...)
+	matches = append(matches, repeatMatches(chars)...)
+	matches = append(matches, sequenceMatches(chars)...)
+	matches = append(matches, keyboardMatches(chars)...)
+	matches = append(matches, yearMatches(chars)...)
+	return matches
+}
+
+func dictionaryMatches(chars []rune, userInputs []string) []patternMatch {
+	ranks := make(map[string]int, len(userInputs))
+	for _, input := range userInputs {
+		for _, token := range personalTokens(input) {
+			ranks[token] = 1
+		}
+	}
+
+	var matches []patternMatch
+	for start := 0; start < len(chars); start++ {
+		for end := start + 3; end <= len(chars); end++ {
+			original := string(chars[start:end])
+			lower := strings.ToLower(original)
+			unleet := leetSubstitutions.Replace(lower)
+
+			rank, ok := ranks[lower]
+			if !ok {
+				rank, ok = commonPasswordRanks[lower]
+			}
+			leet := false
+			if !ok {
+				if rank, ok = ranks[unleet]; !ok {
+					rank, ok = commonPasswordRanks[unleet]
+				}
+				leet = ok
+			}
+			if !ok {
+				continue
+			}
+
+			guesses := float64(rank)
+			if lower != original {
+				guesses *= 2
+			}
+			if leet {
+				guesses *= 2
+			}
+			matches = append(matches, patternMatch{start: start, end: end, guesses: guesses})
+		}
+	}
+	return matches
+}
+
+func repeatMatches(chars []rune) []patternMatch {
+	var matches []patternMatch
+	for start := 0; start < len(chars); {
+		end := start + 1
+		for end < len(chars) && chars[end] == chars[start] {
+			end++
+		}
+		if end-start >= 3 {
+			matches = append(matches, patternMatch{start: start, end: end, guesses: charCardinality(chars[start]) * float64(end-start)})
+		}
+		start = end
+	}
+	return matches
+}
+
+// sequenceMatches finds runs like "abcd", "4321" or "xyz".
+func sequenceMatches(chars []rune) []patternMatch {
+	var matches []patternMatch
+	for start := 0; start < len(chars)-2; {
+		delta := chars[start+1] - chars[start]
+		if (delta != 1 && delta != -1) || !sameClass(chars[start], chars[start+1]) {
+			start++
+			continue
+		}
+
+		end := start + 2
+		for end < len(chars) && chars[end]-chars[end-1] == delta && sameClass(chars[end], chars[start]) {
+			end++
+		}
+		if end-start >= 3 {
+			guesses := float64(end-start) * sequenceStartGuesses(chars[start])
+			if delta < 0 {
+				guesses *= 2
+			}
+			matches = append(matches, patternMatch{start: start, end: end, guesses: guesses})
+		}
+		start = end - 1
+	}
+	return matches
+}
+
+// keyboardMatches finds runs of adjacent keys such as "qwer" or "lkjh".
+func keyboardMatches(chars []rune) []patternMatch {
+	lower := []rune(strings.ToLower(string(chars)))
+	var matches []patternMatch
+	for _, row := range keyboardRows {
+		for _, line := range []string{row, reverse(row)} {
+			for start := 0; start < len(lower); start++ {
+				end := start
+				for end < len(lower) && strings.Contains(line, string(lower[start:end+1])) {
+					end++
+				}
+				if end-start >= 4 {
+					matches = append(matches, patternMatch{start: start, end: end, guesses: 20 * float64(end-start)})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// yearMatches finds years between 1900 and 2039.
+func yearMatches(chars []rune) []patternMatch {
+	var matches []patternMatch
+	for start := 0; start+4 <= len(chars); start++ {
+		year := string(chars[start : start+4])
+		if (strings.HasPrefix(year, "19") || strings.HasPrefix(year, "20")) && isDigits(year) && year <= "2039" {
+			matches = append(matches, patternMatch{start: start, end: start + 4, guesses: 140})
+		}
+	}
+	return matches
+}
+
+// personalTokens splits a user input such as a name or email address into
+// the pieces someone might build a password from.
+func personalTokens(input string) []string {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if input == "" {
+		return nil
+	}
+
+	tokens := []string{input}
+	if at := strings.Index(input, "@"); at > 0 {
+		tokens = append(tokens, input[:at])
+	}
+	for _, part := range strings.FieldsFunc(input, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(part) >= 3 {
+			tokens = append(tokens, part)
+		}
+	}
+	return tokens
+}
+
+func charCardinality(r rune) float64 {
+	switch {
+	case unicode.IsDigit(r):
+		return 10
+	case unicode.IsLetter(r):
+		return 26
+	default:
+		return 33
+	}
+}
+
+func sequenceStartGuesses(r rune) float64 {
+	switch r {
+	case 'a', 'A', 'z', 'Z', '0', '1', '9':
+		return 4
+	}
+	if unicode.IsDigit(r) {
+		return 10
+	}
+	return 26
+}
+
+func sameClass(a, b rune) bool {
+	return (unicode.IsDigit(a) && unicode.IsDigit(b)) ||
+		(unicode.IsLower(a) && unicode.IsLower(b)) ||
+		(unicode.IsUpper(a) && unicode.IsUpper(b))
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"mobile-shop-backend/internal/config"
 	"mobile-shop-backend/internal/database"
+	"mobile-shop-backend/internal/middleware"
 	"mobile-shop-backend/internal/routes"
+	"net/http"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -17,10 +24,15 @@ func main() {
 		log.Println("Warning: Could not load .env file, using system environment variables")
 	}
 
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	r := gin.Default()
 
 	// Configure trusted proxies for security
-	trustedProxies := getTrustedProxies()
+	trustedProxies := cfg.TrustedProxies
 
 	if err := r.SetTrustedProxies(trustedProxies); err != nil {
 		log.Printf("Warning: Failed to set trusted proxies: %v", err)
@@ -29,52 +41,39 @@ func main() {
 	log.Printf("Configured trusted proxies: %v", trustedProxies)
 
 	// Configure CORS
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{
-		"http://localhost:3000",
-		"http://localhost:5173",
-		"http://localhost:5174",
-		"http://34.142.218.188",
-		"http://34.142.218.188:8080",
-		"https://34-142-218-188.sslip.io",
-	}
-	config.AllowCredentials = true
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
-	r.Use(cors.New(config))
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOrigins = cfg.CORSOrigins
+	corsConfig.AllowCredentials = true
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-API-Key"}
+	r.Use(cors.New(corsConfig))
+	r.Use(middleware.ErrorHandler())
 
-	db, err := database.InitDB()
+	db, err := database.InitDB(cfg.Database)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
 	log.Println("Database connected successfully")
-	routes.SetupRoutes(r, db)
+	accountService := routes.SetupRoutes(r, db, cfg)
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	log.Printf("Server starting on port %s", port)
-	log.Fatal(r.Run(":" + port))
-}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go accountService.RunPurger(ctx, time.Hour)
 
-func getTrustedProxies() []string {
-	envProxies := os.Getenv("TRUSTED_PROXIES")
-	if envProxies != "" {
-		proxies := strings.Split(envProxies, ",")
-		for i, proxy := range proxies {
-			proxies[i] = strings.TrimSpace(proxy)
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: r}
+	go func() {
+		log.Printf("Server starting on port %s", cfg.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed: %v", err)
 		}
-		return proxies
-	}
+	}()
 
-	// Default trusted proxies for development and common scenarios
-	return []string{
-		"127.0.0.1",      // localhost
-		"::1",            // localhost IPv6
-		"10.0.0.0/8",     // private network
-		"172.16.0.0/12",  // private network
-		"192.168.0.0/16", // private network
+	<-ctx.Done()
+	stop()
+	log.Println("Shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown failed: %v", err)
 	}
 }
@@ -0,0 +1,52 @@
+package mocks
+
+import (
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Create(key *models.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) GetByID(id uuid.UUID) (*models.APIKey, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetByHash(keyHash string) (*models.APIKey, error) {
+	args := m.Called(keyHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) ListForUser(userID uuid.UUID) ([]models.APIKey, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Touch(id uuid.UUID, ipAddress string, usedAt time.Time, staleBefore time.Time) error {
+	args := m.Called(id, ipAddress, usedAt, staleBefore)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) Revoke(id uuid.UUID) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
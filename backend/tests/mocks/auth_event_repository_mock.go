@@ -0,0 +1,33 @@
+package mocks
+
+import (
+	"mobile-shop-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuthEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuthEventRepository) Create(event *models.AuthEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+func (m *MockAuthEventRepository) List(filter models.AuthEventFilter, offset, limit int) ([]models.AuthEvent, int64, error) {
+	args := m.Called(filter, offset, limit)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.AuthEvent), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockAuthEventRepository) ListForUser(userID uuid.UUID) ([]models.AuthEvent, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AuthEvent), args.Error(1)
+}
@@ -0,0 +1,56 @@
+package mocks
+
+import (
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdentityRepository) Create(identity *models.Identity) error {
+	args := m.Called(identity)
+	return args.Error(0)
+}
+
+func (m *MockIdentityRepository) GetByProviderSubject(provider, subject string) (*models.Identity, error) {
+	args := m.Called(provider, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Identity), args.Error(1)
+}
+
+func (m *MockIdentityRepository) ListForUser(userID uuid.UUID) ([]models.Identity, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Identity), args.Error(1)
+}
+
+type MockOAuthStateRepository struct {
+	mock.Mock
+}
+
+func (m *MockOAuthStateRepository) Create(state *models.OAuthState) error {
+	args := m.Called(state)
+	return args.Error(0)
+}
+
+func (m *MockOAuthStateRepository) Consume(stateHash string) (*models.OAuthState, error) {
+	args := m.Called(stateHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OAuthState), args.Error(1)
+}
+
+func (m *MockOAuthStateRepository) DeleteExpired(now time.Time) error {
+	args := m.Called(now)
+	return args.Error(0)
+}
@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMagicLinkRepository struct {
+	mock.Mock
+}
+
+func (m *MockMagicLinkRepository) Create(link *models.MagicLink) error {
+	args := m.Called(link)
+	return args.Error(0)
+}
+
+func (m *MockMagicLinkRepository) GetLatestForUser(userID uuid.UUID) (*models.MagicLink, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MagicLink), args.Error(1)
+}
+
+func (m *MockMagicLinkRepository) MarkUsed(id uuid.UUID, userID uuid.UUID, usedAt time.Time) (bool, error) {
+	args := m.Called(id, userID, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMagicLinkRepository) DeleteForUser(userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
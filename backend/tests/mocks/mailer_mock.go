@@ -0,0 +1,16 @@
+package mocks
+
+import (
+	"mobile-shop-backend/internal/mail"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(msg mail.Message) error {
+	args := m.Called(msg)
+	return args.Error(0)
+}
@@ -0,0 +1,236 @@
+package mocks
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MockOIDCIssuer is a minimal OpenID Connect provider served by httptest.
+// It implements discovery, JWKS and the token endpoint with PKCE; the
+// browser step is simulated by Authorize.
+type MockOIDCIssuer struct {
+	Server       *httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	// Identity asserted for the next authorization.
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+
+	// ModifyIDToken, when set, can tamper with the ID token claims.
+	ModifyIDToken func(claims jwt.MapClaims)
+
+	// GitHub makes the issuer answer like GitHub: no ID token, and the
+	// identity served from /user, with a numeric GitHubID and a Login, and
+	// /user/emails.
+	GitHub   bool
+	GitHubID int64
+	Login    string
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]pendingAuthorization
+}
+
+type pendingAuthorization struct {
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+	claims        jwt.MapClaims
+}
+
+func NewMockOIDCIssuer(clientID, clientSecret string) *MockOIDCIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	m := &MockOIDCIssuer{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		key:          key,
+		codes:        map[string]pendingAuthorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/jwks", m.jwks)
+	mux.HandleFunc("/token", m.token)
+	mux.HandleFunc("/user", m.user)
+	mux.HandleFunc("/user/emails", m.userEmails)
+	m.Server = httptest.NewServer(mux)
+
+	return m
+}
+
+func (m *MockOIDCIssuer) URL() string {
+	return m.Server.URL
+}
+
+func (m *MockOIDCIssuer) Close() {
+	m.Server.Close()
+}
+
+// Authorize plays the part of the user approving the login at the provider.
+// It returns the code and state the provider would redirect back with.
+func (m *MockOIDCIssuer) Authorize(authURL string) (code string, state string, err error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	q := u.Query()
+	if q.Get("client_id") != m.ClientID || q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" {
+		return "", "", errors.New("invalid authorization request")
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	code = base64.RawURLEncoding.EncodeToString(b)
+	claims := jwt.MapClaims{
+		"sub":            m.Subject,
+		"email":          m.Email,
+		"email_verified": m.EmailVerified,
+		"name":           m.Name,
+	}
+
+	m.mu.Lock()
+	m.codes[code] = pendingAuthorization{
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+		redirectURI:   q.Get("redirect_uri"),
+		claims:        claims,
+	}
+	m.mu.Unlock()
+
+	return code, q.Get("state"), nil
+}
+
+func (m *MockOIDCIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 m.URL(),
+		"authorization_endpoint": m.URL() + "/authorize",
+		"token_endpoint":         m.URL() + "/token",
+		"jwks_uri":               m.URL() + "/jwks",
+	})
+}
+
+func (m *MockOIDCIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": "test-key",
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (m *MockOIDCIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+	if r.Form.Get("client_id") != m.ClientID || r.Form.Get("client_secret") != m.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	m.mu.Lock()
+	pending, ok := m.codes[r.Form.Get("code")]
+	delete(m.codes, r.Form.Get("code"))
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !ok || pending.redirectURI != r.Form.Get("redirect_uri") || base64.RawURLEncoding.EncodeToString(sum[:]) != pending.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   m.URL(),
+		"aud":   m.ClientID,
+		"nonce": pending.nonce,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range pending.claims {
+		claims[k] = v
+	}
+	if m.ModifyIDToken != nil {
+		m.ModifyIDToken(claims)
+	}
+
+	if m.GitHub {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"access_token": "mock-access-token",
+			"token_type":   "bearer",
+			"scope":        "read:user,user:email",
+		})
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	idToken, err := token.SignedString(m.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// user serves the GitHub user object, which has no sub or email_verified.
+// The public email may be empty.
+func (m *MockOIDCIssuer) user(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer mock-access-token" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Bad credentials"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":    m.GitHubID,
+		"login": m.Login,
+		"name":  m.Name,
+		"email": nil,
+	})
+}
+
+func (m *MockOIDCIssuer) userEmails(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer mock-access-token" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Bad credentials"})
+		return
+	}
+	writeJSON(w, http.StatusOK, []map[string]interface{}{
+		{"email": "old-" + m.Email, "primary": false, "verified": true},
+		{"email": m.Email, "primary": true, "verified": m.EmailVerified},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,44 @@
+package mocks
+
+import (
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepository) Create(token *models.PasswordResetToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) GetByHash(tokenHash string) (*models.PasswordResetToken, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) GetLatestForUser(userID uuid.UUID) (*models.PasswordResetToken, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) MarkUsed(id uuid.UUID, usedAt time.Time) (bool, error) {
+	args := m.Called(id, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) DeleteForUser(userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
@@ -0,0 +1,69 @@
+package mocks
+
+import (
+	"mobile-shop-backend/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProductRepository struct {
+	mock.Mock
+}
+
+func (m *MockProductRepository) List(query models.ProductQuery) ([]models.Product, int64, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.Product), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockProductRepository) GetByID(id int) (*models.Product, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) Related(product *models.Product, limit int) ([]models.Product, error) {
+	args := m.Called(product, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) Reviews(productID, limit int) ([]models.ProductReview, error) {
+	args := m.Called(productID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ProductReview), args.Error(1)
+}
+
+func (m *MockProductRepository) RatingCounts(productID int) (map[int]int64, error) {
+	args := m.Called(productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int]int64), args.Error(1)
+}
+
+func (m *MockProductRepository) Categories() ([]string, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockProductRepository) Count() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockProductRepository) CreateBatch(products []models.Product, reviews []models.ProductReview) error {
+	args := m.Called(products, reviews)
+	return args.Error(0)
+}
@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"context"
+	"mobile-shop-backend/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProductSource struct {
+	mock.Mock
+}
+
+func (m *MockProductSource) ListProducts(ctx context.Context, query models.ProductQuery) (*models.ProductPage, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductPage), args.Error(1)
+}
+
+func (m *MockProductSource) ListCategories(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockProductSource) GetProduct(ctx context.Context, id int) (*models.ProductDetail, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ProductDetail), args.Error(1)
+}
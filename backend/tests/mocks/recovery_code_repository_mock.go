@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRecoveryCodeRepository struct {
+	mock.Mock
+}
+
+func (m *MockRecoveryCodeRepository) ReplaceForUser(userID uuid.UUID, codeHashes []string) error {
+	args := m.Called(userID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockRecoveryCodeRepository) Use(userID uuid.UUID, codeHash string, usedAt time.Time) (bool, error) {
+	args := m.Called(userID, codeHash, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRecoveryCodeRepository) CountUnused(userID uuid.UUID) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRecoveryCodeRepository) DeleteForUser(userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
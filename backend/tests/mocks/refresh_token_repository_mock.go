@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(token *models.RefreshToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByHash(tokenHash string) (*models.RefreshToken, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) MarkUsed(id uuid.UUID, usedAt time.Time) (bool, error) {
+	args := m.Called(id, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(familyID uuid.UUID) error {
+	args := m.Called(familyID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeAllForUser(userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeOthersForUser(userID uuid.UUID, exceptFamilyID uuid.UUID) error {
+	args := m.Called(userID, exceptFamilyID)
+	return args.Error(0)
+}
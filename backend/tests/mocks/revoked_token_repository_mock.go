@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRevokedTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRevokedTokenRepository) Revoke(token *models.RevokedToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockRevokedTokenRepository) IsRevoked(jti string) (bool, error) {
+	args := m.Called(jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRevokedTokenRepository) DeleteExpired(before time.Time) error {
+	args := m.Called(before)
+	return args.Error(0)
+}
@@ -0,0 +1,59 @@
+package mocks
+
+import (
+	"mobile-shop-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRoleRepository struct {
+	mock.Mock
+}
+
+func (m *MockRoleRepository) EnsureRole(name string, permissions []string) error {
+	args := m.Called(name, permissions)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) List() ([]models.Role, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) GetByNames(names []string) ([]models.Role, error) {
+	args := m.Called(names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) GetUserRoles(userID uuid.UUID) ([]models.Role, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) GetRoleNamesForUsers(userIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	args := m.Called(userIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID][]string), args.Error(1)
+}
+
+func (m *MockRoleRepository) SetUserRoles(userID uuid.UUID, roleIDs []uint) error {
+	args := m.Called(userID, roleIDs)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) CountUsersWithRole(name string) (int64, error) {
+	args := m.Called(name)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,67 @@
+package mocks
+
+import (
+	"mobile-shop-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) Create(session *models.Session) error {
+	args := m.Called(session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) GetByID(id uuid.UUID) (*models.Session, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) ListActiveForUser(userID uuid.UUID, now time.Time) ([]models.Session, error) {
+	args := m.Called(userID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) ListForUser(userID uuid.UUID) ([]models.Session, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) Refresh(id uuid.UUID, ipAddress, userAgent string, lastSeenAt, expiresAt time.Time) error {
+	args := m.Called(id, ipAddress, userAgent, lastSeenAt, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) Touch(id uuid.UUID, ipAddress string, lastSeenAt time.Time, staleBefore time.Time) error {
+	args := m.Called(id, ipAddress, lastSeenAt, staleBefore)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) Revoke(id uuid.UUID, userID uuid.UUID) (bool, error) {
+	args := m.Called(id, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSessionRepository) RevokeAllForUser(userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) RevokeOthersForUser(userID uuid.UUID, exceptID uuid.UUID) error {
+	args := m.Called(userID, exceptID)
+	return args.Error(0)
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"mobile-shop-backend/internal/models"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -40,6 +41,14 @@ func (m *MockUserRepository) GetByID(id uuid.UUID) (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) List(offset, limit int) ([]models.User, int64, error) {
+	args := m.Called(offset, limit)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
+}
+
 func (m *MockUserRepository) EmailExists(email string) (bool, error) {
 	args := m.Called(email)
 	return args.Bool(0), args.Error(1)
@@ -49,3 +58,48 @@ func (m *MockUserRepository) UsernameExists(username string) (bool, error) {
 	args := m.Called(username)
 	return args.Bool(0), args.Error(1)
 }
+
+func (m *MockUserRepository) UpdateProfile(user *models.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdatePassword(id uuid.UUID, passwordHash string) error {
+	args := m.Called(id, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) IncrementTokenVersion(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(id uuid.UUID, verifiedAt time.Time) error {
+	args := m.Called(id, verifiedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetVerificationSentAt(id uuid.UUID, sentAt time.Time) error {
+	args := m.Called(id, sentAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateMFA(id uuid.UUID, totpSecret string, enabledAt *time.Time) error {
+	args := m.Called(id, totpSecret, enabledAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) AdvanceTOTPStep(id uuid.UUID, step int64) (bool, error) {
+	args := m.Called(id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) SoftDelete(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) PurgeDeleted(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
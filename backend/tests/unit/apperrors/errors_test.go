@@ -0,0 +1,66 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/apperrors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError_Wrap(t *testing.T) {
+	cause := errors.New("record not found")
+	err := apperrors.ErrUserNotFound.Wrap(cause)
+
+	assert.ErrorIs(t, err, apperrors.ErrUserNotFound)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, apperrors.ErrInvalidUserID)
+	assert.Equal(t, "User not found: record not found", err.Error())
+	assert.Nil(t, apperrors.ErrUserNotFound.Unwrap(), "the sentinel is not modified")
+}
+
+func TestError_IsThroughWrapping(t *testing.T) {
+	err := fmt.Errorf("deleting account: %w", apperrors.ErrInvalidPassword)
+
+	var appErr *apperrors.Error
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "INVALID_PASSWORD", appErr.Code)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidPassword)
+}
+
+func TestError_SameCodeDifferentSentinel(t *testing.T) {
+	assert.NotErrorIs(t, apperrors.ErrInvalidMFALoginCode, apperrors.ErrInvalidMFACode)
+}
+
+func TestError_WithRetryAfter(t *testing.T) {
+	err := apperrors.ErrTooManyAttempts.WithRetryAfter(time.Minute)
+
+	assert.ErrorIs(t, err, apperrors.ErrTooManyAttempts)
+	assert.Equal(t, time.Minute, err.RetryAfter)
+	assert.Zero(t, apperrors.ErrTooManyAttempts.RetryAfter)
+}
+
+func TestValidation(t *testing.T) {
+	err := apperrors.Validation(errors.New("email is required"))
+
+	assert.ErrorIs(t, err, apperrors.ErrValidation)
+	assert.Equal(t, "email is required", err.Message)
+	assert.Equal(t, 400, err.Status)
+}
+
+func TestInternal(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := apperrors.Internal(cause)
+
+	assert.ErrorIs(t, err, apperrors.ErrInternal)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "Internal server error", err.Message)
+}
+
+func TestCode(t *testing.T) {
+	assert.Equal(t, "INVALID_CREDENTIALS", apperrors.Code(fmt.Errorf("login: %w", apperrors.ErrInvalidCredentials)))
+	assert.Equal(t, "INTERNAL_ERROR", apperrors.Code(errors.New("connection refused")))
+}
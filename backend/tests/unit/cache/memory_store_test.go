@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/cache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_SetAndGet(t *testing.T) {
+	store := cache.NewMemoryStore(10)
+	ctx := context.Background()
+
+	_, found, err := store.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, store.Set(ctx, "key", []byte("first"), time.Minute))
+	require.NoError(t, store.Set(ctx, "key", []byte("second"), time.Minute))
+
+	value, found, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("second"), value)
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	store := cache.NewMemoryStore(10)
+	ctx := context.Background()
+
+	require.NoError(t, store.Set(ctx, "key", []byte("value"), 20*time.Millisecond))
+	time.Sleep(30 * time.Millisecond)
+
+	_, found, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestMemoryStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	store := cache.NewMemoryStore(3)
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, store.Set(ctx, fmt.Sprintf("key%d", i), []byte("value"), time.Minute))
+	}
+	// Using key1 makes key2 the least recently used.
+	_, _, _ = store.Get(ctx, "key1")
+	require.NoError(t, store.Set(ctx, "key4", []byte("value"), time.Minute))
+
+	assert.Equal(t, 3, store.Len())
+	for key, expected := range map[string]bool{"key1": true, "key2": false, "key3": true, "key4": true} {
+		_, found, _ := store.Get(ctx, key)
+		assert.Equal(t, expected, found, key)
+	}
+}
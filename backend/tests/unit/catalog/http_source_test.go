@@ -0,0 +1,457 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/catalog"
+	"mobile-shop-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upstream is a stand-in for the products API. Each request is answered by
+// the next handler in responses; the last one answers every request after it.
+type upstream struct {
+	*httptest.Server
+	requests  atomic.Int32
+	responses []http.HandlerFunc
+	lastURL   atomic.Value
+}
+
+func newUpstream(t *testing.T, responses ...http.HandlerFunc) *upstream {
+	u := &upstream{responses: responses}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(u.requests.Add(1)) - 1
+		u.lastURL.Store(r.URL.String())
+		if n >= len(u.responses) {
+			n = len(u.responses) - 1
+		}
+		u.responses[n](w, r)
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func (u *upstream) source(configure func(*catalog.HTTPConfig)) *catalog.HTTPSource {
+	config := catalog.HTTPConfig{
+		BaseURL:          u.URL + "/",
+		Timeout:          time.Second,
+		MaxRetries:       2,
+		RetryBackoff:     time.Millisecond,
+		BreakerThreshold: 5,
+		BreakerCooldown:  time.Minute,
+	}
+	if configure != nil {
+		configure(&config)
+	}
+	return catalog.NewHTTPSource(config, nil)
+}
+
+func respondJSON(body interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
+
+func respondStatus(status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}
+}
+
+var testPage = models.ProductPage{
+	Products: []models.Product{
+		{ID: 1, Title: "iPhone 6", Price: 299.99, Category: "smartphones"},
+		{ID: 2, Title: "iPhone X", Price: 899.99, Category: "smartphones"},
+	},
+	Total: 16,
+	Limit: 12,
+}
+
+func TestHTTPSource_ListProductsRequests(t *testing.T) {
+	testCases := []struct {
+		name        string
+		query       models.ProductQuery
+		expectedURL string
+	}{
+		{
+			name:        "All products",
+			query:       models.ProductQuery{SortBy: models.ProductSortTitle, Limit: 12},
+			expectedURL: "/products?limit=12&order=asc&skip=0&sortBy=title",
+		},
+		{
+			name:        "Search",
+			query:       models.ProductQuery{Search: "iphone pro", SortBy: models.ProductSortPrice, SortDesc: true, Limit: 12, Skip: 24},
+			expectedURL: "/products/search?limit=12&order=desc&q=iphone+pro&skip=24&sortBy=price",
+		},
+		{
+			name:        "Category",
+			query:       models.ProductQuery{Category: "mobile accessories", Limit: 6},
+			expectedURL: "/products/category/mobile%20accessories?limit=6&skip=0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u := newUpstream(t, respondJSON(testPage))
+
+			page, err := u.source(nil).ListProducts(context.Background(), tc.query)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedURL, u.lastURL.Load())
+			assert.Equal(t, testPage.Products, page.Products)
+			assert.Equal(t, int64(16), page.Total)
+		})
+	}
+}
+
+func TestHTTPSource_RetriesTemporaryFailures(t *testing.T) {
+	u := newUpstream(t, respondStatus(http.StatusServiceUnavailable), respondStatus(http.StatusBadGateway), respondJSON(testPage))
+
+	page, err := u.source(nil).ListProducts(context.Background(), models.ProductQuery{Limit: 12})
+
+	require.NoError(t, err)
+	assert.Len(t, page.Products, 2)
+	assert.Equal(t, int32(3), u.requests.Load())
+}
+
+func TestHTTPSource_GivesUpAfterMaxRetries(t *testing.T) {
+	u := newUpstream(t, respondStatus(http.StatusInternalServerError))
+
+	_, err := u.source(nil).ListProducts(context.Background(), models.ProductQuery{Limit: 12})
+
+	assert.ErrorIs(t, err, apperrors.ErrProductsUnavailable)
+	assert.Equal(t, int32(3), u.requests.Load(), "one attempt and two retries")
+}
+
+func TestHTTPSource_DoesNotRetryClientErrors(t *testing.T) {
+	u := newUpstream(t, respondStatus(http.StatusNotFound))
+
+	_, err := u.source(nil).ListCategories(context.Background())
+
+	assert.ErrorIs(t, err, apperrors.ErrCategoriesUnavailable)
+	assert.Equal(t, int32(1), u.requests.Load())
+}
+
+func TestHTTPSource_TimesOutSlowResponses(t *testing.T) {
+	u := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+
+	start := time.Now()
+	_, err := u.source(func(c *catalog.HTTPConfig) {
+		c.Timeout = 20 * time.Millisecond
+		c.MaxRetries = 1
+	}).ListProducts(context.Background(), models.ProductQuery{Limit: 12})
+
+	assert.ErrorIs(t, err, apperrors.ErrProductsUnavailable)
+	assert.Equal(t, int32(2), u.requests.Load())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestHTTPSource_RejectsMalformedResponses(t *testing.T) {
+	u := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html>maintenance</html>"))
+	})
+
+	_, err := u.source(func(c *catalog.HTTPConfig) { c.MaxRetries = 0 }).ListCategories(context.Background())
+
+	assert.ErrorIs(t, err, apperrors.ErrCategoriesUnavailable)
+}
+
+func TestHTTPSource_CircuitBreaker(t *testing.T) {
+	healthy := atomic.Bool{}
+	u := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		if healthy.Load() {
+			respondJSON([]string{"laptops", "smartphones"})(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	source := u.source(func(c *catalog.HTTPConfig) {
+		c.MaxRetries = 0
+		c.BreakerThreshold = 2
+		c.BreakerCooldown = 50 * time.Millisecond
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := source.ListCategories(ctx)
+		require.ErrorIs(t, err, apperrors.ErrCategoriesUnavailable)
+	}
+	require.Equal(t, int32(2), u.requests.Load())
+
+	// Open: the upstream is not called.
+	_, err := source.ListCategories(ctx)
+	require.ErrorIs(t, err, apperrors.ErrCategoriesUnavailable)
+	var appErr *apperrors.Error
+	require.ErrorAs(t, err, &appErr)
+	assert.Greater(t, appErr.RetryAfter, time.Duration(0))
+	assert.Equal(t, int32(2), u.requests.Load())
+
+	// After the cooldown a trial request goes through and closes it.
+	time.Sleep(60 * time.Millisecond)
+	healthy.Store(true)
+	categories, err := source.ListCategories(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"laptops", "smartphones"}, categories)
+
+	_, err = source.ListCategories(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), u.requests.Load())
+}
+
+func TestHTTPSource_FailedTrialReopensBreaker(t *testing.T) {
+	u := newUpstream(t, respondStatus(http.StatusServiceUnavailable))
+	source := u.source(func(c *catalog.HTTPConfig) {
+		c.MaxRetries = 0
+		c.BreakerThreshold = 1
+		c.BreakerCooldown = 30 * time.Millisecond
+	})
+	ctx := context.Background()
+
+	_, _ = source.ListCategories(ctx)
+	time.Sleep(40 * time.Millisecond)
+	_, _ = source.ListCategories(ctx)
+	_, _ = source.ListCategories(ctx)
+
+	assert.Equal(t, int32(2), u.requests.Load(), "the failed trial opens the breaker again")
+}
+
+func TestHTTPSource_CancelledRequestsAreNotRetried(t *testing.T) {
+	u := newUpstream(t, respondStatus(http.StatusServiceUnavailable))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := u.source(nil).ListProducts(ctx, models.ProductQuery{Limit: 12})
+
+	assert.ErrorIs(t, err, apperrors.ErrProductsUnavailable)
+	assert.LessOrEqual(t, u.requests.Load(), int32(1))
+}
+
+// respondCatalog serves products like the upstream does: searching titles,
+// listing a category, and paging with limit and skip.
+func respondCatalog(products []models.Product) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		matches := products
+		switch {
+		case r.URL.Path == "/products/search":
+			matches = nil
+			for _, product := range products {
+				if strings.Contains(strings.ToLower(product.Title), strings.ToLower(r.URL.Query().Get("q"))) {
+					matches = append(matches, product)
+				}
+			}
+		case strings.HasPrefix(r.URL.Path, "/products/category/"):
+			matches = nil
+			for _, product := range products {
+				if product.Category == strings.TrimPrefix(r.URL.Path, "/products/category/") {
+					matches = append(matches, product)
+				}
+			}
+		}
+
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		from := min(skip, len(matches))
+		to := min(from+limit, len(matches))
+		respondJSON(models.ProductPage{Products: matches[from:to], Total: int64(len(matches)), Skip: skip, Limit: limit})(w, r)
+	}
+}
+
+func numberedProducts(count int) []models.Product {
+	products := make([]models.Product, count)
+	for i := range products {
+		category := "laptops"
+		if i%2 == 0 {
+			category = "smartphones"
+		}
+		products[i] = models.Product{ID: i + 1, Title: fmt.Sprintf("Phone %d", i), Price: float64(i), Category: category}
+	}
+	return products
+}
+
+func productIDs(products []models.Product) []int {
+	ids := make([]int, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	return ids
+}
+
+func TestHTTPSource_FiltersPriceAcrossPages(t *testing.T) {
+	u := newUpstream(t, respondCatalog(numberedProducts(250)))
+	priceMin, priceMax := 100.0, 199.0
+
+	page, err := u.source(nil).ListProducts(context.Background(), models.ProductQuery{PriceMin: &priceMin, PriceMax: &priceMax, Limit: 12, Skip: 24})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), page.Total)
+	assert.Equal(t, []int{125, 126, 127, 128, 129, 130, 131, 132, 133, 134, 135, 136}, productIDs(page.Products))
+	assert.Equal(t, 24, page.Skip)
+	assert.Equal(t, 12, page.Limit)
+	assert.Equal(t, int32(3), u.requests.Load(), "all 250 products are scanned 100 at a time")
+}
+
+func TestHTTPSource_ReusesScanForOtherPages(t *testing.T) {
+	u := newUpstream(t, respondCatalog(numberedProducts(250)))
+	source := u.source(func(config *catalog.HTTPConfig) { config.ScanCacheTTL = time.Minute })
+	priceMin, priceMax := 100.0, 199.0
+	query := models.ProductQuery{PriceMin: &priceMin, PriceMax: &priceMax, Limit: 12}
+
+	first, err := source.ListProducts(context.Background(), query)
+	require.NoError(t, err)
+	query.Skip = 12
+	second, err := source.ListProducts(context.Background(), query)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{101, 102, 103, 104, 105, 106, 107, 108, 109, 110, 111, 112}, productIDs(first.Products))
+	assert.Equal(t, []int{113, 114, 115, 116, 117, 118, 119, 120, 121, 122, 123, 124}, productIDs(second.Products))
+	assert.Equal(t, int64(100), second.Total)
+	assert.Equal(t, int32(3), u.requests.Load(), "the second page is cut from the first scan")
+
+	// Other filters scan again.
+	priceMin = 150.0
+	_, err = source.ListProducts(context.Background(), models.ProductQuery{PriceMin: &priceMin, Limit: 12})
+	require.NoError(t, err)
+	assert.Equal(t, int32(6), u.requests.Load())
+}
+
+func TestHTTPSource_SharesConcurrentScans(t *testing.T) {
+	release := make(chan struct{})
+	u := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		respondCatalog(numberedProducts(50))(w, r)
+	})
+	source := u.source(func(config *catalog.HTTPConfig) { config.ScanCacheTTL = time.Minute })
+	priceMin := 10.0
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(skip int) {
+			defer wg.Done()
+			page, err := source.ListProducts(context.Background(), models.ProductQuery{PriceMin: &priceMin, Limit: 5, Skip: skip})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(40), page.Total)
+		}(i * 5)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), u.requests.Load())
+}
+
+func TestHTTPSource_PastTheLastMatch(t *testing.T) {
+	u := newUpstream(t, respondCatalog(numberedProducts(50)))
+	priceMin := 40.0
+
+	page, err := u.source(nil).ListProducts(context.Background(), models.ProductQuery{PriceMin: &priceMin, Limit: 12, Skip: 12})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), page.Total)
+	assert.Empty(t, page.Products)
+	assert.NotNil(t, page.Products)
+}
+
+func TestHTTPSource_CombinesSearchCategoryAndPrice(t *testing.T) {
+	u := newUpstream(t, respondCatalog(numberedProducts(30)))
+	priceMax := 16.0
+
+	page, err := u.source(nil).ListProducts(context.Background(), models.ProductQuery{
+		Search:   "phone 1",
+		Category: "smartphones",
+		PriceMax: &priceMax,
+		Limit:    12,
+	})
+
+	require.NoError(t, err)
+	// "Phone 1" and "Phone 10" to "Phone 19" match the search, the even ones
+	// are smartphones, and those up to "Phone 16" are cheap enough.
+	assert.Equal(t, []int{11, 13, 15, 17}, productIDs(page.Products))
+	assert.Equal(t, int64(4), page.Total)
+	assert.Contains(t, u.lastURL.Load(), "/products/search?")
+}
+
+func TestHTTPSource_RejectsScansThatAreTooBroad(t *testing.T) {
+	u := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		respondJSON(models.ProductPage{Products: numberedProducts(100), Total: 1000000})(w, r)
+	})
+	priceMin := 1.0
+
+	_, err := u.source(nil).ListProducts(context.Background(), models.ProductQuery{PriceMin: &priceMin, Limit: 12})
+
+	assert.ErrorIs(t, err, apperrors.ErrProductQueryTooBroad)
+	assert.Equal(t, int32(50), u.requests.Load())
+}
+
+func TestHTTPSource_GetProduct(t *testing.T) {
+	u := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/products/2":
+			_, _ = io.WriteString(w, `{
+				"id": 2, "title": "iPhone 6", "price": 299.99, "rating": 3.5, "stock": 60, "brand": "Apple",
+				"category": "smartphones", "images": ["1.png", "2.png", "3.png"],
+				"reviews": [
+					{"rating": 4, "comment": "Good", "date": "2024-05-01T10:00:00Z", "reviewerName": "Ann"},
+					{"rating": 2, "comment": "Meh", "date": "2024-06-01T10:00:00Z", "reviewerName": "Bob"},
+					{"rating": 4, "comment": "Nice", "date": "2024-04-01T10:00:00Z", "reviewerName": "Cy"}
+				]
+			}`)
+		case "/products/category/smartphones":
+			assert.Equal(t, "limit=5&order=desc&skip=0&sortBy=rating", r.URL.RawQuery)
+			respondJSON(models.ProductPage{Products: numberedProducts(5), Total: 16})(w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	product, err := u.source(nil).GetProduct(context.Background(), 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, "iPhone 6", product.Title)
+	assert.Equal(t, 60, product.Stock)
+	assert.Equal(t, "Apple", product.Brand)
+	assert.Equal(t, []string{"1.png", "2.png", "3.png"}, product.Images)
+	assert.Equal(t, int64(3), product.RatingBreakdown.Count)
+	assert.Equal(t, map[int]int64{1: 0, 2: 1, 3: 0, 4: 2, 5: 0}, product.RatingBreakdown.Stars)
+	require.Len(t, product.Reviews, 3)
+	assert.Equal(t, "Bob", product.Reviews[0].ReviewerName, "latest review first")
+	assert.Equal(t, 2, product.Reviews[0].ProductID)
+	assert.Equal(t, []int{1, 3, 4, 5}, productIDs(product.Related), "the product itself is not related")
+}
+
+func TestHTTPSource_GetProductNotFound(t *testing.T) {
+	u := newUpstream(t, respondStatus(http.StatusNotFound))
+
+	_, err := u.source(nil).GetProduct(context.Background(), 999)
+
+	assert.ErrorIs(t, err, apperrors.ErrProductNotFound)
+	assert.Equal(t, int32(1), u.requests.Load())
+}
+
+func TestHTTPSource_GetProductUnavailable(t *testing.T) {
+	u := newUpstream(t, respondStatus(http.StatusServiceUnavailable))
+
+	_, err := u.source(func(c *catalog.HTTPConfig) { c.MaxRetries = 0 }).GetProduct(context.Background(), 2)
+
+	assert.ErrorIs(t, err, apperrors.ErrProductsUnavailable)
+}
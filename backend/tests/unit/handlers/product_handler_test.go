@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/handlers"
+	"mobile-shop-backend/internal/middleware"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/utils"
+	"mobile-shop-backend/tests/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newProductRouter(source *mocks.MockProductSource) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.GET("/api/products", handlers.NewProductHandler(source).GetProducts)
+	return r
+}
+
+func floatPtr(f float64) *float64 { return &f }
+
+func TestProductHandler_GetProductsPriceRange(t *testing.T) {
+	testCases := []struct {
+		name        string
+		query       string
+		expectedMin *float64
+		expectedMax *float64
+		invalid     bool
+	}{
+		{name: "No bounds", query: ""},
+		{name: "Empty bounds stay open", query: "?priceMin=&priceMax=%20"},
+		{name: "Only a lower bound", query: "?priceMin=10", expectedMin: floatPtr(10)},
+		{name: "Both bounds", query: "?priceMin=10.5&priceMax=200", expectedMin: floatPtr(10.5), expectedMax: floatPtr(200)},
+		{name: "Equal bounds", query: "?priceMin=50&priceMax=50", expectedMin: floatPtr(50), expectedMax: floatPtr(50)},
+		{name: "Non-numeric bound", query: "?priceMin=cheap", invalid: true},
+		{name: "NaN bound", query: "?priceMax=NaN", invalid: true},
+		{name: "Infinite bound", query: "?priceMax=Inf", invalid: true},
+		{name: "Negative bound", query: "?priceMin=-1", invalid: true},
+		{name: "Minimum above maximum", query: "?priceMin=100&priceMax=10", invalid: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			source := new(mocks.MockProductSource)
+			if !tc.invalid {
+				source.On("ListProducts", mock.Anything, mock.MatchedBy(func(query models.ProductQuery) bool {
+					return assert.ObjectsAreEqual(tc.expectedMin, query.PriceMin) && assert.ObjectsAreEqual(tc.expectedMax, query.PriceMax)
+				})).Return(&models.ProductPage{}, nil)
+			}
+
+			w := httptest.NewRecorder()
+			newProductRouter(source).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/products"+tc.query, nil))
+
+			if tc.invalid {
+				var body utils.ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Equal(t, apperrors.ErrInvalidPriceRange.Code, body.Code)
+				assert.NotEmpty(t, body.Details)
+				source.AssertNotCalled(t, "ListProducts", mock.Anything, mock.Anything)
+				return
+			}
+			assert.Equal(t, http.StatusOK, w.Code)
+			source.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package hashing
+
+import (
+	"strings"
+	"testing"
+
+	"mobile-shop-backend/internal/hashing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testParams = hashing.Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idHasher_HashAndVerify(t *testing.T) {
+	hasher := hashing.NewArgon2idHasher(testParams)
+
+	hash, err := hasher.Hash("password123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"))
+
+	ok, err := hasher.Verify(hash, "password123")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = hasher.Verify(hash, "password124")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	other, err := hasher.Hash("password123")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "every hash gets its own salt")
+	assert.False(t, hasher.NeedsRehash(hash))
+}
+
+func TestArgon2idHasher_LongPasswords(t *testing.T) {
+	hasher := hashing.NewArgon2idHasher(testParams)
+	base := strings.Repeat("a", 72)
+
+	// bcrypt ignores everything after 72 bytes; argon2id does not.
+	hash, err := hasher.Hash(base + "first")
+	require.NoError(t, err)
+
+	ok, err := hasher.Verify(hash, base+"second")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestArgon2idHasher_LegacyBcrypt(t *testing.T) {
+	hasher := hashing.NewArgon2idHasher(testParams)
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	ok, err := hasher.Verify(string(legacy), "password123")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = hasher.Verify(string(legacy), "wrongpassword")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.True(t, hasher.NeedsRehash(string(legacy)))
+}
+
+func TestArgon2idHasher_NeedsRehashAfterParameterChange(t *testing.T) {
+	hash, err := hashing.NewArgon2idHasher(testParams).Hash("password123")
+	require.NoError(t, err)
+
+	stronger := testParams
+	stronger.Iterations = 2
+	hasher := hashing.NewArgon2idHasher(stronger)
+
+	assert.True(t, hasher.NeedsRehash(hash))
+	ok, err := hasher.Verify(hash, "password123")
+	require.NoError(t, err)
+	assert.True(t, ok, "hashes made with the old parameters still verify")
+}
+
+func TestArgon2idHasher_UnknownFormat(t *testing.T) {
+	hasher := hashing.NewArgon2idHasher(testParams)
+
+	for _, hash := range []string{"", "plaintext", "$argon2id$v=19$m=64,t=1,p=1$not-base64!$abc", "$argon2i$v=19$m=64,t=1,p=1$c2FsdA$a2V5"} {
+		ok, err := hasher.Verify(hash, "password123")
+		assert.Error(t, err, hash)
+		assert.False(t, ok, hash)
+		assert.True(t, hasher.NeedsRehash(hash), hash)
+	}
+}
+
+func TestArgon2Params_Validate(t *testing.T) {
+	assert.NoError(t, hashing.DefaultArgon2Params().Validate())
+	assert.NoError(t, testParams.Validate())
+
+	invalid := testParams
+	invalid.Parallelism = 16
+	assert.Error(t, invalid.Validate(), "memory below 8 KiB per lane")
+
+	invalid = testParams
+	invalid.Iterations = 0
+	assert.Error(t, invalid.Validate())
+}
@@ -0,0 +1,28 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mobile-shop-backend/internal/mail"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogMailer_Send(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	mailer := mail.NewLogMailer(path)
+
+	require.NoError(t, mailer.Send(mail.Message{To: "john@example.com", Subject: "First", Body: "hello"}))
+	require.NoError(t, mailer.Send(mail.Message{To: "jane@example.com", Subject: "Second", Body: "world"}))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(content), "To: john@example.com")
+	assert.Contains(t, string(content), "Subject: First")
+	assert.Contains(t, string(content), "To: jane@example.com")
+	assert.Contains(t, string(content), "world")
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mobile-shop-backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newAPIKeyRouter simulates AuthMiddleware authenticating the request with an
+// API key holding scopes, or with a session when apiKeyID is empty.
+func newAPIKeyRouter(apiKeyID string, scopes []string, guard gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.GET("/", func(c *gin.Context) {
+		if apiKeyID != "" {
+			c.Set("apiKeyID", apiKeyID)
+			c.Set("scopes", scopes)
+		}
+		c.Next()
+	}, guard, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestRequireSession(t *testing.T) {
+	testCases := []struct {
+		name           string
+		apiKeyID       string
+		expectedStatus int
+	}{
+		{name: "Signed-in user", expectedStatus: http.StatusOK},
+		{name: "API key", apiKeyID: "key-1", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newAPIKeyRouter(tc.apiKeyID, nil, middleware.RequireSession()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	testCases := []struct {
+		name           string
+		apiKeyID       string
+		scopes         []string
+		required       []string
+		expectedStatus int
+	}{
+		{name: "Signed-in user is not restricted", required: []string{"profile:read"}, expectedStatus: http.StatusOK},
+		{name: "Key has the scope", apiKeyID: "key-1", scopes: []string{"profile:read"}, required: []string{"profile:read"}, expectedStatus: http.StatusOK},
+		{name: "Key is missing the scope", apiKeyID: "key-1", scopes: []string{"users:read"}, required: []string{"profile:read"}, expectedStatus: http.StatusForbidden},
+		{name: "Key without scopes", apiKeyID: "key-1", required: []string{"profile:read"}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newAPIKeyRouter(tc.apiKeyID, tc.scopes, middleware.RequireScope(tc.required...)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/middleware"
+	"mobile-shop-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorHandler(t *testing.T) {
+	testCases := []struct {
+		name               string
+		handler            gin.HandlerFunc
+		expectedStatus     int
+		expectedBody       utils.ErrorResponse
+		expectedRetryAfter string
+	}{
+		{
+			name: "Domain error",
+			handler: func(c *gin.Context) {
+				c.Error(apperrors.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   utils.ErrorResponse{Error: "User not found", Code: "USER_NOT_FOUND"},
+		},
+		{
+			name: "Wrapped domain error",
+			handler: func(c *gin.Context) {
+				c.Error(fmt.Errorf("loading profile: %w", apperrors.ErrInvalidUserID.Wrap(errors.New("bad uuid"))))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   utils.ErrorResponse{Error: "Invalid user ID", Code: "INVALID_USER_ID"},
+		},
+		{
+			name: "Validation error",
+			handler: func(c *gin.Context) {
+				c.Error(apperrors.Validation(errors.New("username is required")))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   utils.ErrorResponse{Error: "username is required", Code: "VALIDATION_ERROR"},
+		},
+		{
+			name: "Binding error",
+			handler: func(c *gin.Context) {
+				c.Error(apperrors.InvalidRequest(errors.New("EOF")))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   utils.ErrorResponse{Error: "Validation failed", Code: "VALIDATION_ERROR", Details: "EOF"},
+		},
+		{
+			name: "Retry-After is set",
+			handler: func(c *gin.Context) {
+				c.Error(apperrors.ErrAccountLocked.WithRetryAfter(90*time.Second + time.Millisecond))
+			},
+			expectedStatus:     http.StatusLocked,
+			expectedBody:       utils.ErrorResponse{Error: apperrors.ErrAccountLocked.Message, Code: "ACCOUNT_LOCKED"},
+			expectedRetryAfter: "91",
+		},
+		{
+			name: "Unknown error uses the fallback message",
+			handler: func(c *gin.Context) {
+				c.Error(errors.New("connection refused")).SetMeta("Failed to list users")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   utils.ErrorResponse{Error: "Failed to list users", Code: "INTERNAL_ERROR"},
+		},
+		{
+			name: "Unknown error without a fallback",
+			handler: func(c *gin.Context) {
+				c.Error(errors.New("connection refused"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   utils.ErrorResponse{Error: "Internal server error", Code: "INTERNAL_ERROR"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(middleware.ErrorHandler())
+			r.GET("/", tc.handler)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			var body utils.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tc.expectedBody, body)
+			assert.Equal(t, tc.expectedRetryAfter, w.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestErrorHandler_KeepsWrittenResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.GET("/", func(c *gin.Context) {
+		c.Error(errors.New("logged only"))
+		c.JSON(http.StatusAccepted, gin.H{"queued": true})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.JSONEq(t, `{"queued":true}`, w.Body.String())
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/middleware"
+	"mobile-shop-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRouter simulates AuthMiddleware by placing the given roles and
+// permissions on the context before the guard under test runs. Rejections
+// are rendered by ErrorHandler, as in the server.
+func newRouter(roles, permissions []string, guard gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.GET("/", func(c *gin.Context) {
+		c.Set("roles", roles)
+		c.Set("permissions", permissions)
+		c.Next()
+	}, guard, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestRequireRole(t *testing.T) {
+	testCases := []struct {
+		name           string
+		roles          []string
+		required       []string
+		expectedStatus int
+	}{
+		{name: "Has the role", roles: []string{"admin"}, required: []string{"admin"}, expectedStatus: http.StatusOK},
+		{name: "Has one of the roles", roles: []string{"support"}, required: []string{"admin", "support"}, expectedStatus: http.StatusOK},
+		{name: "Missing the role", roles: []string{"support"}, required: []string{"admin"}, expectedStatus: http.StatusForbidden},
+		{name: "No roles", roles: nil, required: []string{"admin"}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newRouter(tc.roles, nil, middleware.RequireRole(tc.required...)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	testCases := []struct {
+		name           string
+		permissions    []string
+		required       []string
+		expectedStatus int
+	}{
+		{name: "Has the permission", permissions: []string{"users:read"}, required: []string{"users:read"}, expectedStatus: http.StatusOK},
+		{name: "Has all permissions", permissions: []string{"users:read", "users:write"}, required: []string{"users:read", "users:write"}, expectedStatus: http.StatusOK},
+		{name: "Missing one permission", permissions: []string{"users:read"}, required: []string{"users:read", "users:write"}, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newRouter(nil, tc.permissions, middleware.RequirePermission(tc.required...)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRequirePermission_RendersForbidden(t *testing.T) {
+	w := httptest.NewRecorder()
+	newRouter(nil, nil, middleware.RequirePermission("users:read")).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var body utils.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, apperrors.ErrForbidden.Code, body.Code)
+	assert.Equal(t, apperrors.ErrForbidden.Message, body.Error)
+}
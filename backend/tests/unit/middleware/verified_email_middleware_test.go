@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/middleware"
+	"mobile-shop-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	testCases := []struct {
+		name           string
+		verified       bool
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "Verified email", verified: true, expectedStatus: http.StatusOK},
+		{name: "Unverified email", verified: false, expectedStatus: http.StatusForbidden, expectedCode: apperrors.ErrEmailNotVerified.Code},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(middleware.ErrorHandler())
+			r.GET("/", func(c *gin.Context) {
+				c.Set("emailVerified", tc.verified)
+				c.Next()
+			}, middleware.RequireVerifiedEmail(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedCode != "" {
+				var body utils.ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tc.expectedCode, body.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"mobile-shop-backend/internal/oidc"
+	"mobile-shop-backend/tests/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "http://localhost:5173/auth/callback/mock"
+
+func newProvider(issuer *mocks.MockOIDCIssuer) *oidc.Provider {
+	return oidc.NewProvider(oidc.Config{
+		Name:         "mock",
+		Issuer:       issuer.URL(),
+		ClientID:     issuer.ClientID,
+		ClientSecret: issuer.ClientSecret,
+		RedirectURL:  redirectURL,
+	}, nil)
+}
+
+func newIssuer(t *testing.T) *mocks.MockOIDCIssuer {
+	issuer := mocks.NewMockOIDCIssuer("client-id", "client-secret")
+	issuer.Subject = "subject-123"
+	issuer.Email = "john@example.com"
+	issuer.EmailVerified = true
+	issuer.Name = "John Doe"
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+func TestProvider_AuthCodeURL(t *testing.T) {
+	issuer := newIssuer(t)
+
+	authURL, err := newProvider(issuer).AuthCodeURL(context.Background(), "state", "nonce", oidc.CodeChallenge("verifier"))
+	require.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, issuer.URL()+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "client-id", u.Query().Get("client_id"))
+	assert.Equal(t, redirectURL, u.Query().Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", u.Query().Get("scope"))
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	assert.Equal(t, oidc.CodeChallenge("verifier"), u.Query().Get("code_challenge"))
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	testCases := []struct {
+		name          string
+		modify        func(jwt.MapClaims)
+		verifier      string
+		nonce         string
+		expectedError bool
+	}{
+		{
+			name:     "Valid ID token",
+			verifier: "verifier",
+			nonce:    "nonce",
+		},
+		{
+			name:          "Wrong PKCE verifier",
+			verifier:      "other-verifier",
+			nonce:         "nonce",
+			expectedError: true,
+		},
+		{
+			name:          "Nonce mismatch",
+			verifier:      "verifier",
+			nonce:         "other-nonce",
+			expectedError: true,
+		},
+		{
+			name:          "Wrong audience",
+			modify:        func(claims jwt.MapClaims) { claims["aud"] = "another-client" },
+			verifier:      "verifier",
+			nonce:         "nonce",
+			expectedError: true,
+		},
+		{
+			name:          "Wrong issuer",
+			modify:        func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+			verifier:      "verifier",
+			nonce:         "nonce",
+			expectedError: true,
+		},
+		{
+			name:          "Expired ID token",
+			modify:        func(claims jwt.MapClaims) { claims["exp"] = 1 },
+			verifier:      "verifier",
+			nonce:         "nonce",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			issuer := newIssuer(t)
+			issuer.ModifyIDToken = tc.modify
+			provider := newProvider(issuer)
+
+			authURL, err := provider.AuthCodeURL(context.Background(), "state", "nonce", oidc.CodeChallenge("verifier"))
+			require.NoError(t, err)
+			code, state, err := issuer.Authorize(authURL)
+			require.NoError(t, err)
+			assert.Equal(t, "state", state)
+
+			claims, err := provider.Exchange(context.Background(), code, tc.verifier, tc.nonce)
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "subject-123", claims.Subject)
+			assert.Equal(t, "john@example.com", claims.Email)
+			assert.True(t, claims.EmailVerified)
+			assert.Equal(t, "John Doe", claims.Name)
+		})
+	}
+}
+
+func TestProvider_ExchangeGitHubUserInfo(t *testing.T) {
+	testCases := []struct {
+		name            string
+		primaryVerified bool
+	}{
+		{name: "Verified primary email", primaryVerified: true},
+		{name: "Unverified primary email", primaryVerified: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			issuer := newIssuer(t)
+			issuer.GitHub = true
+			issuer.GitHubID = 583231
+			issuer.Login = "octocat"
+			issuer.EmailVerified = tc.primaryVerified
+
+			preset := oidc.Presets["github"]
+			provider := oidc.NewProvider(oidc.Config{
+				Name:         "github",
+				ClientID:     issuer.ClientID,
+				ClientSecret: issuer.ClientSecret,
+				RedirectURL:  redirectURL,
+				Scopes:       preset.Scopes,
+				AuthURL:      issuer.URL() + "/authorize",
+				TokenURL:     issuer.URL() + "/token",
+				UserInfoURL:  issuer.URL() + "/user",
+				EmailsURL:    issuer.URL() + "/user/emails",
+			}, nil)
+
+			authURL, err := provider.AuthCodeURL(context.Background(), "state", "nonce", oidc.CodeChallenge("verifier"))
+			require.NoError(t, err)
+			code, _, err := issuer.Authorize(authURL)
+			require.NoError(t, err)
+
+			claims, err := provider.Exchange(context.Background(), code, "verifier", "nonce")
+
+			require.NoError(t, err)
+			assert.Equal(t, "583231", claims.Subject)
+			assert.Equal(t, "octocat", claims.PreferredUsername)
+			assert.Equal(t, "John Doe", claims.Name)
+			assert.Equal(t, "john@example.com", claims.Email)
+			assert.Equal(t, tc.primaryVerified, claims.EmailVerified)
+		})
+	}
+}
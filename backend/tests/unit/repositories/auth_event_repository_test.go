@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlRecorder is a GORM logger that keeps the statements it is shown.
+type sqlRecorder struct {
+	logger.Interface
+	statements []string
+}
+
+func (r *sqlRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+// newDryRunDB returns a Postgres connection that only renders SQL.
+func newDryRunDB(t *testing.T) (*gorm.DB, *sqlRecorder) {
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               recorder,
+	})
+	require.NoError(t, err)
+	return db, recorder
+}
+
+func TestAuthEventRepository_ListBuildsSQL(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	userID := uuid.MustParse("6f1c1f4e-8a43-4f0e-9a55-3d8f4f9a2b10")
+
+	_, _, err := repositories.NewAuthEventRepository(db).List(models.AuthEventFilter{
+		UserID:       &userID,
+		ExcludeTypes: []string{models.AuthEventProfileView},
+	}, 20, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`SELECT count(*) FROM "auth_events" WHERE type NOT IN ('profile_view') AND user_id = '6f1c1f4e-8a43-4f0e-9a55-3d8f4f9a2b10'`,
+		`SELECT * FROM "auth_events" WHERE type NOT IN ('profile_view') AND user_id = '6f1c1f4e-8a43-4f0e-9a55-3d8f4f9a2b10' ORDER BY created_at DESC LIMIT 10 OFFSET 20`,
+	}, recorder.statements)
+}
+
+func TestAuthEventRepository_ListForUserBuildsSQL(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	userID := uuid.MustParse("6f1c1f4e-8a43-4f0e-9a55-3d8f4f9a2b10")
+
+	_, err := repositories.NewAuthEventRepository(db).ListForUser(userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`SELECT * FROM "auth_events" WHERE user_id = '6f1c1f4e-8a43-4f0e-9a55-3d8f4f9a2b10' ORDER BY created_at DESC`,
+	}, recorder.statements)
+}
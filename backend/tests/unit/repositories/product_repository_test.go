@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"testing"
+
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductRepository_ListBuildsSQL(t *testing.T) {
+	minPrice, maxPrice := 100.0, 500.0
+
+	testCases := []struct {
+		name          string
+		query         models.ProductQuery
+		expectedCount string
+		expectedPage  string
+	}{
+		{
+			name:          "No filters",
+			query:         models.ProductQuery{SortBy: models.ProductSortTitle, Limit: 12},
+			expectedCount: `SELECT count(*) FROM "products"`,
+			expectedPage:  `SELECT * FROM "products" ORDER BY title,id LIMIT 12`,
+		},
+		{
+			name: "Every filter",
+			query: models.ProductQuery{
+				Search:   "50%_off",
+				Category: "smartphones",
+				SortBy:   models.ProductSortPrice,
+				SortDesc: true,
+				PriceMin: &minPrice,
+				PriceMax: &maxPrice,
+				Limit:    12,
+				Skip:     24,
+			},
+			expectedCount: `SELECT count(*) FROM "products" WHERE (title ILIKE '%50\%\_off%' OR description ILIKE '%50\%\_off%' OR brand ILIKE '%50\%\_off%') AND category = 'smartphones' AND price >= 100 AND price <= 500`,
+			expectedPage:  `SELECT * FROM "products" WHERE (title ILIKE '%50\%\_off%' OR description ILIKE '%50\%\_off%' OR brand ILIKE '%50\%\_off%') AND category = 'smartphones' AND price >= 100 AND price <= 500 ORDER BY price DESC,id LIMIT 12 OFFSET 24`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, recorder := newDryRunDB(t)
+
+			_, _, err := repositories.NewProductRepository(db).List(tc.query)
+
+			require.NoError(t, err)
+			assert.Equal(t, []string{tc.expectedCount, tc.expectedPage}, recorder.statements)
+		})
+	}
+}
+
+func TestProductRepository_Categories(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+
+	_, err := repositories.NewProductRepository(db).Categories()
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{`SELECT DISTINCT "category" FROM "products" ORDER BY category`}, recorder.statements)
+}
+
+func TestProductRepository_DetailQueries(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	repo := repositories.NewProductRepository(db)
+
+	_, err := repo.Related(&models.Product{ID: 2, Category: "smartphones"}, 4)
+	require.NoError(t, err)
+	_, err = repo.Reviews(2, 10)
+	require.NoError(t, err)
+	_, err = repo.RatingCounts(2)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`SELECT * FROM "products" WHERE category = 'smartphones' AND id <> 2 ORDER BY rating DESC,id LIMIT 4`,
+		`SELECT * FROM "product_reviews" WHERE product_id = 2 ORDER BY created_at DESC,id DESC LIMIT 10`,
+		`SELECT rating, COUNT(*) AS count FROM "product_reviews" WHERE product_id = 2 GROUP BY "rating"`,
+	}, recorder.statements)
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"testing"
+
+	"mobile-shop-backend/internal/repositories"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleRepository_GetRoleNamesForUsersBuildsSQL(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	first := uuid.MustParse("6f1c1f4e-8a43-4f0e-9a55-3d8f4f9a2b10")
+	second := uuid.MustParse("0b7f5a52-3c1e-4d8a-9f3b-2e6c1d0a9b84")
+
+	roleNames, err := repositories.NewRoleRepository(db).GetRoleNamesForUsers([]uuid.UUID{first, second})
+
+	require.NoError(t, err)
+	assert.Empty(t, roleNames)
+	assert.Equal(t, []string{
+		`SELECT user_roles.user_id, roles.name FROM "user_roles" JOIN roles ON roles.id = user_roles.role_id WHERE user_roles.user_id IN ('6f1c1f4e-8a43-4f0e-9a55-3d8f4f9a2b10','0b7f5a52-3c1e-4d8a-9f3b-2e6c1d0a9b84') ORDER BY roles.name`,
+	}, recorder.statements)
+}
+
+func TestRoleRepository_GetRoleNamesForNoUsers(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+
+	roleNames, err := repositories.NewRoleRepository(db).GetRoleNamesForUsers(nil)
+
+	require.NoError(t, err)
+	assert.Empty(t, roleNames)
+	assert.Empty(t, recorder.statements)
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type accountMocks struct {
+	userRepo         *mocks.MockUserRepository
+	sessionRepo      *mocks.MockSessionRepository
+	refreshTokenRepo *mocks.MockRefreshTokenRepository
+	identityRepo     *mocks.MockIdentityRepository
+	recoveryCodeRepo *mocks.MockRecoveryCodeRepository
+	apiKeyRepo       *mocks.MockAPIKeyRepository
+	authEventRepo    *mocks.MockAuthEventRepository
+}
+
+func newAccountMocks() *accountMocks {
+	return &accountMocks{
+		userRepo:         new(mocks.MockUserRepository),
+		sessionRepo:      new(mocks.MockSessionRepository),
+		refreshTokenRepo: new(mocks.MockRefreshTokenRepository),
+		identityRepo:     new(mocks.MockIdentityRepository),
+		recoveryCodeRepo: new(mocks.MockRecoveryCodeRepository),
+		apiKeyRepo:       new(mocks.MockAPIKeyRepository),
+		authEventRepo:    new(mocks.MockAuthEventRepository),
+	}
+}
+
+func (m *accountMocks) service() *services.AccountService {
+	return services.NewAccountService(m.userRepo, m.sessionRepo, m.refreshTokenRepo, m.identityRepo, m.recoveryCodeRepo, m.apiKeyRepo, m.authEventRepo, newTestRoleService(), testPasswordHasher, 30*24*time.Hour)
+}
+
+func (m *accountMocks) assertExpectations(t *testing.T) {
+	m.userRepo.AssertExpectations(t)
+	m.sessionRepo.AssertExpectations(t)
+	m.refreshTokenRepo.AssertExpectations(t)
+	m.identityRepo.AssertExpectations(t)
+	m.recoveryCodeRepo.AssertExpectations(t)
+	m.apiKeyRepo.AssertExpectations(t)
+	m.authEventRepo.AssertExpectations(t)
+}
+
+func TestAccountService_DeleteAccount(t *testing.T) {
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	testUser := &models.User{ID: uuid.New(), Username: "johndoe", Password: hashedPassword}
+
+	testCases := []struct {
+		name          string
+		password      string
+		mockSetup     func(*accountMocks)
+		expectedError bool
+		expectedErr   error
+	}{
+		{
+			name:     "Signs out everywhere and soft-deletes the account",
+			password: "password123",
+			mockSetup: func(m *accountMocks) {
+				m.userRepo.On("GetByID", testUser.ID).Return(testUser, nil)
+				m.userRepo.On("IncrementTokenVersion", testUser.ID).Return(nil)
+				m.refreshTokenRepo.On("RevokeAllForUser", testUser.ID).Return(nil)
+				m.sessionRepo.On("RevokeAllForUser", testUser.ID).Return(nil)
+				m.userRepo.On("SoftDelete", testUser.ID).Return(nil)
+			},
+		},
+		{
+			name:     "Wrong password",
+			password: "wrongpassword",
+			mockSetup: func(m *accountMocks) {
+				m.userRepo.On("GetByID", testUser.ID).Return(testUser, nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrInvalidPassword,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newAccountMocks()
+			tc.mockSetup(m)
+
+			purgeAt, err := m.service().DeleteAccount(testUser.ID.String(), tc.password)
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), purgeAt, time.Minute)
+			}
+
+			m.assertExpectations(t)
+		})
+	}
+}
+
+func TestAccountService_PurgeDeletedAccounts(t *testing.T) {
+	now := time.Now()
+	m := newAccountMocks()
+	m.userRepo.On("PurgeDeleted", now.Add(-30*24*time.Hour)).Return(int64(2), nil)
+
+	purged, err := m.service().PurgeDeletedAccounts(now)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	m.assertExpectations(t)
+}
+
+func TestAccountService_RunPurgerStopsWhenCancelled(t *testing.T) {
+	m := newAccountMocks()
+	purged := make(chan struct{}, 1)
+	m.userRepo.On("PurgeDeleted", mock.Anything).Return(int64(0), nil).Run(func(mock.Arguments) {
+		select {
+		case purged <- struct{}{}:
+		default:
+		}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		m.service().RunPurger(ctx, time.Millisecond)
+		close(done)
+	}()
+	<-purged
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("purger did not stop after the context was cancelled")
+	}
+}
+
+func TestAccountService_ExportData(t *testing.T) {
+	verifiedAt := time.Now().Add(-time.Hour)
+	testUser := &models.User{
+		ID:         uuid.New(),
+		Name:       "John Doe",
+		Username:   "johndoe",
+		Email:      "john@example.com",
+		Password:   "bcrypt-hash",
+		TOTPSecret: "TOTPSECRET",
+		VerifiedAt: &verifiedAt,
+	}
+
+	m := newAccountMocks()
+	m.userRepo.On("GetByID", testUser.ID).Return(testUser, nil)
+	m.identityRepo.On("ListForUser", testUser.ID).Return([]models.Identity{{Provider: "google", Subject: "subject-1", Email: "john@gmail.com"}}, nil)
+	m.sessionRepo.On("ListForUser", testUser.ID).Return([]models.Session{{ID: uuid.New(), UserAgent: "Firefox", IPAddress: "192.0.2.1"}}, nil)
+	m.recoveryCodeRepo.On("CountUnused", testUser.ID).Return(int64(0), nil)
+	m.apiKeyRepo.On("ListForUser", testUser.ID).Return([]models.APIKey{{ID: uuid.New(), Name: "warehouse", Prefix: "msk_abcdefgh", KeyHash: "key-hash", Scopes: []string{models.ScopeProfileRead}}}, nil)
+
+	m.authEventRepo.On("ListForUser", testUser.ID).Return([]models.AuthEvent{{Type: models.AuthEventLogin, Method: "password", IPAddress: "192.0.2.7", Outcome: models.AuthOutcomeSuccess}}, nil)
+
+	archive, err := m.service().ExportData(testUser.ID.String())
+	require.NoError(t, err)
+
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	require.Len(t, reader.File, 1)
+
+	file, err := reader.File[0].Open()
+	require.NoError(t, err)
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	require.NoError(t, err)
+
+	var export services.UserDataExport
+	require.NoError(t, json.Unmarshal(data, &export))
+	assert.Equal(t, "johndoe", export.Profile.Username)
+	assert.Equal(t, "john@example.com", export.Profile.Email)
+	require.Len(t, export.Identities, 1)
+	assert.Equal(t, "google", export.Identities[0].Provider)
+	assert.Equal(t, "subject-1", export.Identities[0].Subject)
+	require.Len(t, export.Sessions, 1)
+	assert.Equal(t, "Firefox", export.Sessions[0].UserAgent)
+	require.Len(t, export.APIKeys, 1)
+	assert.Equal(t, "warehouse", export.APIKeys[0].Name)
+	assert.Equal(t, []string{models.ScopeProfileRead}, export.APIKeys[0].Scopes)
+	require.Len(t, export.SecurityEvents, 1)
+	assert.Equal(t, models.AuthEventLogin, export.SecurityEvents[0].Type)
+	assert.Equal(t, "192.0.2.7", export.SecurityEvents[0].IPAddress)
+
+	assert.NotContains(t, string(data), "bcrypt-hash", "password hashes are not exported")
+	assert.NotContains(t, string(data), "TOTPSECRET", "MFA secrets are not exported")
+	assert.NotContains(t, string(data), "key-hash", "API key hashes are not exported")
+	m.assertExpectations(t)
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminService_ListUsersLoadsRolesInOneQuery(t *testing.T) {
+	admin := models.User{ID: uuid.New(), Username: "admin"}
+	customer := models.User{ID: uuid.New(), Username: "customer"}
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("List", 0, 20).Return([]models.User{admin, customer}, int64(2), nil)
+	mockRoleRepo := new(mocks.MockRoleRepository)
+	mockRoleRepo.On("GetRoleNamesForUsers", []uuid.UUID{admin.ID, customer.ID}).Return(map[uuid.UUID][]string{
+		admin.ID: {"support", "admin"},
+	}, nil).Once()
+
+	service := services.NewAdminService(mockRepo, services.NewRoleService(mockRoleRepo, mockRepo), newTestLoginThrottler())
+	users, total, err := service.ListUsers(0, 20)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, users, 2)
+	assert.Equal(t, []string{"admin", "support"}, users[0].Roles)
+	assert.Equal(t, []string{}, users[1].Roles)
+	mockRoleRepo.AssertExpectations(t)
+	mockRoleRepo.AssertNotCalled(t, "GetUserRoles", mock.Anything)
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/utils"
+	"mobile-shop-backend/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type apiKeyMocks struct {
+	apiKeyRepo *mocks.MockAPIKeyRepository
+	userRepo   *mocks.MockUserRepository
+	roleRepo   *mocks.MockRoleRepository
+}
+
+// newAPIKeyMocks sets up a user holding the given permissions.
+func newAPIKeyMocks(userID uuid.UUID, permissions ...string) *apiKeyMocks {
+	m := &apiKeyMocks{
+		apiKeyRepo: new(mocks.MockAPIKeyRepository),
+		userRepo:   new(mocks.MockUserRepository),
+		roleRepo:   new(mocks.MockRoleRepository),
+	}
+
+	role := models.Role{Name: "custom"}
+	for _, permission := range permissions {
+		role.Permissions = append(role.Permissions, models.Permission{Name: permission})
+	}
+	m.roleRepo.On("GetUserRoles", userID).Return([]models.Role{role}, nil).Maybe()
+	return m
+}
+
+func (m *apiKeyMocks) service() *services.APIKeyService {
+	return services.NewAPIKeyService(m.apiKeyRepo, m.userRepo, services.NewRoleService(m.roleRepo, m.userRepo))
+}
+
+func (m *apiKeyMocks) assertExpectations(t *testing.T) {
+	m.apiKeyRepo.AssertExpectations(t)
+	m.userRepo.AssertExpectations(t)
+}
+
+func TestAPIKeyService_CreateKey(t *testing.T) {
+	userID := uuid.New()
+	past := time.Now().Add(-time.Hour)
+
+	testCases := []struct {
+		name        string
+		permissions []string
+		request     models.CreateAPIKeyRequest
+		expectedErr error
+	}{
+		{
+			name:    "Profile scope needs no permission",
+			request: models.CreateAPIKeyRequest{Name: "warehouse", Scopes: []string{models.ScopeProfileRead}},
+		},
+		{
+			name:        "Permission scope held by the user",
+			permissions: []string{models.PermissionUsersRead},
+			request:     models.CreateAPIKeyRequest{Name: "reports", Scopes: []string{models.PermissionUsersRead}},
+		},
+		{
+			name:        "Unknown scope",
+			request:     models.CreateAPIKeyRequest{Name: "warehouse", Scopes: []string{"orders:write"}},
+			expectedErr: apperrors.ErrUnknownScope,
+		},
+		{
+			name:        "Permission the user does not hold",
+			request:     models.CreateAPIKeyRequest{Name: "warehouse", Scopes: []string{models.PermissionUsersWrite}},
+			expectedErr: apperrors.ErrScopeNotGranted,
+		},
+		{
+			name:        "Expiry in the past",
+			request:     models.CreateAPIKeyRequest{Name: "warehouse", Scopes: []string{models.ScopeProfileRead}, ExpiresAt: &past},
+			expectedErr: apperrors.ErrInvalidAPIKeyExpiry,
+		},
+		{
+			name:        "Blank name",
+			request:     models.CreateAPIKeyRequest{Name: "   ", Scopes: []string{models.ScopeProfileRead}},
+			expectedErr: apperrors.ErrValidation,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newAPIKeyMocks(userID, tc.permissions...)
+			m.userRepo.On("GetByID", userID).Return(&models.User{ID: userID}, nil)
+			if tc.expectedErr == nil {
+				m.apiKeyRepo.On("Create", mock.AnythingOfType("*models.APIKey")).Return(nil)
+			}
+
+			created, err := m.service().CreateKey(userID.String(), userID.String(), &tc.request)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, created)
+			} else {
+				require.NoError(t, err)
+				assert.True(t, strings.HasPrefix(created.Key, models.APIKeyPrefix))
+				assert.Equal(t, created.Key[:12], created.Prefix)
+				assert.Equal(t, utils.HashToken(created.Key), created.KeyHash, "only the hash is stored")
+				assert.Equal(t, tc.request.Scopes, created.Scopes)
+				assert.Equal(t, userID, created.UserID)
+				assert.Equal(t, userID, created.CreatedByID)
+			}
+			m.assertExpectations(t)
+		})
+	}
+}
+
+func TestAPIKeyService_CreateKeyRemovesDuplicateScopes(t *testing.T) {
+	userID := uuid.New()
+	adminID := uuid.New()
+	m := newAPIKeyMocks(userID)
+	m.userRepo.On("GetByID", userID).Return(&models.User{ID: userID}, nil)
+	m.apiKeyRepo.On("Create", mock.AnythingOfType("*models.APIKey")).Return(nil)
+
+	created, err := m.service().CreateKey(userID.String(), adminID.String(), &models.CreateAPIKeyRequest{
+		Name:   " warehouse ",
+		Scopes: []string{models.ScopeProfileRead, models.ScopeProfileRead},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "warehouse", created.Name)
+	assert.Equal(t, []string{models.ScopeProfileRead}, created.Scopes)
+	assert.Equal(t, adminID, created.CreatedByID)
+	m.assertExpectations(t)
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	userID := uuid.New()
+	rawKey := models.APIKeyPrefix + "test-key"
+	revokedAt := time.Now().Add(-time.Minute)
+	expiredAt := time.Now().Add(-time.Minute)
+	recentlyUsed := time.Now().Add(-time.Second)
+
+	newKey := func() *models.APIKey {
+		return &models.APIKey{
+			ID:      uuid.New(),
+			UserID:  userID,
+			KeyHash: utils.HashToken(rawKey),
+			Scopes:  []string{models.ScopeProfileRead, models.PermissionUsersRead},
+		}
+	}
+
+	testCases := []struct {
+		name                string
+		key                 string
+		permissions         []string
+		mockSetup           func(*apiKeyMocks)
+		expectedErr         error
+		expectedScopes      []string
+		expectedPermissions []string
+	}{
+		{
+			name:        "Valid key records its use",
+			key:         rawKey,
+			permissions: []string{models.PermissionUsersRead},
+			mockSetup: func(m *apiKeyMocks) {
+				key := newKey()
+				m.apiKeyRepo.On("GetByHash", utils.HashToken(rawKey)).Return(key, nil)
+				m.userRepo.On("GetByID", userID).Return(&models.User{ID: userID}, nil)
+				m.apiKeyRepo.On("Touch", key.ID, "192.0.2.1", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(nil)
+			},
+			expectedScopes:      []string{models.ScopeProfileRead, models.PermissionUsersRead},
+			expectedPermissions: []string{models.PermissionUsersRead},
+		},
+		{
+			name: "Permissions the user lost are dropped",
+			key:  rawKey,
+			mockSetup: func(m *apiKeyMocks) {
+				key := newKey()
+				key.LastUsedAt = &recentlyUsed
+				m.apiKeyRepo.On("GetByHash", utils.HashToken(rawKey)).Return(key, nil)
+				m.userRepo.On("GetByID", userID).Return(&models.User{ID: userID}, nil)
+			},
+			expectedScopes:      []string{models.ScopeProfileRead},
+			expectedPermissions: []string{},
+		},
+		{
+			name:        "Not an API key",
+			key:         "eyJhbGciOi",
+			mockSetup:   func(m *apiKeyMocks) {},
+			expectedErr: apperrors.ErrInvalidAPIKey,
+		},
+		{
+			name: "Unknown key",
+			key:  rawKey,
+			mockSetup: func(m *apiKeyMocks) {
+				m.apiKeyRepo.On("GetByHash", utils.HashToken(rawKey)).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedErr: apperrors.ErrInvalidAPIKey,
+		},
+		{
+			name: "Revoked key",
+			key:  rawKey,
+			mockSetup: func(m *apiKeyMocks) {
+				key := newKey()
+				key.RevokedAt = &revokedAt
+				m.apiKeyRepo.On("GetByHash", utils.HashToken(rawKey)).Return(key, nil)
+			},
+			expectedErr: apperrors.ErrInvalidAPIKey,
+		},
+		{
+			name: "Expired key",
+			key:  rawKey,
+			mockSetup: func(m *apiKeyMocks) {
+				key := newKey()
+				key.ExpiresAt = &expiredAt
+				m.apiKeyRepo.On("GetByHash", utils.HashToken(rawKey)).Return(key, nil)
+			},
+			expectedErr: apperrors.ErrAPIKeyExpired,
+		},
+		{
+			name: "Owner deleted their account",
+			key:  rawKey,
+			mockSetup: func(m *apiKeyMocks) {
+				m.apiKeyRepo.On("GetByHash", utils.HashToken(rawKey)).Return(newKey(), nil)
+				m.userRepo.On("GetByID", userID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedErr: apperrors.ErrInvalidAPIKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newAPIKeyMocks(userID, tc.permissions...)
+			tc.mockSetup(m)
+
+			principal, err := m.service().Authenticate(tc.key, "192.0.2.1")
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, principal)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, userID, principal.User.ID)
+				assert.Equal(t, tc.expectedScopes, principal.Scopes)
+				assert.Equal(t, tc.expectedPermissions, principal.Permissions)
+			}
+			m.assertExpectations(t)
+		})
+	}
+}
+
+func TestAPIKeyService_RevokeKey(t *testing.T) {
+	userID := uuid.New()
+	key := &models.APIKey{ID: uuid.New(), UserID: userID}
+
+	t.Run("Own key", func(t *testing.T) {
+		m := newAPIKeyMocks(userID)
+		m.apiKeyRepo.On("GetByID", key.ID).Return(key, nil)
+		m.apiKeyRepo.On("Revoke", key.ID).Return(true, nil)
+
+		assert.NoError(t, m.service().RevokeKey(userID.String(), key.ID.String()))
+		m.assertExpectations(t)
+	})
+
+	t.Run("Another user's key", func(t *testing.T) {
+		m := newAPIKeyMocks(userID)
+		m.apiKeyRepo.On("GetByID", key.ID).Return(key, nil)
+
+		err := m.service().RevokeKey(uuid.New().String(), key.ID.String())
+		assert.ErrorIs(t, err, apperrors.ErrAPIKeyNotFound)
+		m.apiKeyRepo.AssertNotCalled(t, "Revoke", mock.Anything)
+	})
+
+	t.Run("Admin revokes any key", func(t *testing.T) {
+		m := newAPIKeyMocks(userID)
+		m.apiKeyRepo.On("GetByID", key.ID).Return(key, nil)
+		m.apiKeyRepo.On("Revoke", key.ID).Return(true, nil)
+
+		assert.NoError(t, m.service().RevokeAnyKey(key.ID.String()))
+		m.assertExpectations(t)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		m := newAPIKeyMocks(userID)
+		assert.ErrorIs(t, m.service().RevokeKey(userID.String(), "not-a-uuid"), apperrors.ErrInvalidAPIKeyID)
+	})
+}
+
+func TestAPIKeyService_ListKeysHidesRevoked(t *testing.T) {
+	userID := uuid.New()
+	revokedAt := time.Now()
+	m := newAPIKeyMocks(userID)
+	m.apiKeyRepo.On("ListForUser", userID).Return([]models.APIKey{
+		{ID: uuid.New(), Name: "active"},
+		{ID: uuid.New(), Name: "revoked", RevokedAt: &revokedAt},
+	}, nil)
+
+	keys, err := m.service().ListKeys(userID.String())
+
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "active", keys[0].Name)
+	m.assertExpectations(t)
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingAuthEventRepo returns an audit log that keeps every event.
+func recordingAuthEventRepo(events *[]*models.AuthEvent) *mocks.MockAuthEventRepository {
+	mockAuthEventRepo := new(mocks.MockAuthEventRepository)
+	mockAuthEventRepo.On("Create", mock.AnythingOfType("*models.AuthEvent")).Return(nil).Run(func(args mock.Arguments) {
+		*events = append(*events, args.Get(0).(*models.AuthEvent))
+	})
+	return mockAuthEventRepo
+}
+
+func TestAuthService_LoginRecordsEvents(t *testing.T) {
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	mfaEnabledAt := time.Now()
+	testUser := &models.User{ID: uuid.New(), Username: "johndoe", Email: "john@example.com", Password: hashedPassword}
+	mfaUser := &models.User{ID: uuid.New(), Username: "janedoe", Email: "jane@example.com", Password: hashedPassword, MFAEnabledAt: &mfaEnabledAt}
+	client := services.ClientInfo{IP: "192.0.2.1", UserAgent: "test-agent"}
+
+	testCases := []struct {
+		name            string
+		input           models.LoginRequest
+		expectedUserID  *uuid.UUID
+		expectedOutcome string
+		expectedReason  string
+	}{
+		{
+			name:            "Successful login",
+			input:           models.LoginRequest{Identifier: "JohnDoe", Password: "password123"},
+			expectedUserID:  &testUser.ID,
+			expectedOutcome: models.AuthOutcomeSuccess,
+		},
+		{
+			name:            "Wrong password",
+			input:           models.LoginRequest{Identifier: "johndoe", Password: "wrong"},
+			expectedUserID:  &testUser.ID,
+			expectedOutcome: models.AuthOutcomeFailure,
+			expectedReason:  apperrors.ErrInvalidCredentials.Code,
+		},
+		{
+			name:            "Unknown account",
+			input:           models.LoginRequest{Identifier: "nobody", Password: "password123"},
+			expectedOutcome: models.AuthOutcomeFailure,
+			expectedReason:  apperrors.ErrInvalidCredentials.Code,
+		},
+		{
+			name:            "MFA required",
+			input:           models.LoginRequest{Identifier: "janedoe", Password: "password123"},
+			expectedUserID:  &mfaUser.ID,
+			expectedOutcome: models.AuthOutcomeMFARequired,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockUserRepository)
+			mockRepo.On("GetByUsername", "johndoe").Return(testUser, nil).Maybe()
+			mockRepo.On("GetByUsername", "janedoe").Return(mfaUser, nil).Maybe()
+			mockRepo.On("GetByUsername", "nobody").Return(nil, assert.AnError).Maybe()
+			mockTokenRepo := new(mocks.MockRefreshTokenRepository)
+			mockTokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil).Maybe()
+			var events []*models.AuthEvent
+
+			authService := services.NewAuthService(mockRepo, mockTokenRepo, new(mocks.MockRevokedTokenRepository), newTestSessionRepo(), recordingAuthEventRepo(&events), newTestRoleService(), newTestLoginThrottler(), nil, testPasswordHasher, testPasswordPolicy, newTestKeySet(t), services.DefaultTokenConfig())
+			_, _ = authService.Login(&tc.input, client)
+
+			require.Len(t, events, 1)
+			event := events[0]
+			assert.Equal(t, models.AuthEventLogin, event.Type)
+			assert.Equal(t, services.LoginMethodPassword, event.Method)
+			assert.Equal(t, strings.ToLower(tc.input.Identifier), event.Identifier)
+			assert.Equal(t, tc.expectedUserID, event.UserID)
+			assert.Equal(t, tc.expectedOutcome, event.Outcome)
+			assert.Equal(t, tc.expectedReason, event.Reason)
+			assert.Equal(t, "192.0.2.1", event.IPAddress)
+			assert.Equal(t, "test-agent", event.UserAgent)
+		})
+	}
+}
+
+func TestAuthService_RecordsProfileAndPasswordEvents(t *testing.T) {
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	testUser := &models.User{ID: uuid.New(), Username: "johndoe", Email: "john@example.com", Password: hashedPassword}
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("GetByID", testUser.ID).Return(testUser, nil)
+	var events []*models.AuthEvent
+
+	authService := services.NewAuthService(mockRepo, new(mocks.MockRefreshTokenRepository), new(mocks.MockRevokedTokenRepository), newTestSessionRepo(), recordingAuthEventRepo(&events), newTestRoleService(), newTestLoginThrottler(), nil, testPasswordHasher, testPasswordPolicy, newTestKeySet(t), services.DefaultTokenConfig())
+
+	_, err := authService.GetProfile(testUser.ID.String(), services.ClientInfo{})
+	require.NoError(t, err)
+	err = authService.ChangePassword(testUser.ID.String(), uuid.New().String(), "wrong", "newpassword456", services.ClientInfo{})
+	require.ErrorIs(t, err, apperrors.ErrInvalidPassword)
+
+	require.Len(t, events, 2)
+	assert.Equal(t, models.AuthEventProfileView, events[0].Type)
+	assert.Equal(t, models.AuthOutcomeSuccess, events[0].Outcome)
+	assert.Equal(t, models.AuthEventPasswordChange, events[1].Type)
+	assert.Equal(t, models.AuthOutcomeFailure, events[1].Outcome)
+	assert.Equal(t, apperrors.ErrInvalidPassword.Code, events[1].Reason)
+	assert.Equal(t, &testUser.ID, events[1].UserID)
+}
+
+func TestAuthEventService_ListEvents(t *testing.T) {
+	userID := uuid.New()
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		query          models.AuthEventQuery
+		expectedFilter models.AuthEventFilter
+		expectedErr    error
+	}{
+		{
+			name:           "No filters",
+			expectedFilter: models.AuthEventFilter{},
+		},
+		{
+			name:  "All filters",
+			query: models.AuthEventQuery{Type: "login", UserID: userID.String(), Outcome: "failure", IP: "192.0.2.1", From: "2024-05-01T00:00:00Z"},
+			expectedFilter: models.AuthEventFilter{
+				Type:      models.AuthEventLogin,
+				UserID:    &userID,
+				Outcome:   models.AuthOutcomeFailure,
+				IPAddress: "192.0.2.1",
+				From:      &from,
+			},
+		},
+		{
+			name:        "Unknown type",
+			query:       models.AuthEventQuery{Type: "checkout"},
+			expectedErr: apperrors.ErrValidation,
+		},
+		{
+			name:        "Unknown outcome",
+			query:       models.AuthEventQuery{Outcome: "maybe"},
+			expectedErr: apperrors.ErrValidation,
+		},
+		{
+			name:        "Invalid user ID",
+			query:       models.AuthEventQuery{UserID: "not-a-uuid"},
+			expectedErr: apperrors.ErrInvalidUserID,
+		},
+		{
+			name:        "Malformed timestamp",
+			query:       models.AuthEventQuery{From: "yesterday"},
+			expectedErr: apperrors.ErrValidation,
+		},
+		{
+			name:        "Empty range",
+			query:       models.AuthEventQuery{From: "2024-05-02T00:00:00Z", To: "2024-05-01T00:00:00Z"},
+			expectedErr: apperrors.ErrValidation,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAuthEventRepo := new(mocks.MockAuthEventRepository)
+			if tc.expectedErr == nil {
+				mockAuthEventRepo.On("List", tc.expectedFilter, 10, 50).Return([]models.AuthEvent{{Type: models.AuthEventLogin}}, int64(11), nil)
+			}
+
+			events, total, err := services.NewAuthEventService(mockAuthEventRepo).ListEvents(&tc.query, 10, 50)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				mockAuthEventRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, events, 1)
+				assert.Equal(t, int64(11), total)
+			}
+			mockAuthEventRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthEventService_RecentActivityHidesProfileViews(t *testing.T) {
+	userID := uuid.New()
+	mockAuthEventRepo := new(mocks.MockAuthEventRepository)
+	mockAuthEventRepo.On("List", models.AuthEventFilter{UserID: &userID, ExcludeTypes: []string{models.AuthEventProfileView}}, 0, 20).Return([]models.AuthEvent{}, int64(0), nil)
+
+	events, err := services.NewAuthEventService(mockAuthEventRepo).RecentActivity(userID.String(), 20)
+
+	require.NoError(t, err)
+	assert.Empty(t, events)
+	mockAuthEventRepo.AssertExpectations(t)
+}
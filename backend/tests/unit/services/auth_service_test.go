@@ -2,10 +2,18 @@ package services
 
 import (
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/hashing"
 	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
 	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/signing"
+	"mobile-shop-backend/internal/utils"
+	"mobile-shop-backend/internal/validators"
 	"mobile-shop-backend/tests/mocks"
 
 	"github.com/google/uuid"
@@ -15,13 +23,63 @@ import (
 	"gorm.io/gorm"
 )
 
+// testPasswordHasher uses the cheapest argon2id parameters to keep tests fast.
+var testPasswordHasher = hashing.NewArgon2idHasher(hashing.Argon2Params{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+
+// testPasswordPolicy only enforces a length, so fixtures can use simple
+// passwords; the policy rules have their own tests.
+var testPasswordPolicy = &validators.PasswordPolicy{MinLength: 6}
+
+func passwordMatches(hash, password string) bool {
+	ok, err := testPasswordHasher.Verify(hash, password)
+	return err == nil && ok
+}
+
+func newTestLoginThrottler() *services.LoginThrottler {
+	return services.NewLoginThrottler(repositories.NewMemoryLoginAttemptRepository(time.Hour), services.DefaultLoginThrottleConfig())
+}
+
+func newTestKeySet(t *testing.T) *signing.KeySet {
+	key, err := signing.GenerateEd25519Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keySet, err := signing.NewKeySet("mobile-shop", "mobile-shop-api", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keySet
+}
+
+// newTestSessionRepo returns a session repository that accepts new sessions.
+func newTestSessionRepo() *mocks.MockSessionRepository {
+	mockSessionRepo := new(mocks.MockSessionRepository)
+	mockSessionRepo.On("Create", mock.AnythingOfType("*models.Session")).Return(nil).Maybe()
+	return mockSessionRepo
+}
+
+// newTestAuthEventRepo returns an audit log that accepts every event.
+func newTestAuthEventRepo() *mocks.MockAuthEventRepository {
+	mockAuthEventRepo := new(mocks.MockAuthEventRepository)
+	mockAuthEventRepo.On("Create", mock.AnythingOfType("*models.AuthEvent")).Return(nil).Maybe()
+	return mockAuthEventRepo
+}
+
+// newTestRoleService returns a role service for users without any roles.
+func newTestRoleService() *services.RoleService {
+	mockRoleRepo := new(mocks.MockRoleRepository)
+	mockRoleRepo.On("GetUserRoles", mock.AnythingOfType("uuid.UUID")).Return([]models.Role{}, nil).Maybe()
+	return services.NewRoleService(mockRoleRepo, new(mocks.MockUserRepository))
+}
+
 func TestAuthService_Register(t *testing.T) {
 	testCases := []struct {
 		name          string
 		input         models.RegisterRequest
-		mockSetup     func(*mocks.MockUserRepository)
+		mockSetup     func(*mocks.MockUserRepository, *mocks.MockRefreshTokenRepository)
 		expectedError bool
 		errorMessage  string
+		expectedErr   error
 	}{
 		{
 			name: "Successful registration",
@@ -31,7 +89,7 @@ func TestAuthService_Register(t *testing.T) {
 				Email:    "john@example.com",
 				Password: "password123",
 			},
-			mockSetup: func(mockRepo *mocks.MockUserRepository) {
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
 				mockRepo.On("EmailExists", "john@example.com").Return(false, nil)
 				mockRepo.On("UsernameExists", "johndoe").Return(false, nil)
 				mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil).Run(func(args mock.Arguments) {
@@ -40,9 +98,44 @@ func TestAuthService_Register(t *testing.T) {
 						user.ID = uuid.New()
 					}
 				})
+				mockTokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
 			},
 			expectedError: false,
 		},
+		{
+			name: "Email and username are normalized",
+			input: models.RegisterRequest{
+				Name:     "John Doe",
+				Username: "JohnDoe",
+				Email:    "John@Example.com",
+				Password: "password123",
+			},
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
+				mockRepo.On("EmailExists", "john@example.com").Return(false, nil)
+				mockRepo.On("UsernameExists", "johndoe").Return(false, nil)
+				mockRepo.On("Create", mock.MatchedBy(func(user *models.User) bool {
+					return user.Email == "john@example.com" && user.Username == "johndoe"
+				})).Return(nil).Run(func(args mock.Arguments) {
+					args.Get(0).(*models.User).ID = uuid.New()
+				})
+				mockTokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "Email already exists in another case",
+			input: models.RegisterRequest{
+				Name:     "John Doe",
+				Username: "johndoe",
+				Email:    "JOHN@example.com",
+				Password: "password123",
+			},
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
+				mockRepo.On("EmailExists", "john@example.com").Return(true, nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrEmailExists,
+		},
 		{
 			name: "Email already exists",
 			input: models.RegisterRequest{
@@ -51,11 +144,11 @@ func TestAuthService_Register(t *testing.T) {
 				Email:    "john@example.com",
 				Password: "password123",
 			},
-			mockSetup: func(mockRepo *mocks.MockUserRepository) {
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
 				mockRepo.On("EmailExists", "john@example.com").Return(true, nil)
 			},
 			expectedError: true,
-			errorMessage:  "email already exists",
+			expectedErr:   apperrors.ErrEmailExists,
 		},
 		{
 			name: "Username already exists",
@@ -65,12 +158,12 @@ func TestAuthService_Register(t *testing.T) {
 				Email:    "john@example.com",
 				Password: "password123",
 			},
-			mockSetup: func(mockRepo *mocks.MockUserRepository) {
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
 				mockRepo.On("EmailExists", "john@example.com").Return(false, nil)
 				mockRepo.On("UsernameExists", "johndoe").Return(true, nil)
 			},
 			expectedError: true,
-			errorMessage:  "username already exists",
+			expectedErr:   apperrors.ErrUsernameExists,
 		},
 		{
 			name: "Database error during email check",
@@ -80,7 +173,7 @@ func TestAuthService_Register(t *testing.T) {
 				Email:    "john@example.com",
 				Password: "password123",
 			},
-			mockSetup: func(mockRepo *mocks.MockUserRepository) {
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
 				mockRepo.On("EmailExists", "john@example.com").Return(false, errors.New("database error"))
 			},
 			expectedError: true,
@@ -94,7 +187,7 @@ func TestAuthService_Register(t *testing.T) {
 				Email:    "john@example.com",
 				Password: "password123",
 			},
-			mockSetup: func(mockRepo *mocks.MockUserRepository) {
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
 				mockRepo.On("EmailExists", "john@example.com").Return(false, nil)
 				mockRepo.On("UsernameExists", "johndoe").Return(false, errors.New("database error"))
 			},
@@ -109,7 +202,7 @@ func TestAuthService_Register(t *testing.T) {
 				Email:    "john@example.com",
 				Password: "password123",
 			},
-			mockSetup: func(mockRepo *mocks.MockUserRepository) {
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
 				mockRepo.On("EmailExists", "john@example.com").Return(false, nil)
 				mockRepo.On("UsernameExists", "johndoe").Return(false, nil)
 				mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(errors.New("database error"))
@@ -119,53 +212,85 @@ func TestAuthService_Register(t *testing.T) {
 		},
 	}
 
-	jwtSecret := []byte("test-secret")
-
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockRepo := new(mocks.MockUserRepository)
-			tc.mockSetup(mockRepo)
+			mockTokenRepo := new(mocks.MockRefreshTokenRepository)
+			tc.mockSetup(mockRepo, mockTokenRepo)
 
-			authService := services.NewAuthService(mockRepo, jwtSecret)
-			user, token, err := authService.Register(&tc.input)
+			authService := services.NewAuthService(mockRepo, mockTokenRepo, new(mocks.MockRevokedTokenRepository), newTestSessionRepo(), newTestAuthEventRepo(), newTestRoleService(), newTestLoginThrottler(), nil, testPasswordHasher, testPasswordPolicy, newTestKeySet(t), services.DefaultTokenConfig())
+			user, tokens, err := authService.Register(&tc.input, services.ClientInfo{})
 
 			if tc.expectedError {
 				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tc.errorMessage)
+				if tc.expectedErr != nil {
+					assert.ErrorIs(t, err, tc.expectedErr)
+				} else {
+					assert.Contains(t, err.Error(), tc.errorMessage)
+				}
 				assert.Nil(t, user)
-				assert.Empty(t, token)
+				assert.Nil(t, tokens)
 			} else {
 				assert.NoError(t, err)
 				assert.NotNil(t, user)
-				assert.NotEmpty(t, token)
+				assert.NotNil(t, tokens)
+				assert.NotEmpty(t, tokens.AccessToken)
+				assert.NotEmpty(t, tokens.RefreshToken)
 				assert.Equal(t, tc.input.Name, user.Name)
-				assert.Equal(t, tc.input.Username, user.Username)
-				assert.Equal(t, tc.input.Email, user.Email)
+				assert.Equal(t, "johndoe", user.Username)
+				assert.Equal(t, "john@example.com", user.Email)
 				assert.NotEmpty(t, user.ID)
 				assert.NotEmpty(t, user.Password) // Should be hashed
 			}
 
 			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
 		})
 	}
 }
 
+func TestAuthService_RegisterRejectsWeakPassword(t *testing.T) {
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("EmailExists", "john@example.com").Return(false, nil)
+	mockRepo.On("UsernameExists", "johndoe").Return(false, nil)
+
+	policy := validators.DefaultPasswordPolicy()
+	authService := services.NewAuthService(mockRepo, new(mocks.MockRefreshTokenRepository), new(mocks.MockRevokedTokenRepository), newTestSessionRepo(), newTestAuthEventRepo(), newTestRoleService(), newTestLoginThrottler(), nil, testPasswordHasher, &policy, newTestKeySet(t), services.DefaultTokenConfig())
+	_, _, err := authService.Register(&models.RegisterRequest{
+		Name:     "John Doe",
+		Username: "johndoe",
+		Email:    "john@example.com",
+		Password: "johndoe1",
+	}, services.ClientInfo{})
+
+	assert.ErrorIs(t, err, apperrors.ErrWeakPassword)
+	var appErr *apperrors.Error
+	if assert.ErrorAs(t, err, &appErr) {
+		assert.Equal(t, []apperrors.Violation{
+			{Rule: validators.PasswordRulePersonalInfo, Message: "password must not contain your name, username or email address"},
+			{Rule: validators.PasswordRuleStrength, Message: "password is too easy to guess; avoid common words, sequences and repeated characters"},
+		}, appErr.Violations)
+	}
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestAuthService_Login(t *testing.T) {
-	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
 	testUser := &models.User{
 		ID:       uuid.New(),
 		Name:     "John Doe",
 		Username: "johndoe",
 		Email:    "john@example.com",
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 	}
 
 	testCases := []struct {
 		name          string
 		input         models.LoginRequest
-		mockSetup     func(*mocks.MockUserRepository)
+		mockSetup     func(*mocks.MockUserRepository, *mocks.MockRefreshTokenRepository)
 		expectedError bool
-		errorMessage  string
+		expectedErr   error
 	}{
 		{
 			name: "Successful login",
@@ -173,8 +298,33 @@ func TestAuthService_Login(t *testing.T) {
 				Username: "johndoe",
 				Password: "password123",
 			},
-			mockSetup: func(mockRepo *mocks.MockUserRepository) {
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
+				mockRepo.On("GetByUsername", "johndoe").Return(testUser, nil)
+				mockTokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "Successful login with email in any case",
+			input: models.LoginRequest{
+				Identifier: " John@Example.COM",
+				Password:   "password123",
+			},
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
+				mockRepo.On("GetByEmail", "john@example.com").Return(testUser, nil)
+				mockTokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "Username is case-insensitive",
+			input: models.LoginRequest{
+				Identifier: "JohnDoe",
+				Password:   "password123",
+			},
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
 				mockRepo.On("GetByUsername", "johndoe").Return(testUser, nil)
+				mockTokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
 			},
 			expectedError: false,
 		},
@@ -184,11 +334,11 @@ func TestAuthService_Login(t *testing.T) {
 				Username: "johndoe",
 				Password: "password123",
 			},
-			mockSetup: func(mockRepo *mocks.MockUserRepository) {
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
 				mockRepo.On("GetByUsername", "johndoe").Return(nil, gorm.ErrRecordNotFound)
 			},
 			expectedError: true,
-			errorMessage:  "invalid credentials",
+			expectedErr:   apperrors.ErrInvalidCredentials,
 		},
 		{
 			name: "Wrong password",
@@ -196,11 +346,11 @@ func TestAuthService_Login(t *testing.T) {
 				Username: "johndoe",
 				Password: "wrongpassword",
 			},
-			mockSetup: func(mockRepo *mocks.MockUserRepository) {
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
 				mockRepo.On("GetByUsername", "johndoe").Return(testUser, nil)
 			},
 			expectedError: true,
-			errorMessage:  "invalid credentials",
+			expectedErr:   apperrors.ErrInvalidCredentials,
 		},
 		{
 			name: "Database error during lookup",
@@ -208,33 +358,35 @@ func TestAuthService_Login(t *testing.T) {
 				Username: "johndoe",
 				Password: "password123",
 			},
-			mockSetup: func(mockRepo *mocks.MockUserRepository) {
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
 				mockRepo.On("GetByUsername", "johndoe").Return(nil, errors.New("database error"))
 			},
 			expectedError: true,
-			errorMessage:  "invalid credentials",
+			expectedErr:   apperrors.ErrInvalidCredentials,
 		},
 	}
 
-	jwtSecret := []byte("test-secret")
-
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockRepo := new(mocks.MockUserRepository)
-			tc.mockSetup(mockRepo)
+			mockTokenRepo := new(mocks.MockRefreshTokenRepository)
+			tc.mockSetup(mockRepo, mockTokenRepo)
 
-			authService := services.NewAuthService(mockRepo, jwtSecret)
-			user, token, err := authService.Login(&tc.input)
+			authService := services.NewAuthService(mockRepo, mockTokenRepo, new(mocks.MockRevokedTokenRepository), newTestSessionRepo(), newTestAuthEventRepo(), newTestRoleService(), newTestLoginThrottler(), nil, testPasswordHasher, testPasswordPolicy, newTestKeySet(t), services.DefaultTokenConfig())
+			result, err := authService.Login(&tc.input, services.ClientInfo{IP: "192.0.2.1"})
 
 			if tc.expectedError {
 				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tc.errorMessage)
-				assert.Nil(t, user)
-				assert.Empty(t, token)
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, result)
 			} else {
 				assert.NoError(t, err)
-				assert.NotNil(t, user)
-				assert.NotEmpty(t, token)
+				assert.NotNil(t, result)
+				user, tokens := result.User, result.Tokens
+				assert.NotNil(t, tokens)
+				assert.NotEmpty(t, tokens.AccessToken)
+				assert.NotEmpty(t, tokens.RefreshToken)
+				assert.Empty(t, result.MFAToken)
 				assert.Equal(t, testUser.ID, user.ID)
 				assert.Equal(t, testUser.Name, user.Name)
 				assert.Equal(t, testUser.Username, user.Username)
@@ -242,6 +394,477 @@ func TestAuthService_Login(t *testing.T) {
 			}
 
 			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthService_LoginUpgradesLegacyHash(t *testing.T) {
+	legacyHash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	newUser := func() *models.User {
+		return &models.User{ID: uuid.New(), Username: "johndoe", Password: string(legacyHash)}
+	}
+
+	t.Run("Rehashed after a successful login", func(t *testing.T) {
+		testUser := newUser()
+		mockRepo := new(mocks.MockUserRepository)
+		mockTokenRepo := new(mocks.MockRefreshTokenRepository)
+		mockRepo.On("GetByUsername", "johndoe").Return(testUser, nil)
+		mockRepo.On("UpdatePassword", testUser.ID, mock.MatchedBy(func(hash string) bool {
+			return strings.HasPrefix(hash, "$argon2id$") && passwordMatches(hash, "password123")
+		})).Return(nil)
+		mockTokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+
+		authService := services.NewAuthService(mockRepo, mockTokenRepo, new(mocks.MockRevokedTokenRepository), newTestSessionRepo(), newTestAuthEventRepo(), newTestRoleService(), newTestLoginThrottler(), nil, testPasswordHasher, testPasswordPolicy, newTestKeySet(t), services.DefaultTokenConfig())
+		result, err := authService.Login(&models.LoginRequest{Username: "johndoe", Password: "password123"}, services.ClientInfo{})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result.Tokens)
+		assert.False(t, testPasswordHasher.NeedsRehash(testUser.Password))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Failed rehash does not fail the login", func(t *testing.T) {
+		testUser := newUser()
+		mockRepo := new(mocks.MockUserRepository)
+		mockTokenRepo := new(mocks.MockRefreshTokenRepository)
+		mockRepo.On("GetByUsername", "johndoe").Return(testUser, nil)
+		mockRepo.On("UpdatePassword", testUser.ID, mock.AnythingOfType("string")).Return(errors.New("database error"))
+		mockTokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+
+		authService := services.NewAuthService(mockRepo, mockTokenRepo, new(mocks.MockRevokedTokenRepository), newTestSessionRepo(), newTestAuthEventRepo(), newTestRoleService(), newTestLoginThrottler(), nil, testPasswordHasher, testPasswordPolicy, newTestKeySet(t), services.DefaultTokenConfig())
+		result, err := authService.Login(&models.LoginRequest{Username: "johndoe", Password: "password123"}, services.ClientInfo{})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result.Tokens)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Not rehashed after a wrong password", func(t *testing.T) {
+		mockRepo := new(mocks.MockUserRepository)
+		mockRepo.On("GetByUsername", "johndoe").Return(newUser(), nil)
+
+		authService := services.NewAuthService(mockRepo, new(mocks.MockRefreshTokenRepository), new(mocks.MockRevokedTokenRepository), newTestSessionRepo(), newTestAuthEventRepo(), newTestRoleService(), newTestLoginThrottler(), nil, testPasswordHasher, testPasswordPolicy, newTestKeySet(t), services.DefaultTokenConfig())
+		_, err := authService.Login(&models.LoginRequest{Username: "johndoe", Password: "wrongpassword"}, services.ClientInfo{})
+
+		assert.ErrorIs(t, err, apperrors.ErrInvalidCredentials)
+		mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	testUser := &models.User{
+		ID:       uuid.New(),
+		Name:     "John Doe",
+		Username: "johndoe",
+		Email:    "john@example.com",
+	}
+	familyID := uuid.New()
+	usedAt := time.Now().Add(-time.Minute)
+	rawToken := "refresh-token"
+
+	newStoredToken := func() *models.RefreshToken {
+		return &models.RefreshToken{
+			ID:        uuid.New(),
+			UserID:    testUser.ID,
+			FamilyID:  familyID,
+			TokenHash: utils.HashToken(rawToken),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	newSession := func() *models.Session {
+		return &models.Session{
+			ID:        familyID,
+			UserID:    testUser.ID,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		mockSetup     func(*mocks.MockUserRepository, *mocks.MockRefreshTokenRepository, *mocks.MockSessionRepository)
+		expectedError bool
+		expectedErr   error
+	}{
+		{
+			name: "Successful rotation",
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository, mockSessionRepo *mocks.MockSessionRepository) {
+				stored := newStoredToken()
+				mockTokenRepo.On("GetByHash", utils.HashToken(rawToken)).Return(stored, nil)
+				mockSessionRepo.On("GetByID", familyID).Return(newSession(), nil)
+				mockTokenRepo.On("MarkUsed", stored.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+				mockRepo.On("GetByID", testUser.ID).Return(testUser, nil)
+				mockTokenRepo.On("Create", mock.MatchedBy(func(token *models.RefreshToken) bool {
+					return token.FamilyID == familyID && token.TokenHash != utils.HashToken(rawToken)
+				})).Return(nil)
+				mockSessionRepo.On("Refresh", familyID, "192.0.2.1", "test-agent", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "Unknown token",
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository, mockSessionRepo *mocks.MockSessionRepository) {
+				mockTokenRepo.On("GetByHash", utils.HashToken(rawToken)).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrInvalidRefreshToken,
+		},
+		{
+			name: "Expired token",
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository, mockSessionRepo *mocks.MockSessionRepository) {
+				stored := newStoredToken()
+				stored.ExpiresAt = time.Now().Add(-time.Hour)
+				mockTokenRepo.On("GetByHash", utils.HashToken(rawToken)).Return(stored, nil)
+				mockSessionRepo.On("GetByID", familyID).Return(newSession(), nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrRefreshTokenExpired,
+		},
+		{
+			name: "Reused token revokes the family",
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository, mockSessionRepo *mocks.MockSessionRepository) {
+				stored := newStoredToken()
+				stored.UsedAt = &usedAt
+				mockTokenRepo.On("GetByHash", utils.HashToken(rawToken)).Return(stored, nil)
+				mockSessionRepo.On("Revoke", familyID, testUser.ID).Return(true, nil)
+				mockTokenRepo.On("RevokeFamily", familyID).Return(nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrRefreshTokenReused,
+		},
+		{
+			name: "Concurrent use revokes the family",
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository, mockSessionRepo *mocks.MockSessionRepository) {
+				stored := newStoredToken()
+				mockTokenRepo.On("GetByHash", utils.HashToken(rawToken)).Return(stored, nil)
+				mockSessionRepo.On("GetByID", familyID).Return(newSession(), nil)
+				mockTokenRepo.On("MarkUsed", stored.ID, mock.AnythingOfType("time.Time")).Return(false, nil)
+				mockSessionRepo.On("Revoke", familyID, testUser.ID).Return(true, nil)
+				mockTokenRepo.On("RevokeFamily", familyID).Return(nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrRefreshTokenReused,
+		},
+		{
+			name: "Revoked session",
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository, mockSessionRepo *mocks.MockSessionRepository) {
+				stored := newStoredToken()
+				session := newSession()
+				session.RevokedAt = &usedAt
+				mockTokenRepo.On("GetByHash", utils.HashToken(rawToken)).Return(stored, nil)
+				mockSessionRepo.On("GetByID", familyID).Return(session, nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrInvalidRefreshToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockUserRepository)
+			mockTokenRepo := new(mocks.MockRefreshTokenRepository)
+			mockSessionRepo := new(mocks.MockSessionRepository)
+			tc.mockSetup(mockRepo, mockTokenRepo, mockSessionRepo)
+
+			authService := services.NewAuthService(mockRepo, mockTokenRepo, new(mocks.MockRevokedTokenRepository), mockSessionRepo, newTestAuthEventRepo(), newTestRoleService(), newTestLoginThrottler(), nil, testPasswordHasher, testPasswordPolicy, newTestKeySet(t), services.DefaultTokenConfig())
+			user, tokens, err := authService.Refresh(rawToken, services.ClientInfo{IP: "192.0.2.1", UserAgent: "test-agent"})
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, user)
+				assert.Nil(t, tokens)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testUser.ID, user.ID)
+				assert.NotEmpty(t, tokens.AccessToken)
+				assert.NotEqual(t, rawToken, tokens.RefreshToken)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+			mockSessionRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	userID := uuid.New()
+	sessionID := uuid.New()
+	familyID := uuid.New()
+	expiresAt := time.Now().Add(time.Minute)
+
+	mockRepo := new(mocks.MockUserRepository)
+	mockTokenRepo := new(mocks.MockRefreshTokenRepository)
+	mockRevokedRepo := new(mocks.MockRevokedTokenRepository)
+	mockSessionRepo := new(mocks.MockSessionRepository)
+
+	mockRevokedRepo.On("Revoke", mock.MatchedBy(func(token *models.RevokedToken) bool {
+		return token.JTI == "token-id" && token.UserID == userID && token.ExpiresAt.Equal(expiresAt)
+	})).Return(nil)
+	mockRevokedRepo.On("DeleteExpired", mock.AnythingOfType("time.Time")).Return(nil)
+	mockTokenRepo.On("GetByHash", utils.HashToken("refresh-token")).Return(&models.RefreshToken{
+		UserID:   userID,
+		FamilyID: familyID,
+	}, nil)
+	mockSessionRepo.On("Revoke", sessionID, userID).Return(true, nil)
+	mockTokenRepo.On("RevokeFamily", sessionID).Return(nil)
+	mockSessionRepo.On("Revoke", familyID, userID).Return(true, nil)
+	mockTokenRepo.On("RevokeFamily", familyID).Return(nil)
+
+	authService := services.NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessionRepo, newTestAuthEventRepo(), newTestRoleService(), newTestLoginThrottler(), nil, testPasswordHasher, testPasswordPolicy, newTestKeySet(t), services.DefaultTokenConfig())
+	err := authService.Logout(userID.String(), sessionID.String(), "token-id", expiresAt, "refresh-token", services.ClientInfo{})
+
+	assert.NoError(t, err)
+	mockTokenRepo.AssertExpectations(t)
+	mockRevokedRepo.AssertExpectations(t)
+	mockSessionRepo.AssertExpectations(t)
+}
+
+func TestAuthService_LogoutAll(t *testing.T) {
+	userID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		userID        string
+		mockSetup     func(*mocks.MockUserRepository, *mocks.MockRefreshTokenRepository, *mocks.MockSessionRepository)
+		expectedError bool
+		errorMessage  string
+		expectedErr   error
+	}{
+		{
+			name:   "Revokes every token",
+			userID: userID.String(),
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository, mockSessionRepo *mocks.MockSessionRepository) {
+				mockRepo.On("IncrementTokenVersion", userID).Return(nil)
+				mockTokenRepo.On("RevokeAllForUser", userID).Return(nil)
+				mockSessionRepo.On("RevokeAllForUser", userID).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:          "Invalid user ID",
+			userID:        "not-a-uuid",
+			mockSetup:     func(*mocks.MockUserRepository, *mocks.MockRefreshTokenRepository, *mocks.MockSessionRepository) {},
+			expectedError: true,
+			expectedErr:   apperrors.ErrInvalidUserID,
+		},
+		{
+			name:   "Database error",
+			userID: userID.String(),
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository, mockSessionRepo *mocks.MockSessionRepository) {
+				mockRepo.On("IncrementTokenVersion", userID).Return(errors.New("database error"))
+			},
+			expectedError: true,
+			errorMessage:  "failed to revoke tokens",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockUserRepository)
+			mockTokenRepo := new(mocks.MockRefreshTokenRepository)
+			mockSessionRepo := new(mocks.MockSessionRepository)
+			tc.mockSetup(mockRepo, mockTokenRepo, mockSessionRepo)
+
+			authService := services.NewAuthService(mockRepo, mockTokenRepo, new(mocks.MockRevokedTokenRepository), mockSessionRepo, newTestAuthEventRepo(), newTestRoleService(), newTestLoginThrottler(), nil, testPasswordHasher, testPasswordPolicy, newTestKeySet(t), services.DefaultTokenConfig())
+			err := authService.LogoutAll(tc.userID, services.ClientInfo{})
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				if tc.expectedErr != nil {
+					assert.ErrorIs(t, err, tc.expectedErr)
+				} else {
+					assert.Contains(t, err.Error(), tc.errorMessage)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+			mockSessionRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthService_UpdateProfile(t *testing.T) {
+	verifiedAt := time.Now().Add(-time.Hour)
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	newTestUser := func() *models.User {
+		return &models.User{
+			ID:         uuid.New(),
+			Name:       "John Doe",
+			Username:   "johndoe",
+			Email:      "john@example.com",
+			Password:   hashedPassword,
+			VerifiedAt: &verifiedAt,
+		}
+	}
+	stringPtr := func(s string) *string { return &s }
+
+	testCases := []struct {
+		name                  string
+		input                 models.UpdateProfileRequest
+		mockSetup             func(*mocks.MockUserRepository, *models.User)
+		expectedError         bool
+		expectedErr           error
+		expectedPreviousEmail string
+	}{
+		{
+			name:  "Changes name and username",
+			input: models.UpdateProfileRequest{Name: stringPtr("Johnny"), Username: stringPtr("johnny")},
+			mockSetup: func(mockRepo *mocks.MockUserRepository, user *models.User) {
+				mockRepo.On("GetByID", user.ID).Return(user, nil)
+				mockRepo.On("UsernameExists", "johnny").Return(false, nil)
+				mockRepo.On("UpdateProfile", mock.MatchedBy(func(u *models.User) bool {
+					return u.Name == "Johnny" && u.Username == "johnny" && u.VerifiedAt != nil
+				})).Return(nil)
+			},
+		},
+		{
+			name:  "New email must be verified again",
+			input: models.UpdateProfileRequest{Email: stringPtr("johnny@example.com"), CurrentPassword: "password123"},
+			mockSetup: func(mockRepo *mocks.MockUserRepository, user *models.User) {
+				mockRepo.On("GetByID", user.ID).Return(user, nil)
+				mockRepo.On("EmailExists", "johnny@example.com").Return(false, nil)
+				mockRepo.On("UpdateProfile", mock.MatchedBy(func(u *models.User) bool {
+					return u.Email == "johnny@example.com" && u.VerifiedAt == nil
+				})).Return(nil)
+			},
+			expectedPreviousEmail: "john@example.com",
+		},
+		{
+			name:  "Email change without the current password",
+			input: models.UpdateProfileRequest{Email: stringPtr("johnny@example.com")},
+			mockSetup: func(mockRepo *mocks.MockUserRepository, user *models.User) {
+				mockRepo.On("GetByID", user.ID).Return(user, nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrValidation,
+		},
+		{
+			name:  "Email change with a wrong current password",
+			input: models.UpdateProfileRequest{Email: stringPtr("johnny@example.com"), CurrentPassword: "wrong-password"},
+			mockSetup: func(mockRepo *mocks.MockUserRepository, user *models.User) {
+				mockRepo.On("GetByID", user.ID).Return(user, nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrInvalidPassword,
+		},
+		{
+			name:  "Unchanged email is not checked",
+			input: models.UpdateProfileRequest{Email: stringPtr("john@example.com")},
+			mockSetup: func(mockRepo *mocks.MockUserRepository, user *models.User) {
+				mockRepo.On("GetByID", user.ID).Return(user, nil)
+				mockRepo.On("UpdateProfile", mock.AnythingOfType("*models.User")).Return(nil)
+			},
+		},
+		{
+			name:  "Username taken",
+			input: models.UpdateProfileRequest{Username: stringPtr("janedoe")},
+			mockSetup: func(mockRepo *mocks.MockUserRepository, user *models.User) {
+				mockRepo.On("GetByID", user.ID).Return(user, nil)
+				mockRepo.On("UsernameExists", "janedoe").Return(true, nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrUsernameExists,
+		},
+		{
+			name:  "Email taken",
+			input: models.UpdateProfileRequest{Email: stringPtr("jane@example.com"), CurrentPassword: "password123"},
+			mockSetup: func(mockRepo *mocks.MockUserRepository, user *models.User) {
+				mockRepo.On("GetByID", user.ID).Return(user, nil)
+				mockRepo.On("EmailExists", "jane@example.com").Return(true, nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrEmailExists,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testUser := newTestUser()
+			mockRepo := new(mocks.MockUserRepository)
+			tc.mockSetup(mockRepo, testUser)
+
+			authService := services.NewAuthService(mockRepo, new(mocks.MockRefreshTokenRepository), new(mocks.MockRevokedTokenRepository), newTestSessionRepo(), newTestAuthEventRepo(), newTestRoleService(), newTestLoginThrottler(), nil, testPasswordHasher, testPasswordPolicy, newTestKeySet(t), services.DefaultTokenConfig())
+			user, previousEmail, err := authService.UpdateProfile(testUser.ID.String(), &tc.input, services.ClientInfo{})
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, user)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedPreviousEmail, previousEmail)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthService_ChangePassword(t *testing.T) {
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	testUser := &models.User{
+		ID:       uuid.New(),
+		Username: "johndoe",
+		Password: hashedPassword,
+	}
+	sessionID := uuid.New()
+
+	testCases := []struct {
+		name            string
+		currentPassword string
+		mockSetup       func(*mocks.MockUserRepository, *mocks.MockRefreshTokenRepository, *mocks.MockSessionRepository)
+		expectedError   bool
+		expectedErr     error
+	}{
+		{
+			name:            "Changes the password and signs out other sessions",
+			currentPassword: "password123",
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository, mockSessionRepo *mocks.MockSessionRepository) {
+				mockRepo.On("GetByID", testUser.ID).Return(testUser, nil)
+				mockRepo.On("UpdatePassword", testUser.ID, mock.MatchedBy(func(hash string) bool {
+					return passwordMatches(hash, "newpassword456")
+				})).Return(nil)
+				mockSessionRepo.On("RevokeOthersForUser", testUser.ID, sessionID).Return(nil)
+				mockTokenRepo.On("RevokeOthersForUser", testUser.ID, sessionID).Return(nil)
+			},
+		},
+		{
+			name:            "Wrong current password",
+			currentPassword: "wrongpassword",
+			mockSetup: func(mockRepo *mocks.MockUserRepository, mockTokenRepo *mocks.MockRefreshTokenRepository, mockSessionRepo *mocks.MockSessionRepository) {
+				mockRepo.On("GetByID", testUser.ID).Return(testUser, nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrInvalidPassword,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockUserRepository)
+			mockTokenRepo := new(mocks.MockRefreshTokenRepository)
+			mockSessionRepo := new(mocks.MockSessionRepository)
+			tc.mockSetup(mockRepo, mockTokenRepo, mockSessionRepo)
+
+			authService := services.NewAuthService(mockRepo, mockTokenRepo, new(mocks.MockRevokedTokenRepository), mockSessionRepo, newTestAuthEventRepo(), newTestRoleService(), newTestLoginThrottler(), nil, testPasswordHasher, testPasswordPolicy, newTestKeySet(t), services.DefaultTokenConfig())
+			err := authService.ChangePassword(testUser.ID.String(), sessionID.String(), tc.currentPassword, "newpassword456", services.ClientInfo{})
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+			mockSessionRepo.AssertExpectations(t)
 		})
 	}
 }
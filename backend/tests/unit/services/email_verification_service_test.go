@@ -0,0 +1,160 @@
+package services
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/mail"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// sendAndCaptureToken sends a verification email and extracts the token from
+// the link in its body.
+func sendAndCaptureToken(t *testing.T, service *services.EmailVerificationService, mockRepo *mocks.MockUserRepository, mockMailer *mocks.MockMailer, user *models.User) string {
+	var body string
+	mockMailer.On("Send", mock.AnythingOfType("mail.Message")).Return(nil).Run(func(args mock.Arguments) {
+		body = args.Get(0).(mail.Message).Body
+	}).Once()
+	mockRepo.On("SetVerificationSentAt", user.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	require.NoError(t, service.SendVerification(user))
+
+	_, rest, found := strings.Cut(body, "/verify-email?token=")
+	require.True(t, found)
+	token, err := url.QueryUnescape(strings.Fields(rest)[0])
+	require.NoError(t, err)
+	return token
+}
+
+func TestEmailVerificationService_Verify(t *testing.T) {
+	user := &models.User{
+		ID:    uuid.New(),
+		Name:  "John Doe",
+		Email: "john@example.com",
+	}
+
+	mockRepo := new(mocks.MockUserRepository)
+	mockMailer := new(mocks.MockMailer)
+	service := services.NewEmailVerificationService(mockRepo, mockMailer, []byte("test-secret"), "http://shop.test")
+
+	token := sendAndCaptureToken(t, service, mockRepo, mockMailer, user)
+
+	t.Run("Valid token verifies the email", func(t *testing.T) {
+		mockRepo.On("GetByID", user.ID).Return(user, nil).Once()
+		mockRepo.On("MarkEmailVerified", user.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		verified, err := service.Verify(token)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, verified.VerifiedAt)
+	})
+
+	t.Run("Tampered token is rejected", func(t *testing.T) {
+		_, err := service.Verify(token[:len(token)-2] + "xx")
+
+		assert.Error(t, err)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidVerificationToken)
+	})
+
+	t.Run("Token signed with another key is rejected", func(t *testing.T) {
+		other := services.NewEmailVerificationService(mockRepo, mockMailer, []byte("other-secret"), "http://shop.test")
+
+		_, err := other.Verify(token)
+
+		assert.Error(t, err)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidVerificationToken)
+	})
+
+	t.Run("Token for a previous email is rejected", func(t *testing.T) {
+		changed := *user
+		changed.Email = "new@example.com"
+		changed.VerifiedAt = nil
+		mockRepo.On("GetByID", user.ID).Return(&changed, nil).Once()
+
+		_, err := service.Verify(token)
+
+		assert.Error(t, err)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidVerificationToken)
+	})
+
+	mockRepo.AssertExpectations(t)
+	mockMailer.AssertExpectations(t)
+}
+
+func TestEmailVerificationService_ResendVerification(t *testing.T) {
+	recently := time.Now().Add(-10 * time.Second)
+	verifiedAt := time.Now().Add(-time.Hour)
+
+	testCases := []struct {
+		name          string
+		user          *models.User
+		expectSend    bool
+		expectedError bool
+		expectedErr   error
+	}{
+		{
+			name:       "Sends when no email was sent before",
+			user:       &models.User{ID: uuid.New(), Email: "john@example.com"},
+			expectSend: true,
+		},
+		{
+			name:          "Throttles repeated requests",
+			user:          &models.User{ID: uuid.New(), Email: "john@example.com", VerificationSentAt: &recently},
+			expectedError: true,
+			expectedErr:   apperrors.ErrVerificationThrottled,
+		},
+		{
+			name:          "Already verified",
+			user:          &models.User{ID: uuid.New(), Email: "john@example.com", VerifiedAt: &verifiedAt},
+			expectedError: true,
+			expectedErr:   apperrors.ErrEmailAlreadyVerified,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockUserRepository)
+			mockMailer := new(mocks.MockMailer)
+			mockRepo.On("GetByID", tc.user.ID).Return(tc.user, nil)
+			if tc.expectSend {
+				mockMailer.On("Send", mock.AnythingOfType("mail.Message")).Return(nil)
+				mockRepo.On("SetVerificationSentAt", tc.user.ID, mock.AnythingOfType("time.Time")).Return(nil)
+			}
+
+			service := services.NewEmailVerificationService(mockRepo, mockMailer, []byte("test-secret"), "http://shop.test")
+			err := service.ResendVerification(tc.user.ID.String())
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockMailer.AssertExpectations(t)
+		})
+	}
+}
+
+func TestEmailVerificationService_NotifyEmailChanged(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Name: "John Doe", Email: "johnny@example.com"}
+	mockMailer := new(mocks.MockMailer)
+	mockMailer.On("Send", mock.MatchedBy(func(msg mail.Message) bool {
+		return msg.To == "john@example.com" && strings.Contains(msg.Body, "johnny@example.com")
+	})).Return(nil)
+	service := services.NewEmailVerificationService(new(mocks.MockUserRepository), mockMailer, []byte("test-secret"), "http://shop.test")
+
+	assert.NoError(t, service.NotifyEmailChanged(user, "john@example.com"))
+	mockMailer.AssertExpectations(t)
+}
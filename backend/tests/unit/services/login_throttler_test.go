@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginThrottler_ProgressiveDelay(t *testing.T) {
+	config := services.DefaultLoginThrottleConfig()
+	config.DelayAfter = 2
+	config.BaseDelay = time.Hour
+	config.MaxDelay = 4 * time.Hour
+	throttler := services.NewLoginThrottler(repositories.NewMemoryLoginAttemptRepository(time.Hour), config)
+	key := services.UnknownAccountKey("johndoe")
+
+	require.NoError(t, throttler.RecordFailure(key, "192.0.2.1"))
+	assert.NoError(t, throttler.CheckAccount(key), "no delay before DelayAfter failures")
+
+	require.NoError(t, throttler.RecordFailure(key, "192.0.2.1"))
+	err := throttler.CheckAccount(key)
+	var throttleErr *apperrors.Error
+	require.True(t, errors.As(err, &throttleErr))
+	assert.ErrorIs(t, err, apperrors.ErrTooManyAttempts)
+	assert.InDelta(t, time.Hour.Seconds(), throttleErr.RetryAfter.Seconds(), 5)
+
+	require.NoError(t, throttler.RecordFailure(key, "192.0.2.1"))
+	require.True(t, errors.As(throttler.CheckAccount(key), &throttleErr))
+	assert.InDelta(t, (2 * time.Hour).Seconds(), throttleErr.RetryAfter.Seconds(), 5, "delay doubles")
+
+	require.NoError(t, throttler.RecordSuccess(key))
+	assert.NoError(t, throttler.CheckAccount(key))
+}
+
+func TestLoginAttemptKeys_CoverThrottlerKeys(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "John@Example.com", Username: "JohnDoe"}
+
+	keys := models.LoginAttemptKeys(user)
+
+	// Purging the user deletes these rows, so every key the throttler can
+	// store for them must be listed.
+	assert.ElementsMatch(t, []string{
+		services.AccountKey(user.ID),
+		services.UnknownAccountKey(" john@example.com "),
+		services.UnknownAccountKey("johndoe"),
+	}, keys)
+}
+
+func TestLoginThrottler_AccountLockout(t *testing.T) {
+	config := services.DefaultLoginThrottleConfig()
+	config.DelayAfter = 100
+	config.MaxAccountFailures = 3
+	throttler := services.NewLoginThrottler(repositories.NewMemoryLoginAttemptRepository(time.Hour), config)
+	userID := uuid.New()
+	key := services.AccountKey(userID)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, throttler.RecordFailure(key, "192.0.2.1"))
+	}
+
+	err := throttler.CheckAccount(key)
+	assert.ErrorIs(t, err, apperrors.ErrAccountLocked)
+	assert.NoError(t, throttler.CheckAccount(services.AccountKey(uuid.New())), "other accounts are unaffected")
+
+	require.NoError(t, throttler.Unlock(userID))
+	assert.NoError(t, throttler.CheckAccount(key))
+}
+
+func TestLoginThrottler_IPBlock(t *testing.T) {
+	config := services.DefaultLoginThrottleConfig()
+	config.DelayAfter = 100
+	config.MaxIPFailures = 3
+	throttler := services.NewLoginThrottler(repositories.NewMemoryLoginAttemptRepository(time.Hour), config)
+
+	// Spread failures across accounts so only the IP limit is reached.
+	for i := 0; i < 3; i++ {
+		require.NoError(t, throttler.RecordFailure(services.UnknownAccountKey(uuid.NewString()), "192.0.2.1"))
+	}
+
+	err := throttler.CheckIP("192.0.2.1")
+	assert.ErrorIs(t, err, apperrors.ErrTooManyAttempts)
+	assert.NoError(t, throttler.CheckIP("192.0.2.2"))
+}
+
+func TestAuthService_LoginLockout(t *testing.T) {
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	testUser := &models.User{
+		ID:       uuid.New(),
+		Username: "johndoe",
+		Password: hashedPassword,
+	}
+
+	config := services.DefaultLoginThrottleConfig()
+	config.DelayAfter = 100
+	config.MaxAccountFailures = 2
+	throttler := services.NewLoginThrottler(repositories.NewMemoryLoginAttemptRepository(time.Hour), config)
+
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("GetByUsername", "johndoe").Return(testUser, nil)
+	authService := services.NewAuthService(mockRepo, new(mocks.MockRefreshTokenRepository), new(mocks.MockRevokedTokenRepository), newTestSessionRepo(), newTestAuthEventRepo(), newTestRoleService(), throttler, nil, testPasswordHasher, testPasswordPolicy, newTestKeySet(t), services.DefaultTokenConfig())
+
+	for i := 0; i < 2; i++ {
+		_, err := authService.Login(&models.LoginRequest{Username: "johndoe", Password: "wrongpassword"}, services.ClientInfo{IP: "192.0.2.1"})
+		assert.ErrorIs(t, err, apperrors.ErrInvalidCredentials)
+	}
+
+	// Even the correct password is refused while the account is locked.
+	_, err := authService.Login(&models.LoginRequest{Username: "johndoe", Password: "password123"}, services.ClientInfo{IP: "192.0.2.2"})
+	assert.ErrorIs(t, err, apperrors.ErrAccountLocked)
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/mail"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/signing"
+	"mobile-shop-backend/tests/mocks"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var magicLinkTokenPattern = regexp.MustCompile(`/magic-link\?token=(\S+)`)
+
+type magicLinkMocks struct {
+	userRepo      *mocks.MockUserRepository
+	magicLinkRepo *mocks.MockMagicLinkRepository
+	tokenRepo     *mocks.MockRefreshTokenRepository
+	mailer        *mocks.MockMailer
+	keySet        *signing.KeySet
+}
+
+func newMagicLinkMocks(t *testing.T) *magicLinkMocks {
+	m := &magicLinkMocks{
+		userRepo:      new(mocks.MockUserRepository),
+		magicLinkRepo: new(mocks.MockMagicLinkRepository),
+		tokenRepo:     new(mocks.MockRefreshTokenRepository),
+		mailer:        new(mocks.MockMailer),
+		keySet:        newTestKeySet(t),
+	}
+	m.tokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil).Maybe()
+	return m
+}
+
+func (m *magicLinkMocks) service() *services.MagicLinkService {
+	authService := services.NewAuthService(m.userRepo, m.tokenRepo, new(mocks.MockRevokedTokenRepository), newTestSessionRepo(), newTestAuthEventRepo(), newTestRoleService(), newTestLoginThrottler(), nil, testPasswordHasher, testPasswordPolicy, m.keySet, services.DefaultTokenConfig())
+	return services.NewMagicLinkService(m.userRepo, m.magicLinkRepo, authService, m.keySet, m.mailer, 15*time.Minute, "http://localhost:5173")
+}
+
+// requestToken sends a link to user and returns the token from the email.
+func (m *magicLinkMocks) requestToken(t *testing.T, user *models.User) (string, *models.MagicLink) {
+	var sent mail.Message
+	var link *models.MagicLink
+	m.userRepo.On("GetByEmail", user.Email).Return(user, nil)
+	m.magicLinkRepo.On("GetLatestForUser", user.ID).Return(nil, gorm.ErrRecordNotFound)
+	m.magicLinkRepo.On("DeleteForUser", user.ID).Return(nil)
+	m.magicLinkRepo.On("Create", mock.AnythingOfType("*models.MagicLink")).Return(nil).Run(func(args mock.Arguments) {
+		link = args.Get(0).(*models.MagicLink)
+	})
+	m.mailer.On("Send", mock.AnythingOfType("mail.Message")).Return(nil).Run(func(args mock.Arguments) {
+		sent = args.Get(0).(mail.Message)
+	})
+
+	require.NoError(t, m.service().RequestLink(user.Email))
+
+	assert.Equal(t, user.Email, sent.To)
+	match := magicLinkTokenPattern.FindStringSubmatch(sent.Body)
+	require.NotNil(t, match, "email contains the sign-in link")
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return token, link
+}
+
+func TestMagicLinkService_RequestAndConsume(t *testing.T) {
+	verifiedAt := time.Now().Add(-time.Hour)
+	user := &models.User{ID: uuid.New(), Email: "john@example.com", Name: "John Doe", VerifiedAt: &verifiedAt}
+	m := newMagicLinkMocks(t)
+
+	token, link := m.requestToken(t, user)
+	require.NotNil(t, link)
+	assert.Equal(t, user.ID, link.UserID)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), link.ExpiresAt, time.Minute)
+	_, err := m.keySet.Parse(token)
+	assert.Error(t, err, "a sign-in link is not an access token")
+
+	m.magicLinkRepo.On("MarkUsed", link.ID, user.ID, mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+	m.userRepo.On("GetByID", user.ID).Return(user, nil)
+
+	result, err := m.service().ConsumeLink(token, services.ClientInfo{IP: "192.0.2.1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, result.User.ID)
+	assert.NotEmpty(t, result.Tokens.AccessToken)
+	assert.NotEmpty(t, result.Tokens.RefreshToken)
+	m.userRepo.AssertNotCalled(t, "MarkEmailVerified", mock.Anything, mock.Anything)
+
+	// The link has been used.
+	m.magicLinkRepo.On("MarkUsed", link.ID, user.ID, mock.AnythingOfType("time.Time")).Return(false, nil).Once()
+	_, err = m.service().ConsumeLink(token, services.ClientInfo{})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidMagicLink)
+	m.magicLinkRepo.AssertExpectations(t)
+}
+
+func TestMagicLinkService_ConsumeVerifiesEmail(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "john@example.com"}
+	m := newMagicLinkMocks(t)
+	token, link := m.requestToken(t, user)
+
+	m.magicLinkRepo.On("MarkUsed", link.ID, user.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+	m.userRepo.On("GetByID", user.ID).Return(user, nil)
+	m.userRepo.On("MarkEmailVerified", user.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	result, err := m.service().ConsumeLink(token, services.ClientInfo{})
+
+	require.NoError(t, err)
+	assert.NotNil(t, result.User.VerifiedAt)
+	m.userRepo.AssertExpectations(t)
+}
+
+func TestMagicLinkService_RequestLinkIgnoresUnknownEmail(t *testing.T) {
+	m := newMagicLinkMocks(t)
+	m.userRepo.On("GetByEmail", "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+
+	assert.NoError(t, m.service().RequestLink("Nobody@Example.com"))
+	m.mailer.AssertNotCalled(t, "Send", mock.Anything)
+}
+
+func TestMagicLinkService_RequestLinkIsThrottled(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "john@example.com"}
+	m := newMagicLinkMocks(t)
+	m.userRepo.On("GetByEmail", user.Email).Return(user, nil)
+	m.magicLinkRepo.On("GetLatestForUser", user.ID).Return(&models.MagicLink{CreatedAt: time.Now().Add(-10 * time.Second)}, nil)
+
+	assert.NoError(t, m.service().RequestLink(user.Email))
+	m.magicLinkRepo.AssertNotCalled(t, "Create", mock.Anything)
+	m.mailer.AssertNotCalled(t, "Send", mock.Anything)
+}
+
+func TestMagicLinkService_RequestLinkHidesMailFailure(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "john@example.com"}
+	m := newMagicLinkMocks(t)
+	m.userRepo.On("GetByEmail", user.Email).Return(user, nil)
+	m.magicLinkRepo.On("GetLatestForUser", user.ID).Return(nil, gorm.ErrRecordNotFound)
+	m.magicLinkRepo.On("DeleteForUser", user.ID).Return(nil)
+	m.magicLinkRepo.On("Create", mock.AnythingOfType("*models.MagicLink")).Return(nil)
+	m.mailer.On("Send", mock.AnythingOfType("mail.Message")).Return(errors.New("smtp down"))
+
+	assert.NoError(t, m.service().RequestLink(user.Email))
+	m.mailer.AssertExpectations(t)
+}
+
+func TestMagicLinkService_ConsumeRejectsOtherTokens(t *testing.T) {
+	userID := uuid.New()
+	m := newMagicLinkMocks(t)
+
+	accessToken, err := m.keySet.Sign(jwt.MapClaims{
+		"user_id": userID.String(),
+		"typ":     services.TokenTypeAccess,
+		"jti":     uuid.New().String(),
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	})
+	require.NoError(t, err)
+	expired, err := m.keySet.SignFor(signing.PurposeMagicLink, jwt.MapClaims{
+		"user_id": userID.String(),
+		"typ":     services.TokenTypeMagicLink,
+		"jti":     uuid.New().String(),
+		"exp":     time.Now().Add(-time.Minute).Unix(),
+	})
+	require.NoError(t, err)
+
+	for name, token := range map[string]string{"access token": accessToken, "expired link": expired, "garbage": "not-a-token"} {
+		t.Run(name, func(t *testing.T) {
+			_, err := m.service().ConsumeLink(token, services.ClientInfo{})
+			assert.ErrorIs(t, err, apperrors.ErrInvalidMagicLink)
+		})
+	}
+	m.magicLinkRepo.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything, mock.Anything)
+}
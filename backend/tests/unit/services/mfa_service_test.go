@@ -0,0 +1,227 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/repositories"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/totp"
+	"mobile-shop-backend/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMFAService_Enrollment(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "john@example.com"}
+
+	mockRepo := new(mocks.MockUserRepository)
+	mockCodeRepo := new(mocks.MockRecoveryCodeRepository)
+	mfaService := services.NewMFAService(mockRepo, mockCodeRepo, newTestLoginThrottler(), testPasswordHasher, "MobileShop")
+
+	var secret string
+	mockRepo.On("GetByID", user.ID).Return(user, nil)
+	mockRepo.On("UpdateMFA", user.ID, mock.AnythingOfType("string"), (*time.Time)(nil)).Return(nil).Run(func(args mock.Arguments) {
+		secret = args.String(1)
+		user.TOTPSecret = secret
+	}).Once()
+
+	enrollment, err := mfaService.BeginEnrollment(user.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, secret, enrollment.Secret)
+	assert.Contains(t, enrollment.OTPAuthURI, "otpauth://totp/MobileShop:")
+
+	_, err = mfaService.ConfirmEnrollment(user.ID.String(), "000000")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidMFACode)
+
+	code, err := totp.CodeAt(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	mockRepo.On("UpdateMFA", user.ID, secret, mock.AnythingOfType("*time.Time")).Return(nil).Once()
+	mockRepo.On("AdvanceTOTPStep", user.ID, mock.AnythingOfType("int64")).Return(true, nil).Once()
+	mockCodeRepo.On("ReplaceForUser", user.ID, mock.MatchedBy(func(hashes []string) bool {
+		return len(hashes) == 10
+	})).Return(nil)
+
+	recoveryCodes, err := mfaService.ConfirmEnrollment(user.ID.String(), code)
+	require.NoError(t, err)
+	assert.Len(t, recoveryCodes, 10)
+	assert.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$`, recoveryCodes[0])
+
+	mockRepo.AssertExpectations(t)
+	mockCodeRepo.AssertExpectations(t)
+}
+
+func TestMFAService_WrongCodesLockTheAccount(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	enabledAt := time.Now().Add(-time.Hour)
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	user := &models.User{ID: uuid.New(), Password: hashedPassword, TOTPSecret: secret, MFAEnabledAt: &enabledAt}
+
+	newService := func() (*services.MFAService, *mocks.MockUserRepository, *mocks.MockRecoveryCodeRepository) {
+		mockRepo := new(mocks.MockUserRepository)
+		mockCodeRepo := new(mocks.MockRecoveryCodeRepository)
+		throttler := services.NewLoginThrottler(repositories.NewMemoryLoginAttemptRepository(time.Hour), services.LoginThrottleConfig{
+			FailureWindow:      time.Hour,
+			DelayAfter:         100,
+			MaxAccountFailures: 3,
+			MaxIPFailures:      100,
+			LockoutDuration:    time.Hour,
+		})
+		mockRepo.On("GetByID", user.ID).Return(user, nil)
+		mockCodeRepo.On("Use", user.ID, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(false, nil)
+		return services.NewMFAService(mockRepo, mockCodeRepo, throttler, testPasswordHasher, "MobileShop"), mockRepo, mockCodeRepo
+	}
+
+	t.Run("Disable", func(t *testing.T) {
+		mfaService, mockRepo, _ := newService()
+		for i := 0; i < 3; i++ {
+			err := mfaService.Disable(user.ID.String(), "password123", "000000")
+			assert.ErrorIs(t, err, apperrors.ErrInvalidMFACode)
+		}
+
+		code, _ := totp.CodeAt(secret, totp.Step(time.Now()))
+		err := mfaService.Disable(user.ID.String(), "password123", code)
+
+		assert.ErrorIs(t, err, apperrors.ErrAccountLocked)
+		mockRepo.AssertNotCalled(t, "UpdateMFA", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Disable with wrong passwords", func(t *testing.T) {
+		mfaService, _, _ := newService()
+		for i := 0; i < 3; i++ {
+			err := mfaService.Disable(user.ID.String(), "wrong-password", "000000")
+			assert.ErrorIs(t, err, apperrors.ErrInvalidPassword)
+		}
+
+		err := mfaService.Disable(user.ID.String(), "password123", "000000")
+
+		assert.ErrorIs(t, err, apperrors.ErrAccountLocked)
+	})
+
+	t.Run("RegenerateRecoveryCodes", func(t *testing.T) {
+		mfaService, _, mockCodeRepo := newService()
+		for i := 0; i < 3; i++ {
+			_, err := mfaService.RegenerateRecoveryCodes(user.ID.String(), "000000")
+			assert.ErrorIs(t, err, apperrors.ErrInvalidMFACode)
+		}
+
+		code, _ := totp.CodeAt(secret, totp.Step(time.Now()))
+		_, err := mfaService.RegenerateRecoveryCodes(user.ID.String(), code)
+
+		assert.ErrorIs(t, err, apperrors.ErrAccountLocked)
+		mockCodeRepo.AssertNotCalled(t, "ReplaceForUser", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthService_LoginWithMFA(t *testing.T) {
+	secret, _ := totp.GenerateSecret()
+	enabledAt := time.Now().Add(-time.Hour)
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	user := &models.User{
+		ID:           uuid.New(),
+		Username:     "johndoe",
+		Password:     hashedPassword,
+		TOTPSecret:   secret,
+		MFAEnabledAt: &enabledAt,
+	}
+
+	keySet := newTestKeySet(t)
+	newService := func() (*services.AuthService, *mocks.MockUserRepository, *mocks.MockRefreshTokenRepository, *mocks.MockRevokedTokenRepository, *mocks.MockRecoveryCodeRepository) {
+		mockRepo := new(mocks.MockUserRepository)
+		mockTokenRepo := new(mocks.MockRefreshTokenRepository)
+		mockRevokedRepo := new(mocks.MockRevokedTokenRepository)
+		mockCodeRepo := new(mocks.MockRecoveryCodeRepository)
+		mfaService := services.NewMFAService(mockRepo, mockCodeRepo, newTestLoginThrottler(), testPasswordHasher, "MobileShop")
+		authService := services.NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, newTestSessionRepo(), newTestAuthEventRepo(), newTestRoleService(), newTestLoginThrottler(), mfaService, testPasswordHasher, testPasswordPolicy, keySet, services.DefaultTokenConfig())
+		mockRepo.On("GetByUsername", "johndoe").Return(user, nil)
+		mockRepo.On("GetByID", user.ID).Return(user, nil)
+		return authService, mockRepo, mockTokenRepo, mockRevokedRepo, mockCodeRepo
+	}
+
+	t.Run("Password alone only yields an MFA token", func(t *testing.T) {
+		authService, _, _, _, _ := newService()
+
+		result, err := authService.Login(&models.LoginRequest{Username: "johndoe", Password: "password123"}, services.ClientInfo{IP: "192.0.2.1"})
+
+		require.NoError(t, err)
+		assert.Nil(t, result.Tokens)
+		assert.NotEmpty(t, result.MFAToken)
+		_, err = keySet.Parse(result.MFAToken)
+		assert.Error(t, err, "an MFA token is not an access token")
+	})
+
+	t.Run("Valid TOTP code completes the login", func(t *testing.T) {
+		authService, mockRepo, mockTokenRepo, mockRevokedRepo, _ := newService()
+		result, err := authService.Login(&models.LoginRequest{Username: "johndoe", Password: "password123"}, services.ClientInfo{IP: "192.0.2.1"})
+		require.NoError(t, err)
+
+		code, _ := totp.CodeAt(secret, totp.Step(time.Now()))
+		mockRevokedRepo.On("IsRevoked", mock.AnythingOfType("string")).Return(false, nil)
+		mockRepo.On("AdvanceTOTPStep", user.ID, mock.AnythingOfType("int64")).Return(true, nil)
+		mockRevokedRepo.On("Revoke", mock.AnythingOfType("*models.RevokedToken")).Return(nil)
+		mockTokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+
+		loggedIn, tokens, err := authService.CompleteMFALogin(result.MFAToken, code, services.ClientInfo{IP: "192.0.2.1"})
+
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, loggedIn.ID)
+		assert.NotEmpty(t, tokens.AccessToken)
+		mockRevokedRepo.AssertExpectations(t)
+	})
+
+	t.Run("Recovery code completes the login", func(t *testing.T) {
+		authService, _, mockTokenRepo, mockRevokedRepo, mockCodeRepo := newService()
+		result, err := authService.Login(&models.LoginRequest{Username: "johndoe", Password: "password123"}, services.ClientInfo{IP: "192.0.2.1"})
+		require.NoError(t, err)
+
+		mockRevokedRepo.On("IsRevoked", mock.AnythingOfType("string")).Return(false, nil)
+		mockCodeRepo.On("Use", user.ID, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(true, nil)
+		mockRevokedRepo.On("Revoke", mock.AnythingOfType("*models.RevokedToken")).Return(nil)
+		mockTokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+
+		_, tokens, err := authService.CompleteMFALogin(result.MFAToken, "ABCD-EFGH-IJKL", services.ClientInfo{IP: "192.0.2.1"})
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, tokens.AccessToken)
+		mockCodeRepo.AssertExpectations(t)
+	})
+
+	t.Run("Wrong code is rejected", func(t *testing.T) {
+		authService, _, _, mockRevokedRepo, mockCodeRepo := newService()
+		result, err := authService.Login(&models.LoginRequest{Username: "johndoe", Password: "password123"}, services.ClientInfo{IP: "192.0.2.1"})
+		require.NoError(t, err)
+
+		mockRevokedRepo.On("IsRevoked", mock.AnythingOfType("string")).Return(false, nil)
+		mockCodeRepo.On("Use", user.ID, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(false, nil)
+
+		_, _, err = authService.CompleteMFALogin(result.MFAToken, "000000", services.ClientInfo{IP: "192.0.2.1"})
+
+		assert.ErrorIs(t, err, apperrors.ErrInvalidMFALoginCode)
+	})
+
+	t.Run("Already exchanged MFA token is rejected", func(t *testing.T) {
+		authService, _, _, mockRevokedRepo, _ := newService()
+		result, err := authService.Login(&models.LoginRequest{Username: "johndoe", Password: "password123"}, services.ClientInfo{IP: "192.0.2.1"})
+		require.NoError(t, err)
+
+		mockRevokedRepo.On("IsRevoked", mock.AnythingOfType("string")).Return(true, nil)
+
+		_, _, err = authService.CompleteMFALogin(result.MFAToken, "000000", services.ClientInfo{IP: "192.0.2.1"})
+
+		assert.ErrorIs(t, err, apperrors.ErrInvalidMFAToken)
+	})
+
+	t.Run("Malformed MFA token is rejected", func(t *testing.T) {
+		authService, _, _, _, _ := newService()
+
+		_, _, err := authService.CompleteMFALogin("not-a-jwt", "000000", services.ClientInfo{IP: "192.0.2.1"})
+
+		assert.ErrorIs(t, err, apperrors.ErrInvalidMFAToken)
+	})
+}
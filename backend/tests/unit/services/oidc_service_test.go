@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/oidc"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type oidcMocks struct {
+	issuer       *mocks.MockOIDCIssuer
+	userRepo     *mocks.MockUserRepository
+	tokenRepo    *mocks.MockRefreshTokenRepository
+	stateRepo    *mocks.MockOAuthStateRepository
+	identityRepo *mocks.MockIdentityRepository
+	savedState   *models.OAuthState
+}
+
+func newOIDCMocks(t *testing.T) *oidcMocks {
+	issuer := mocks.NewMockOIDCIssuer("client-id", "client-secret")
+	issuer.Subject = "subject-123"
+	issuer.Email = "john@example.com"
+	issuer.EmailVerified = true
+	issuer.Name = "John Doe"
+	t.Cleanup(issuer.Close)
+
+	m := &oidcMocks{
+		issuer:       issuer,
+		userRepo:     new(mocks.MockUserRepository),
+		tokenRepo:    new(mocks.MockRefreshTokenRepository),
+		stateRepo:    new(mocks.MockOAuthStateRepository),
+		identityRepo: new(mocks.MockIdentityRepository),
+	}
+	m.stateRepo.On("DeleteExpired", mock.Anything).Return(nil)
+	m.stateRepo.On("Create", mock.AnythingOfType("*models.OAuthState")).Return(nil).Run(func(args mock.Arguments) {
+		m.savedState = args.Get(0).(*models.OAuthState)
+	})
+	m.tokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil).Maybe()
+	return m
+}
+
+func (m *oidcMocks) service(t *testing.T) *services.OIDCService {
+	provider := oidc.NewProvider(oidc.Config{
+		Name:         "mock",
+		Issuer:       m.issuer.URL(),
+		ClientID:     m.issuer.ClientID,
+		ClientSecret: m.issuer.ClientSecret,
+		RedirectURL:  "http://localhost:5173/auth/callback/mock",
+	}, nil)
+	authService := services.NewAuthService(m.userRepo, m.tokenRepo, new(mocks.MockRevokedTokenRepository), newTestSessionRepo(), newTestAuthEventRepo(), newTestRoleService(), newTestLoginThrottler(), nil, testPasswordHasher, testPasswordPolicy, newTestKeySet(t), services.DefaultTokenConfig())
+	return services.NewOIDCService([]*oidc.Provider{provider}, m.stateRepo, m.identityRepo, m.userRepo, authService)
+}
+
+// login runs the whole flow: redirect, approval at the mock issuer and callback.
+func (m *oidcMocks) login(t *testing.T, service *services.OIDCService) (*services.LoginResult, error) {
+	authURL, err := service.BeginLogin(context.Background(), "mock")
+	require.NoError(t, err)
+
+	code, state, err := m.issuer.Authorize(authURL)
+	require.NoError(t, err)
+	m.stateRepo.On("Consume", mock.AnythingOfType("string")).Return(m.savedState, nil).Once()
+
+	return service.CompleteLogin(context.Background(), "mock", state, code, services.ClientInfo{})
+}
+
+func TestOIDCService_CompleteLogin(t *testing.T) {
+	verifiedAt := time.Now()
+	verifiedUser := &models.User{ID: uuid.New(), Username: "johndoe", Email: "john@example.com", VerifiedAt: &verifiedAt}
+
+	testCases := []struct {
+		name          string
+		setup         func(*oidcMocks)
+		expectedError bool
+		expectedErr   error
+		check         func(*testing.T, *oidcMocks, *services.LoginResult)
+	}{
+		{
+			name: "Signs in an already linked identity",
+			setup: func(m *oidcMocks) {
+				m.identityRepo.On("GetByProviderSubject", "mock", "subject-123").Return(&models.Identity{UserID: verifiedUser.ID}, nil)
+				m.userRepo.On("GetByID", verifiedUser.ID).Return(verifiedUser, nil)
+			},
+			check: func(t *testing.T, m *oidcMocks, result *services.LoginResult) {
+				assert.Equal(t, verifiedUser.ID, result.User.ID)
+				assert.NotNil(t, result.Tokens)
+				m.identityRepo.AssertNotCalled(t, "Create", mock.Anything)
+			},
+		},
+		{
+			name: "Links to an existing account with the same verified email",
+			setup: func(m *oidcMocks) {
+				m.identityRepo.On("GetByProviderSubject", "mock", "subject-123").Return(nil, gorm.ErrRecordNotFound)
+				m.userRepo.On("GetByEmail", "john@example.com").Return(verifiedUser, nil)
+				m.identityRepo.On("Create", mock.MatchedBy(func(identity *models.Identity) bool {
+					return identity.UserID == verifiedUser.ID && identity.Provider == "mock" && identity.Subject == "subject-123"
+				})).Return(nil)
+			},
+			check: func(t *testing.T, m *oidcMocks, result *services.LoginResult) {
+				assert.Equal(t, verifiedUser.ID, result.User.ID)
+				assert.NotNil(t, result.Tokens)
+			},
+		},
+		{
+			name: "Creates a verified account for a new email",
+			setup: func(m *oidcMocks) {
+				m.identityRepo.On("GetByProviderSubject", "mock", "subject-123").Return(nil, gorm.ErrRecordNotFound)
+				m.userRepo.On("GetByEmail", "john@example.com").Return(nil, gorm.ErrRecordNotFound)
+				m.userRepo.On("UsernameExists", "john").Return(false, nil)
+				m.userRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil).Run(func(args mock.Arguments) {
+					args.Get(0).(*models.User).ID = uuid.New()
+				})
+				m.identityRepo.On("Create", mock.AnythingOfType("*models.Identity")).Return(nil)
+			},
+			check: func(t *testing.T, m *oidcMocks, result *services.LoginResult) {
+				assert.Equal(t, "john", result.User.Username)
+				assert.Equal(t, "John Doe", result.User.Name)
+				assert.NotNil(t, result.User.VerifiedAt)
+				assert.NotNil(t, result.Tokens)
+			},
+		},
+		{
+			name: "Shortens a long name without splitting characters",
+			setup: func(m *oidcMocks) {
+				m.issuer.Name = "Ž" + strings.Repeat("ofie Ž", 10)
+				m.identityRepo.On("GetByProviderSubject", "mock", "subject-123").Return(nil, gorm.ErrRecordNotFound)
+				m.userRepo.On("GetByEmail", "john@example.com").Return(nil, gorm.ErrRecordNotFound)
+				m.userRepo.On("UsernameExists", "john").Return(false, nil)
+				m.userRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil).Run(func(args mock.Arguments) {
+					args.Get(0).(*models.User).ID = uuid.New()
+				})
+				m.identityRepo.On("Create", mock.AnythingOfType("*models.Identity")).Return(nil)
+			},
+			check: func(t *testing.T, m *oidcMocks, result *services.LoginResult) {
+				assert.True(t, utf8.ValidString(result.User.Name))
+				assert.LessOrEqual(t, len(result.User.Name), 50)
+				assert.Equal(t, "Ž"+strings.Repeat("ofie Ž", 6)+"ofie", result.User.Name)
+			},
+		},
+		{
+			name: "Refuses to link to an account with an unverified email",
+			setup: func(m *oidcMocks) {
+				m.identityRepo.On("GetByProviderSubject", "mock", "subject-123").Return(nil, gorm.ErrRecordNotFound)
+				m.userRepo.On("GetByEmail", "john@example.com").Return(&models.User{ID: uuid.New(), Email: "john@example.com"}, nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrAccountEmailNotVerified,
+		},
+		{
+			name: "Refuses an email the provider has not verified",
+			setup: func(m *oidcMocks) {
+				m.issuer.EmailVerified = false
+				m.identityRepo.On("GetByProviderSubject", "mock", "subject-123").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrProviderEmailNotVerified,
+		},
+		{
+			name: "Refuses a verified email that is not a valid address",
+			setup: func(m *oidcMocks) {
+				m.issuer.Email = "john.example.com"
+				m.identityRepo.On("GetByProviderSubject", "mock", "subject-123").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrProviderEmailInvalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newOIDCMocks(t)
+			tc.setup(m)
+
+			result, err := m.login(t, m.service(t))
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+				tc.check(t, m, result)
+			}
+
+			m.userRepo.AssertExpectations(t)
+			m.identityRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOIDCService_InvalidState(t *testing.T) {
+	m := newOIDCMocks(t)
+	m.stateRepo.On("Consume", mock.AnythingOfType("string")).Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := m.service(t).CompleteLogin(context.Background(), "mock", "forged-state", "code", services.ClientInfo{})
+
+	assert.ErrorIs(t, err, apperrors.ErrInvalidOAuthState)
+}
+
+func TestOIDCService_UnknownProvider(t *testing.T) {
+	m := newOIDCMocks(t)
+
+	_, err := m.service(t).BeginLogin(context.Background(), "unknown")
+
+	assert.ErrorIs(t, err, apperrors.ErrUnknownProvider)
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/mail"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/internal/utils"
+	"mobile-shop-backend/internal/validators"
+	"mobile-shop-backend/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type passwordResetMocks struct {
+	userRepo         *mocks.MockUserRepository
+	resetRepo        *mocks.MockPasswordResetRepository
+	refreshTokenRepo *mocks.MockRefreshTokenRepository
+	sessionRepo      *mocks.MockSessionRepository
+	mailer           *mocks.MockMailer
+}
+
+func newPasswordResetMocks() *passwordResetMocks {
+	return &passwordResetMocks{
+		userRepo:         new(mocks.MockUserRepository),
+		resetRepo:        new(mocks.MockPasswordResetRepository),
+		refreshTokenRepo: new(mocks.MockRefreshTokenRepository),
+		sessionRepo:      new(mocks.MockSessionRepository),
+		mailer:           new(mocks.MockMailer),
+	}
+}
+
+func (m *passwordResetMocks) service() *services.PasswordResetService {
+	return services.NewPasswordResetService(m.userRepo, m.resetRepo, m.refreshTokenRepo, m.sessionRepo, newTestLoginThrottler(), testPasswordHasher, testPasswordPolicy, m.mailer, "http://shop.test")
+}
+
+func (m *passwordResetMocks) assertExpectations(t *testing.T) {
+	m.userRepo.AssertExpectations(t)
+	m.resetRepo.AssertExpectations(t)
+	m.refreshTokenRepo.AssertExpectations(t)
+	m.sessionRepo.AssertExpectations(t)
+	m.mailer.AssertExpectations(t)
+}
+
+func TestPasswordResetService_RequestReset(t *testing.T) {
+	testUser := &models.User{
+		ID:    uuid.New(),
+		Name:  "John Doe",
+		Email: "john@example.com",
+	}
+
+	testCases := []struct {
+		name          string
+		email         string
+		mockSetup     func(*passwordResetMocks)
+		expectedError bool
+		errorMessage  string
+		expectedErr   error
+	}{
+		{
+			name:  "Sends a reset link",
+			email: "john@example.com",
+			mockSetup: func(m *passwordResetMocks) {
+				m.userRepo.On("GetByEmail", "john@example.com").Return(testUser, nil)
+				m.resetRepo.On("GetLatestForUser", testUser.ID).Return(nil, gorm.ErrRecordNotFound)
+				m.resetRepo.On("DeleteForUser", testUser.ID).Return(nil)
+				m.resetRepo.On("Create", mock.MatchedBy(func(token *models.PasswordResetToken) bool {
+					return token.UserID == testUser.ID && token.TokenHash != "" && token.ExpiresAt.After(time.Now())
+				})).Return(nil)
+				m.mailer.On("Send", mock.MatchedBy(func(msg mail.Message) bool {
+					return msg.To == "john@example.com" && strings.Contains(msg.Body, "http://shop.test/reset-password?token=")
+				})).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:  "Unknown email is silently ignored",
+			email: "nobody@example.com",
+			mockSetup: func(m *passwordResetMocks) {
+				m.userRepo.On("GetByEmail", "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedError: false,
+		},
+		{
+			name:  "A recent link is not resent",
+			email: "john@example.com",
+			mockSetup: func(m *passwordResetMocks) {
+				m.userRepo.On("GetByEmail", "john@example.com").Return(testUser, nil)
+				m.resetRepo.On("GetLatestForUser", testUser.ID).Return(&models.PasswordResetToken{CreatedAt: time.Now().Add(-10 * time.Second)}, nil)
+			},
+			expectedError: false,
+		},
+		{
+			name:  "Mail delivery failure is not reported",
+			email: "john@example.com",
+			mockSetup: func(m *passwordResetMocks) {
+				m.userRepo.On("GetByEmail", "john@example.com").Return(testUser, nil)
+				m.resetRepo.On("GetLatestForUser", testUser.ID).Return(nil, gorm.ErrRecordNotFound)
+				m.resetRepo.On("DeleteForUser", testUser.ID).Return(nil)
+				m.resetRepo.On("Create", mock.AnythingOfType("*models.PasswordResetToken")).Return(nil)
+				m.mailer.On("Send", mock.AnythingOfType("mail.Message")).Return(errors.New("smtp down"))
+			},
+			expectedError: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPasswordResetMocks()
+			tc.mockSetup(m)
+
+			err := m.service().RequestReset(tc.email)
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				if tc.expectedErr != nil {
+					assert.ErrorIs(t, err, tc.expectedErr)
+				} else {
+					assert.Contains(t, err.Error(), tc.errorMessage)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+
+			m.assertExpectations(t)
+		})
+	}
+}
+
+func TestPasswordResetService_ResetPassword(t *testing.T) {
+	userID := uuid.New()
+	rawToken := "reset-token"
+	usedAt := time.Now().Add(-time.Minute)
+
+	newStoredToken := func() *models.PasswordResetToken {
+		return &models.PasswordResetToken{
+			ID:        uuid.New(),
+			UserID:    userID,
+			TokenHash: utils.HashToken(rawToken),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		mockSetup     func(*passwordResetMocks)
+		expectedError bool
+		expectedErr   error
+	}{
+		{
+			name: "Resets the password and revokes sessions",
+			mockSetup: func(m *passwordResetMocks) {
+				stored := newStoredToken()
+				m.resetRepo.On("GetByHash", utils.HashToken(rawToken)).Return(stored, nil)
+				m.userRepo.On("GetByID", userID).Return(&models.User{ID: userID, Username: "johndoe", Email: "john@example.com"}, nil)
+				m.resetRepo.On("MarkUsed", stored.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+				m.userRepo.On("UpdatePassword", userID, mock.MatchedBy(func(hash string) bool {
+					return passwordMatches(hash, "newpassword123")
+				})).Return(nil)
+				m.userRepo.On("IncrementTokenVersion", userID).Return(nil)
+				m.refreshTokenRepo.On("RevokeAllForUser", userID).Return(nil)
+				m.sessionRepo.On("RevokeAllForUser", userID).Return(nil)
+			},
+			expectedError: false,
+		},
+		{
+			name: "Unknown token",
+			mockSetup: func(m *passwordResetMocks) {
+				m.resetRepo.On("GetByHash", utils.HashToken(rawToken)).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrInvalidResetToken,
+		},
+		{
+			name: "Token already used",
+			mockSetup: func(m *passwordResetMocks) {
+				stored := newStoredToken()
+				stored.UsedAt = &usedAt
+				m.resetRepo.On("GetByHash", utils.HashToken(rawToken)).Return(stored, nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrInvalidResetToken,
+		},
+		{
+			name: "Token expired",
+			mockSetup: func(m *passwordResetMocks) {
+				stored := newStoredToken()
+				stored.ExpiresAt = time.Now().Add(-time.Minute)
+				m.resetRepo.On("GetByHash", utils.HashToken(rawToken)).Return(stored, nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrResetTokenExpired,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newPasswordResetMocks()
+			tc.mockSetup(m)
+
+			err := m.service().ResetPassword(rawToken, "newpassword123")
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			m.assertExpectations(t)
+		})
+	}
+}
+
+func TestPasswordResetService_ResetPasswordRejectsWeakPassword(t *testing.T) {
+	userID := uuid.New()
+	stored := &models.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		TokenHash: utils.HashToken("reset-token"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	m := newPasswordResetMocks()
+	m.resetRepo.On("GetByHash", stored.TokenHash).Return(stored, nil)
+	m.userRepo.On("GetByID", userID).Return(&models.User{ID: userID, Username: "johndoe", Email: "john@example.com"}, nil)
+
+	err := m.service().ResetPassword("reset-token", "123")
+
+	assert.ErrorIs(t, err, apperrors.ErrWeakPassword)
+	var appErr *apperrors.Error
+	if assert.ErrorAs(t, err, &appErr) {
+		assert.Equal(t, validators.PasswordRuleMinLength, appErr.Violations[0].Rule)
+	}
+	// The link stays valid so the user can try another password.
+	m.resetRepo.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything)
+	m.assertExpectations(t)
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/cache"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingProductSource answers every call with a page titled after the
+// call number, optionally holding calls until release is closed.
+type countingProductSource struct {
+	calls   atomic.Int32
+	err     error
+	release chan struct{}
+}
+
+func (s *countingProductSource) ListProducts(ctx context.Context, query models.ProductQuery) (*models.ProductPage, error) {
+	call := s.calls.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.ProductPage{
+		Products: []models.Product{{ID: int(call), Title: query.Search}},
+		Total:    1,
+		Limit:    query.Limit,
+	}, nil
+}
+
+func (s *countingProductSource) ListCategories(ctx context.Context) ([]string, error) {
+	s.calls.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []string{"laptops", "smartphones"}, nil
+}
+
+func (s *countingProductSource) GetProduct(ctx context.Context, id int) (*models.ProductDetail, error) {
+	s.calls.Add(1)
+	if id != 1 {
+		return nil, apperrors.ErrProductNotFound
+	}
+	return &models.ProductDetail{Product: models.Product{ID: 1, Title: "iPhone 6"}, RatingBreakdown: models.NewRatingBreakdown()}, nil
+}
+
+// failingStore is a cache store that is down.
+type failingStore struct{}
+
+func (failingStore) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("connection refused")
+}
+
+func (failingStore) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("connection refused")
+}
+
+func newCachedSource(source services.ProductSource, config services.ProductCacheConfig) *services.CachedProductSource {
+	return services.NewCachedProductSource(source, cache.NewMemoryStore(100), config)
+}
+
+func TestCachedProductSource_CachesResponses(t *testing.T) {
+	source := &countingProductSource{}
+	cached := newCachedSource(source, services.DefaultProductCacheConfig())
+	ctx := context.Background()
+	query := models.ProductQuery{Search: "iphone", SortBy: models.ProductSortTitle, Limit: 12}
+
+	first, err := cached.ListProducts(ctx, query)
+	require.NoError(t, err)
+	second, err := cached.ListProducts(ctx, query)
+	require.NoError(t, err)
+	categories, err := cached.ListCategories(ctx)
+	require.NoError(t, err)
+	_, err = cached.ListCategories(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, []string{"laptops", "smartphones"}, categories)
+	assert.Equal(t, int32(2), source.calls.Load())
+	assert.Equal(t, services.ProductCacheStats{Hits: 2, Misses: 2}, cached.Stats())
+}
+
+func TestCachedProductSource_NormalizesKeys(t *testing.T) {
+	source := &countingProductSource{}
+	cached := newCachedSource(source, services.DefaultProductCacheConfig())
+	ctx := context.Background()
+	minPrice := 100.0
+	samePrice := 100.00
+
+	_, err := cached.ListProducts(ctx, models.ProductQuery{Search: "iPhone", PriceMin: &minPrice, Limit: 12})
+	require.NoError(t, err)
+	_, err = cached.ListProducts(ctx, models.ProductQuery{Search: "IPHONE", PriceMin: &samePrice, Limit: 12})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), source.calls.Load())
+
+	for _, query := range []models.ProductQuery{
+		{Search: "iphone", Limit: 12},
+		{Search: "iphone", PriceMin: &minPrice, Limit: 12, Skip: 12},
+		{Search: "iphone", PriceMax: &minPrice, Limit: 12},
+		{Search: "iphone", PriceMin: &minPrice, Limit: 12, SortBy: models.ProductSortPrice},
+	} {
+		_, err := cached.ListProducts(ctx, query)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(5), source.calls.Load(), "different queries are cached separately")
+}
+
+func TestCachedProductSource_CachesProductDetails(t *testing.T) {
+	source := &countingProductSource{}
+	cached := newCachedSource(source, services.DefaultProductCacheConfig())
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		product, err := cached.GetProduct(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "iPhone 6", product.Title)
+		assert.Len(t, product.RatingBreakdown.Stars, 5)
+
+		_, err = cached.GetProduct(ctx, 2)
+		assert.ErrorIs(t, err, apperrors.ErrProductNotFound)
+	}
+
+	assert.Equal(t, int32(3), source.calls.Load(), "only the found product is cached")
+}
+
+func TestCachedProductSource_DoesNotCacheErrors(t *testing.T) {
+	source := &countingProductSource{err: errors.New("upstream down")}
+	cached := newCachedSource(source, services.DefaultProductCacheConfig())
+	ctx := context.Background()
+
+	_, err := cached.ListCategories(ctx)
+	require.Error(t, err)
+
+	source.err = nil
+	categories, err := cached.ListCategories(ctx)
+	require.NoError(t, err)
+	assert.Len(t, categories, 2)
+	assert.Equal(t, int32(2), source.calls.Load())
+}
+
+func TestCachedProductSource_CoalescesConcurrentMisses(t *testing.T) {
+	source := &countingProductSource{release: make(chan struct{})}
+	cached := newCachedSource(source, services.DefaultProductCacheConfig())
+	query := models.ProductQuery{Search: "laptop", Limit: 12}
+
+	var wg sync.WaitGroup
+	pages := make([]*models.ProductPage, 10)
+	for i := range pages {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			page, err := cached.ListProducts(context.Background(), query)
+			assert.NoError(t, err)
+			pages[i] = page
+		}(i)
+	}
+
+	require.Eventually(t, func() bool { return cached.Stats().Misses == 10 }, time.Second, time.Millisecond)
+	close(source.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), source.calls.Load())
+	for _, page := range pages {
+		assert.Equal(t, pages[0], page)
+	}
+	// Every caller gets its own copy.
+	pages[0].Products[0].Title = "changed"
+	assert.Equal(t, "laptop", pages[1].Products[0].Title)
+}
+
+func TestCachedProductSource_ServesStaleWhileRevalidating(t *testing.T) {
+	source := &countingProductSource{}
+	cached := newCachedSource(source, services.ProductCacheConfig{TTL: 20 * time.Millisecond, StaleTTL: time.Minute})
+	ctx := context.Background()
+	query := models.ProductQuery{Limit: 12}
+
+	first, err := cached.ListProducts(ctx, query)
+	require.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+
+	stale, err := cached.ListProducts(ctx, query)
+	require.NoError(t, err)
+	assert.Equal(t, first.Products[0].ID, stale.Products[0].ID, "the stale response is served")
+
+	require.Eventually(t, func() bool {
+		page, err := cached.ListProducts(ctx, query)
+		return err == nil && page.Products[0].ID == 2
+	}, time.Second, 5*time.Millisecond, "the response is refreshed in the background")
+	assert.Equal(t, int64(1), cached.Stats().Misses)
+	assert.GreaterOrEqual(t, cached.Stats().StaleHits, int64(1))
+}
+
+func TestCachedProductSource_ExpiresAfterStaleWindow(t *testing.T) {
+	source := &countingProductSource{}
+	cached := newCachedSource(source, services.ProductCacheConfig{TTL: 10 * time.Millisecond, StaleTTL: 10 * time.Millisecond})
+	ctx := context.Background()
+
+	_, err := cached.ListCategories(ctx)
+	require.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+	_, err = cached.ListCategories(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), source.calls.Load())
+	assert.Equal(t, services.ProductCacheStats{Misses: 2}, cached.Stats())
+}
+
+func TestCachedProductSource_WorksWithoutStore(t *testing.T) {
+	source := &countingProductSource{}
+	cached := services.NewCachedProductSource(source, failingStore{}, services.DefaultProductCacheConfig())
+
+	categories, err := cached.ListCategories(context.Background())
+
+	require.NoError(t, err)
+	assert.Len(t, categories, 2)
+	assert.Equal(t, services.ProductCacheStats{Misses: 1, Errors: 2}, cached.Stats())
+}
+
+func TestCachedProductSource_CallerCancellation(t *testing.T) {
+	source := &countingProductSource{release: make(chan struct{})}
+	cached := newCachedSource(source, services.DefaultProductCacheConfig())
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		require.Eventually(t, func() bool { return source.calls.Load() == 1 }, time.Second, time.Millisecond)
+		cancel()
+	}()
+	_, err := cached.ListCategories(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// The shared call carries on and fills the cache.
+	close(source.release)
+	require.Eventually(t, func() bool {
+		_, err := cached.ListCategories(context.Background())
+		return err == nil && cached.Stats().Hits == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(1), source.calls.Load())
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/database"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestProductService_ListProducts(t *testing.T) {
+	testCases := []struct {
+		name         string
+		sortBy       string
+		expectedSort string
+	}{
+		{name: "Sort by price", sortBy: models.ProductSortPrice, expectedSort: models.ProductSortPrice},
+		{name: "Sort by rating", sortBy: models.ProductSortRating, expectedSort: models.ProductSortRating},
+		{name: "Unknown sort falls back to title", sortBy: "price; DROP TABLE products", expectedSort: models.ProductSortTitle},
+		{name: "No sort", expectedSort: models.ProductSortTitle},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockProductRepository)
+			mockRepo.On("List", models.ProductQuery{Category: "smartphones", SortBy: tc.expectedSort, Limit: 12, Skip: 12}).
+				Return([]models.Product{{ID: 1, Title: "iPhone 6"}}, int64(16), nil)
+
+			page, err := services.NewProductService(mockRepo).ListProducts(context.Background(), models.ProductQuery{Category: "smartphones", SortBy: tc.sortBy, Limit: 12, Skip: 12})
+
+			require.NoError(t, err)
+			assert.Len(t, page.Products, 1)
+			assert.Equal(t, int64(16), page.Total)
+			assert.Equal(t, 12, page.Skip)
+			assert.Equal(t, 12, page.Limit)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProductService_ListProductsReturnsEmptyPage(t *testing.T) {
+	mockRepo := new(mocks.MockProductRepository)
+	mockRepo.On("List", mock.AnythingOfType("models.ProductQuery")).Return([]models.Product(nil), int64(0), nil)
+
+	page, err := services.NewProductService(mockRepo).ListProducts(context.Background(), models.ProductQuery{Search: "nothing", Limit: 12})
+
+	require.NoError(t, err)
+	assert.NotNil(t, page.Products, "rendered as [] rather than null")
+	assert.Empty(t, page.Products)
+}
+
+func TestProductService_SeedCatalog(t *testing.T) {
+	products, err := database.SeedProducts()
+	require.NoError(t, err)
+	require.NotEmpty(t, products)
+	reviews, err := database.SeedProductReviews()
+	require.NoError(t, err)
+	require.NotEmpty(t, reviews)
+
+	t.Run("Empty catalog is seeded", func(t *testing.T) {
+		mockRepo := new(mocks.MockProductRepository)
+		mockRepo.On("Count").Return(int64(0), nil)
+		mockRepo.On("CreateBatch", products, reviews).Return(nil)
+
+		assert.NoError(t, services.NewProductService(mockRepo).SeedCatalog(products, reviews))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Existing catalog is left alone", func(t *testing.T) {
+		mockRepo := new(mocks.MockProductRepository)
+		mockRepo.On("Count").Return(int64(3), nil)
+
+		assert.NoError(t, services.NewProductService(mockRepo).SeedCatalog(products, reviews))
+		mockRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Count fails", func(t *testing.T) {
+		mockRepo := new(mocks.MockProductRepository)
+		mockRepo.On("Count").Return(int64(0), errors.New("connection refused"))
+
+		assert.Error(t, services.NewProductService(mockRepo).SeedCatalog(products, reviews))
+		mockRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+	})
+}
+
+func TestProductService_GetProduct(t *testing.T) {
+	product := &models.Product{ID: 2, Title: "iPhone 6", Category: "smartphones", Images: []string{"1.png", "2.png"}}
+
+	t.Run("Product with reviews", func(t *testing.T) {
+		mockRepo := new(mocks.MockProductRepository)
+		mockRepo.On("GetByID", 2).Return(product, nil)
+		mockRepo.On("RatingCounts", 2).Return(map[int]int64{5: 3, 4: 1, 2: 1}, nil)
+		mockRepo.On("Reviews", 2, models.ProductDetailReviews).Return([]models.ProductReview{{ID: 7, ProductID: 2, Rating: 5}}, nil)
+		mockRepo.On("Related", product, models.ProductDetailRelated).Return([]models.Product{{ID: 3, Category: "smartphones"}}, nil)
+
+		detail, err := services.NewProductService(mockRepo).GetProduct(context.Background(), 2)
+
+		require.NoError(t, err)
+		assert.Equal(t, *product, detail.Product)
+		assert.Equal(t, int64(5), detail.RatingBreakdown.Count)
+		assert.Equal(t, map[int]int64{1: 0, 2: 1, 3: 0, 4: 1, 5: 3}, detail.RatingBreakdown.Stars)
+		assert.Len(t, detail.Reviews, 1)
+		assert.Equal(t, 3, detail.Related[0].ID)
+	})
+
+	t.Run("Product without reviews or related products", func(t *testing.T) {
+		mockRepo := new(mocks.MockProductRepository)
+		mockRepo.On("GetByID", 2).Return(product, nil)
+		mockRepo.On("RatingCounts", 2).Return(map[int]int64{}, nil)
+		mockRepo.On("Reviews", 2, mock.Anything).Return([]models.ProductReview(nil), nil)
+		mockRepo.On("Related", product, mock.Anything).Return([]models.Product(nil), nil)
+
+		detail, err := services.NewProductService(mockRepo).GetProduct(context.Background(), 2)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), detail.RatingBreakdown.Count)
+		assert.Len(t, detail.RatingBreakdown.Stars, 5)
+		assert.NotNil(t, detail.Reviews, "rendered as [] rather than null")
+		assert.NotNil(t, detail.Related, "rendered as [] rather than null")
+	})
+
+	t.Run("Unknown product", func(t *testing.T) {
+		mockRepo := new(mocks.MockProductRepository)
+		mockRepo.On("GetByID", 99).Return(nil, gorm.ErrRecordNotFound)
+
+		_, err := services.NewProductService(mockRepo).GetProduct(context.Background(), 99)
+
+		assert.ErrorIs(t, err, apperrors.ErrProductNotFound)
+	})
+
+	t.Run("Database failure", func(t *testing.T) {
+		mockRepo := new(mocks.MockProductRepository)
+		mockRepo.On("GetByID", 2).Return(nil, errors.New("connection refused"))
+
+		_, err := services.NewProductService(mockRepo).GetProduct(context.Background(), 2)
+
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, apperrors.ErrProductNotFound)
+	})
+}
+
+func TestSeedProductReviews_BelongToSeededProducts(t *testing.T) {
+	products, err := database.SeedProducts()
+	require.NoError(t, err)
+	reviews, err := database.SeedProductReviews()
+	require.NoError(t, err)
+
+	ids := map[int]bool{}
+	for _, product := range products {
+		ids[product.ID] = true
+	}
+	for _, review := range reviews {
+		assert.True(t, ids[review.ProductID], "review %d belongs to an unknown product", review.ID)
+		assert.True(t, review.Rating >= 1 && review.Rating <= 5, "review %d has rating %d", review.ID, review.Rating)
+	}
+}
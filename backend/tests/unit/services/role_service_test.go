@@ -0,0 +1,171 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRoleService_GetUserAccess(t *testing.T) {
+	userID := uuid.New()
+	mockRoleRepo := new(mocks.MockRoleRepository)
+	mockRoleRepo.On("GetUserRoles", userID).Return([]models.Role{
+		{Name: "support", Permissions: []models.Permission{{Name: "users:read"}}},
+		{Name: "admin", Permissions: []models.Permission{{Name: "users:write"}, {Name: "users:read"}}},
+	}, nil)
+
+	roles, permissions, err := services.NewRoleService(mockRoleRepo, new(mocks.MockUserRepository)).GetUserAccess(userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin", "support"}, roles)
+	assert.Equal(t, []string{"users:read", "users:write"}, permissions, "permissions are de-duplicated")
+}
+
+func TestRoleService_SetUserRoles(t *testing.T) {
+	user := &models.User{ID: uuid.New()}
+
+	testCases := []struct {
+		name          string
+		roles         []string
+		mockSetup     func(*mocks.MockRoleRepository, *mocks.MockUserRepository)
+		expectedError bool
+		expectedErr   error
+	}{
+		{
+			name:  "Assigns roles and revokes existing tokens",
+			roles: []string{"admin"},
+			mockSetup: func(mockRoleRepo *mocks.MockRoleRepository, mockRepo *mocks.MockUserRepository) {
+				mockRepo.On("GetByID", user.ID).Return(user, nil)
+				mockRoleRepo.On("GetByNames", []string{"admin"}).Return([]models.Role{{ID: 1, Name: "admin"}}, nil)
+				mockRoleRepo.On("SetUserRoles", user.ID, []uint{1}).Return(nil)
+				mockRepo.On("IncrementTokenVersion", user.ID).Return(nil)
+			},
+		},
+		{
+			name:  "Unknown role",
+			roles: []string{"admin", "wizard"},
+			mockSetup: func(mockRoleRepo *mocks.MockRoleRepository, mockRepo *mocks.MockUserRepository) {
+				mockRepo.On("GetByID", user.ID).Return(user, nil)
+				mockRoleRepo.On("GetByNames", []string{"admin", "wizard"}).Return([]models.Role{{ID: 1, Name: "admin"}}, nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrUnknownRole,
+		},
+		{
+			name:  "User not found",
+			roles: []string{"admin"},
+			mockSetup: func(mockRoleRepo *mocks.MockRoleRepository, mockRepo *mocks.MockUserRepository) {
+				mockRepo.On("GetByID", user.ID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRoleRepo := new(mocks.MockRoleRepository)
+			mockRepo := new(mocks.MockUserRepository)
+			tc.mockSetup(mockRoleRepo, mockRepo)
+
+			roles, err := services.NewRoleService(mockRoleRepo, mockRepo).SetUserRoles(user.ID.String(), tc.roles)
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.roles, roles)
+			}
+
+			mockRoleRepo.AssertExpectations(t)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRoleService_BootstrapAdmin(t *testing.T) {
+	verifiedAt := time.Now()
+	user := &models.User{ID: uuid.New(), Username: "johndoe", Email: "john@example.com", VerifiedAt: &verifiedAt}
+
+	t.Run("Promotes the user when no admin exists", func(t *testing.T) {
+		mockRoleRepo := new(mocks.MockRoleRepository)
+		mockRepo := new(mocks.MockUserRepository)
+		mockRoleRepo.On("CountUsersWithRole", models.RoleAdmin).Return(int64(0), nil)
+		mockRepo.On("GetByUsername", "john@example.com").Return(nil, gorm.ErrRecordNotFound)
+		mockRepo.On("GetByEmail", "john@example.com").Return(user, nil)
+		mockRepo.On("GetByID", user.ID).Return(user, nil)
+		mockRoleRepo.On("GetByNames", []string{models.RoleAdmin}).Return([]models.Role{{ID: 1, Name: models.RoleAdmin}}, nil)
+		mockRoleRepo.On("SetUserRoles", user.ID, []uint{1}).Return(nil)
+		mockRepo.On("IncrementTokenVersion", user.ID).Return(nil)
+
+		err := services.NewRoleService(mockRoleRepo, mockRepo).BootstrapAdmin("john@example.com")
+
+		assert.NoError(t, err)
+		mockRoleRepo.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Refuses an email that has not been verified", func(t *testing.T) {
+		mockRoleRepo := new(mocks.MockRoleRepository)
+		mockRepo := new(mocks.MockUserRepository)
+		mockRoleRepo.On("CountUsersWithRole", models.RoleAdmin).Return(int64(0), nil)
+		mockRepo.On("GetByUsername", "john@example.com").Return(nil, gorm.ErrRecordNotFound)
+		mockRepo.On("GetByEmail", "john@example.com").Return(&models.User{ID: uuid.New(), Email: "john@example.com"}, nil)
+
+		err := services.NewRoleService(mockRoleRepo, mockRepo).BootstrapAdmin("john@example.com")
+
+		assert.Error(t, err)
+		mockRoleRepo.AssertNotCalled(t, "SetUserRoles", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Does nothing once an admin exists", func(t *testing.T) {
+		mockRoleRepo := new(mocks.MockRoleRepository)
+		mockRepo := new(mocks.MockUserRepository)
+		mockRoleRepo.On("CountUsersWithRole", models.RoleAdmin).Return(int64(1), nil)
+
+		err := services.NewRoleService(mockRoleRepo, mockRepo).BootstrapAdmin("johndoe")
+
+		assert.NoError(t, err)
+		mockRoleRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "GetByUsername", mock.Anything)
+	})
+}
+
+func TestAuthService_TokenCarriesRoles(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Username: "johndoe"}
+	mockRoleRepo := new(mocks.MockRoleRepository)
+	mockRoleRepo.On("GetUserRoles", user.ID).Return([]models.Role{
+		{Name: "admin", Permissions: []models.Permission{{Name: "users:read"}}},
+	}, nil)
+	mockRepo := new(mocks.MockUserRepository)
+	mockRepo.On("EmailExists", mock.Anything).Return(false, nil)
+	mockRepo.On("UsernameExists", mock.Anything).Return(false, nil)
+	mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.User).ID = user.ID
+	})
+	mockTokenRepo := new(mocks.MockRefreshTokenRepository)
+	mockTokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+
+	keySet := newTestKeySet(t)
+	roleService := services.NewRoleService(mockRoleRepo, mockRepo)
+	authService := services.NewAuthService(mockRepo, mockTokenRepo, new(mocks.MockRevokedTokenRepository), newTestSessionRepo(), newTestAuthEventRepo(), roleService, newTestLoginThrottler(), nil, testPasswordHasher, testPasswordPolicy, keySet, services.DefaultTokenConfig())
+
+	_, tokens, err := authService.Register(&models.RegisterRequest{Name: "John", Username: "johndoe", Email: "john@example.com", Password: "password123"}, services.ClientInfo{})
+	require.NoError(t, err)
+
+	claims, err := keySet.Parse(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"admin"}, claims["roles"])
+	assert.Equal(t, []interface{}{"users:read"}, claims["perms"])
+}
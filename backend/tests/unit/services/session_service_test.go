@@ -0,0 +1,90 @@
+package services
+
+import (
+	"testing"
+
+	"mobile-shop-backend/internal/apperrors"
+	"mobile-shop-backend/internal/models"
+	"mobile-shop-backend/internal/services"
+	"mobile-shop-backend/tests/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionService_ListSessions(t *testing.T) {
+	userID := uuid.New()
+	current := models.Session{ID: uuid.New(), UserID: userID, UserAgent: "Firefox"}
+	other := models.Session{ID: uuid.New(), UserID: userID, UserAgent: "Safari"}
+
+	mockSessionRepo := new(mocks.MockSessionRepository)
+	mockSessionRepo.On("ListActiveForUser", userID, mock.AnythingOfType("time.Time")).Return([]models.Session{other, current}, nil)
+
+	sessions, err := services.NewSessionService(mockSessionRepo, new(mocks.MockRefreshTokenRepository)).ListSessions(userID.String(), current.ID.String())
+
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.False(t, sessions[0].Current)
+	assert.True(t, sessions[1].Current)
+	assert.Equal(t, "Firefox", sessions[1].UserAgent)
+}
+
+func TestSessionService_RevokeSession(t *testing.T) {
+	userID := uuid.New()
+	sessionID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		sessionID     string
+		mockSetup     func(*mocks.MockSessionRepository, *mocks.MockRefreshTokenRepository)
+		expectedError bool
+		expectedErr   error
+	}{
+		{
+			name:      "Revokes the session and its refresh tokens",
+			sessionID: sessionID.String(),
+			mockSetup: func(mockSessionRepo *mocks.MockSessionRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
+				mockSessionRepo.On("Revoke", sessionID, userID).Return(true, nil)
+				mockTokenRepo.On("RevokeFamily", sessionID).Return(nil)
+			},
+		},
+		{
+			name:      "Session of another user or already revoked",
+			sessionID: sessionID.String(),
+			mockSetup: func(mockSessionRepo *mocks.MockSessionRepository, mockTokenRepo *mocks.MockRefreshTokenRepository) {
+				mockSessionRepo.On("Revoke", sessionID, userID).Return(false, nil)
+			},
+			expectedError: true,
+			expectedErr:   apperrors.ErrSessionNotFound,
+		},
+		{
+			name:          "Invalid session ID",
+			sessionID:     "not-a-uuid",
+			mockSetup:     func(*mocks.MockSessionRepository, *mocks.MockRefreshTokenRepository) {},
+			expectedError: true,
+			expectedErr:   apperrors.ErrInvalidSessionID,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSessionRepo := new(mocks.MockSessionRepository)
+			mockTokenRepo := new(mocks.MockRefreshTokenRepository)
+			tc.mockSetup(mockSessionRepo, mockTokenRepo)
+
+			err := services.NewSessionService(mockSessionRepo, mockTokenRepo).RevokeSession(userID.String(), tc.sessionID)
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockSessionRepo.AssertExpectations(t)
+			mockTokenRepo.AssertExpectations(t)
+		})
+	}
+}
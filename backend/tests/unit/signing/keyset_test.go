@@ -0,0 +1,230 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/signing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRSAKey(t *testing.T, bits int) []byte {
+	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func newEd25519Key(t *testing.T) *signing.Key {
+	key, err := signing.GenerateEd25519Key()
+	require.NoError(t, err)
+	return key
+}
+
+func newKeySet(t *testing.T, signingKey *signing.Key, verificationKeys ...*signing.Key) *signing.KeySet {
+	keySet, err := signing.NewKeySet("mobile-shop", "mobile-shop-api", signingKey, verificationKeys...)
+	require.NoError(t, err)
+	return keySet
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"user_id": "123",
+		"exp":     time.Now().Add(time.Minute).Unix(),
+		"iat":     time.Now().Unix(),
+	}
+}
+
+func TestKeySet_SignAndParse(t *testing.T) {
+	rsaKey, err := signing.ParseKeyPEM(newRSAKey(t, 2048))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name        string
+		key         *signing.Key
+		expectedAlg string
+	}{
+		{name: "RS256", key: rsaKey, expectedAlg: "RS256"},
+		{name: "EdDSA", key: newEd25519Key(t), expectedAlg: "EdDSA"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keySet := newKeySet(t, tc.key)
+
+			tokenString, err := keySet.Sign(validClaims())
+			require.NoError(t, err)
+
+			token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedAlg, token.Header["alg"])
+			assert.Equal(t, tc.key.ID, token.Header["kid"])
+
+			claims, err := keySet.Parse(tokenString)
+			require.NoError(t, err)
+			assert.Equal(t, "123", claims["user_id"])
+			assert.Equal(t, "mobile-shop", claims["iss"])
+			assert.Equal(t, "mobile-shop-api", claims["aud"])
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey := newEd25519Key(t)
+	newKey := newEd25519Key(t)
+
+	oldToken, err := newKeySet(t, oldKey).Sign(validClaims())
+	require.NoError(t, err)
+
+	// After rotation the old key is still accepted for verification.
+	rotated := newKeySet(t, newKey, oldKey)
+	_, err = rotated.Parse(oldToken)
+	assert.NoError(t, err)
+
+	// Once it is retired, its tokens are rejected.
+	_, err = newKeySet(t, newKey).Parse(oldToken)
+	assert.Error(t, err)
+}
+
+func TestKeySet_RejectsInvalidTokens(t *testing.T) {
+	key := newEd25519Key(t)
+	keySet := newKeySet(t, key)
+
+	testCases := []struct {
+		name  string
+		token func() string
+	}{
+		{
+			name: "HS256 token signed with the public key",
+			token: func() string {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+					"iss": "mobile-shop", "aud": "mobile-shop-api", "exp": time.Now().Add(time.Minute).Unix(),
+				})
+				token.Header["kid"] = key.ID
+				s, _ := token.SignedString([]byte(key.Public.(ed25519.PublicKey)))
+				return s
+			},
+		},
+		{
+			name: "Wrong audience",
+			token: func() string {
+				other, _ := signing.NewKeySet("mobile-shop", "another-api", key)
+				s, _ := other.Sign(validClaims())
+				return s
+			},
+		},
+		{
+			name: "Wrong issuer",
+			token: func() string {
+				other, _ := signing.NewKeySet("someone-else", "mobile-shop-api", key)
+				s, _ := other.Sign(validClaims())
+				return s
+			},
+		},
+		{
+			name: "Unknown key id",
+			token: func() string {
+				s, _ := newKeySet(t, newEd25519Key(t)).Sign(validClaims())
+				return s
+			},
+		},
+		{
+			name: "Missing expiry",
+			token: func() string {
+				s, _ := keySet.Sign(jwt.MapClaims{"user_id": "123"})
+				return s
+			},
+		},
+		{
+			name: "Expired",
+			token: func() string {
+				claims := validClaims()
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+				s, _ := keySet.Sign(claims)
+				return s
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := keySet.Parse(tc.token())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestKeySet_PurposeTokensAreNotAccessTokens(t *testing.T) {
+	keySet := newKeySet(t, newEd25519Key(t))
+
+	for _, purpose := range []string{signing.PurposeMFA, signing.PurposeMagicLink} {
+		t.Run(purpose, func(t *testing.T) {
+			tokenString, err := keySet.SignFor(purpose, validClaims())
+			require.NoError(t, err)
+
+			claims, err := keySet.ParseFor(purpose, tokenString)
+			require.NoError(t, err)
+			assert.Equal(t, "mobile-shop-api:"+purpose, claims["aud"])
+
+			// Verifiers of access tokens, including ones using the JWKS,
+			// check the access audience.
+			_, err = keySet.Parse(tokenString)
+			assert.Error(t, err)
+
+			accessToken, err := keySet.Sign(validClaims())
+			require.NoError(t, err)
+			_, err = keySet.ParseFor(purpose, accessToken)
+			assert.Error(t, err)
+		})
+	}
+
+	mfaToken, err := keySet.SignFor(signing.PurposeMFA, validClaims())
+	require.NoError(t, err)
+	_, err = keySet.ParseFor(signing.PurposeMagicLink, mfaToken)
+	assert.Error(t, err)
+}
+
+func TestKeySet_JWKS(t *testing.T) {
+	rsaKey, err := signing.ParseKeyPEM(newRSAKey(t, 2048))
+	require.NoError(t, err)
+	edKey := newEd25519Key(t)
+
+	jwks := newKeySet(t, edKey, rsaKey).JWKS()
+
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, edKey.ID, jwks.Keys[0].Kid, "active key is listed first")
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "Ed25519", jwks.Keys[0].Crv)
+	assert.Equal(t, rsaKey.ID, jwks.Keys[1].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+	assert.Equal(t, "RS256", jwks.Keys[1].Alg)
+	assert.NotEmpty(t, jwks.Keys[1].N)
+}
+
+func TestParseKeyPEM(t *testing.T) {
+	_, err := signing.ParseKeyPEM(newRSAKey(t, 1024))
+	assert.Error(t, err, "short RSA keys are rejected")
+
+	_, err = signing.ParseKeyPEM([]byte("not a key"))
+	assert.Error(t, err)
+
+	privatePEM := newRSAKey(t, 2048)
+	privateKey, err := signing.ParseKeyPEM(privatePEM)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(privateKey.Public)
+	require.NoError(t, err)
+	publicKey, err := signing.ParseKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	require.NoError(t, err)
+	assert.Nil(t, publicKey.Private)
+	assert.Equal(t, privateKey.ID, publicKey.ID, "key ID is derived from the public key")
+}
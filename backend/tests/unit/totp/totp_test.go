@@ -0,0 +1,69 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"mobile-shop-backend/internal/totp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Base32 of the ASCII secret "12345678901234567890" from RFC 6238 appendix B.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeAt_RFC6238Vectors(t *testing.T) {
+	testCases := []struct {
+		unix     int64
+		expected string
+	}{
+		{unix: 59, expected: "287082"},
+		{unix: 1111111109, expected: "081804"},
+		{unix: 1111111111, expected: "050471"},
+		{unix: 1234567890, expected: "005924"},
+		{unix: 2000000000, expected: "279037"},
+	}
+
+	for _, tc := range testCases {
+		code, err := totp.CodeAt(rfcSecret, totp.Step(time.Unix(tc.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, code, "time %d", tc.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current, _ := totp.CodeAt(rfcSecret, totp.Step(now))
+	previous, _ := totp.CodeAt(rfcSecret, totp.Step(now)-1)
+	stale, _ := totp.CodeAt(rfcSecret, totp.Step(now)-3)
+
+	step, ok := totp.Validate(rfcSecret, current, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, totp.Step(now), step)
+
+	step, ok = totp.Validate(rfcSecret, previous, now, 1)
+	assert.True(t, ok, "codes within the skew are accepted")
+	assert.Equal(t, totp.Step(now)-1, step)
+
+	_, ok = totp.Validate(rfcSecret, stale, now, 1)
+	assert.False(t, ok)
+
+	_, ok = totp.Validate(rfcSecret, "12345", now, 1)
+	assert.False(t, ok)
+}
+
+func TestGenerateSecretAndURI(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	_, err = totp.CodeAt(secret, 1)
+	assert.NoError(t, err)
+
+	uri := totp.URI("MobileShop", "john@example.com", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/MobileShop:john@example.com?"))
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=MobileShop")
+}
@@ -0,0 +1,156 @@
+package validators
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mobile-shop-backend/internal/validators"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rules(t *testing.T, policy validators.PasswordPolicy, password string, personalInfo ...string) []string {
+	t.Helper()
+	violations, err := policy.Check(password, personalInfo...)
+	require.NoError(t, err)
+
+	var broken []string
+	for _, v := range violations {
+		broken = append(broken, v.Rule)
+	}
+	return broken
+}
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	personalInfo := []string{"John Doe", "johndoe", "john.doe@example.com"}
+
+	testCases := []struct {
+		name          string
+		policy        validators.PasswordPolicy
+		password      string
+		expectedRules []string
+	}{
+		{
+			name:     "Strong password passes the default policy",
+			policy:   validators.DefaultPasswordPolicy(),
+			password: "kT9#mQ2!vL",
+		},
+		{
+			name:          "Too short",
+			policy:        validators.PasswordPolicy{MinLength: 8},
+			password:      "kT9#mQ2",
+			expectedRules: []string{validators.PasswordRuleMinLength},
+		},
+		{
+			name:          "Missing required classes",
+			policy:        validators.PasswordPolicy{RequiredClasses: []validators.CharClass{validators.CharClassUpper, validators.CharClassDigit, validators.CharClassSymbol}},
+			password:      "lowercaseonly",
+			expectedRules: []string{validators.PasswordRuleUppercase, validators.PasswordRuleDigit, validators.PasswordRuleSymbol},
+		},
+		{
+			name:          "Contains the username",
+			policy:        validators.PasswordPolicy{DisallowPersonalInfo: true},
+			password:      "xJohnDoe!42q",
+			expectedRules: []string{validators.PasswordRulePersonalInfo},
+		},
+		{
+			name:          "Contains the email local part",
+			policy:        validators.PasswordPolicy{DisallowPersonalInfo: true},
+			password:      "john.doe#2024",
+			expectedRules: []string{validators.PasswordRulePersonalInfo},
+		},
+		{
+			name:          "Common password",
+			policy:        validators.PasswordPolicy{MinStrength: 2},
+			password:      "P@ssw0rd",
+			expectedRules: []string{validators.PasswordRuleStrength},
+		},
+		{
+			name:          "Every broken rule is reported",
+			policy:        validators.DefaultPasswordPolicy(),
+			password:      "johndoe",
+			expectedRules: []string{validators.PasswordRuleMinLength, validators.PasswordRulePersonalInfo, validators.PasswordRuleStrength},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedRules, rules(t, tc.policy, tc.password, personalInfo...))
+		})
+	}
+}
+
+func TestPasswordStrength(t *testing.T) {
+	weak := []string{"password", "P@ssw0rd", "123456789", "qwertyuiop", "aaaaaaaaaaaa", "abcdefgh", "letmein123"}
+	for _, password := range weak {
+		assert.Equal(t, 0, validators.PasswordStrength(password), password)
+	}
+
+	assert.Less(t, validators.PasswordStrength("johndoe1990", "johndoe"), validators.PasswordStrength("johndoe1990"),
+		"the user's own details are easy guesses")
+	assert.Equal(t, 4, validators.PasswordStrength("correct horse battery staple"))
+	assert.Equal(t, 4, validators.PasswordStrength("kT9#mQ2!vL"))
+}
+
+func writeCorpus(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600))
+	return path
+}
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func TestBreachCorpus(t *testing.T) {
+	path := writeCorpus(t,
+		"# sample corpus",
+		sha1Hex("kT9#mQ2!vL")+":42",
+		strings.ToLower(sha1Hex("hunter2")),
+		"",
+	)
+
+	corpus, err := validators.LoadBreachCorpus(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, corpus.Len())
+
+	hash := sha1Hex("kT9#mQ2!vL")
+	suffixes, err := corpus.Range(hash[:5])
+	require.NoError(t, err)
+	assert.Equal(t, 42, suffixes[hash[5:]])
+
+	for password, expected := range map[string]bool{"kT9#mQ2!vL": true, "hunter2": true, "v8$Lq2#pZx": false} {
+		breached, err := validators.IsBreached(corpus, password)
+		require.NoError(t, err)
+		assert.Equal(t, expected, breached, password)
+	}
+
+	policy := validators.PasswordPolicy{Breached: corpus}
+	assert.Equal(t, []string{validators.PasswordRuleBreached}, rules(t, policy, "kT9#mQ2!vL"))
+}
+
+func TestLoadBreachCorpus_Invalid(t *testing.T) {
+	for _, line := range []string{"not-a-hash", sha1Hex("x") + ":many", sha1Hex("x")[:39]} {
+		_, err := validators.LoadBreachCorpus(writeCorpus(t, line))
+		assert.Error(t, err, line)
+	}
+
+	_, err := validators.LoadBreachCorpus(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestParseCharClass(t *testing.T) {
+	class, err := validators.ParseCharClass(" Upper ")
+	require.NoError(t, err)
+	assert.Equal(t, validators.CharClassUpper, class)
+
+	_, err = validators.ParseCharClass("emoji")
+	assert.Error(t, err)
+}
@@ -1,11 +1,13 @@
 package validators
 
 import (
+	"strings"
 	"testing"
 
 	"mobile-shop-backend/internal/models"
 	"mobile-shop-backend/internal/validators"
 
+	"github.com/gin-gonic/gin/binding"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -70,6 +72,28 @@ func TestValidateRegistration(t *testing.T) {
 			expectedError: true,
 			errorMessage:  "invalid email",
 		},
+		{
+			name: "Email with display name",
+			input: models.RegisterRequest{
+				Name:     "John Doe",
+				Username: "johndoe",
+				Email:    "John <john@example.com>",
+				Password: "password123",
+			},
+			expectedError: true,
+			errorMessage:  "invalid email",
+		},
+		{
+			name: "Email without dotted domain",
+			input: models.RegisterRequest{
+				Name:     "John Doe",
+				Username: "johndoe",
+				Email:    "john@localhost",
+				Password: "password123",
+			},
+			expectedError: true,
+			errorMessage:  "invalid email",
+		},
 		{
 			name: "Missing password",
 			input: models.RegisterRequest{
@@ -82,15 +106,15 @@ func TestValidateRegistration(t *testing.T) {
 			errorMessage:  "password is required",
 		},
 		{
-			name: "Password too short",
+			name: "Password too long",
 			input: models.RegisterRequest{
 				Name:     "John Doe",
 				Username: "johndoe",
 				Email:    "john@example.com",
-				Password: "123",
+				Password: strings.Repeat("a", 101),
 			},
 			expectedError: true,
-			errorMessage:  "password must be at least 6 characters long",
+			errorMessage:  "password must be no more than 100 characters long",
 		},
 		{
 			name: "Username too short",
@@ -156,6 +180,14 @@ func TestValidateLogin(t *testing.T) {
 			},
 			expectedError: false,
 		},
+		{
+			name: "Valid login with email identifier",
+			input: models.LoginRequest{
+				Identifier: "John@Example.com",
+				Password:   "password123",
+			},
+			expectedError: false,
+		},
 		{
 			name: "Missing username",
 			input: models.LoginRequest{
@@ -163,7 +195,7 @@ func TestValidateLogin(t *testing.T) {
 				Password: "password123",
 			},
 			expectedError: true,
-			errorMessage:  "username is required",
+			errorMessage:  "username or email is required",
 		},
 		{
 			name: "Missing password",
@@ -181,16 +213,17 @@ func TestValidateLogin(t *testing.T) {
 				Password: "password123",
 			},
 			expectedError: true,
-			errorMessage:  "username must be at least 3 characters long",
+			errorMessage:  "username or email must be at least 3 characters long",
 		},
 		{
-			name: "Password too short",
+			// The password policy's minimum length may be lower, so short
+			// passwords are left to the credential check.
+			name: "Short password",
 			input: models.LoginRequest{
 				Username: "johndoe",
 				Password: "123",
 			},
-			expectedError: true,
-			errorMessage:  "password must be at least 6 characters long",
+			expectedError: false,
 		},
 	}
 
@@ -207,3 +240,125 @@ func TestValidateLogin(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateUpdateProfile(t *testing.T) {
+	name := "Jane Doe"
+	badUsername := "jane doe"
+	badEmail := "jane@"
+
+	testCases := []struct {
+		name          string
+		input         models.UpdateProfileRequest
+		expectedError bool
+		errorMessage  string
+	}{
+		{
+			name:          "Valid name change",
+			input:         models.UpdateProfileRequest{Name: &name},
+			expectedError: false,
+		},
+		{
+			name:          "Nothing to update",
+			input:         models.UpdateProfileRequest{},
+			expectedError: true,
+			errorMessage:  "nothing to update",
+		},
+		{
+			name:          "Invalid username",
+			input:         models.UpdateProfileRequest{Username: &badUsername},
+			expectedError: true,
+			errorMessage:  "username can only contain letters, numbers, and underscores",
+		},
+		{
+			name:          "Invalid email",
+			input:         models.UpdateProfileRequest{Email: &badEmail},
+			expectedError: true,
+			errorMessage:  "invalid email",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validators.ValidateUpdateProfileRequest(&tc.input)
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorMessage)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateChangePassword(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         models.ChangePasswordRequest
+		expectedError bool
+		errorMessage  string
+	}{
+		{
+			name:          "Valid change",
+			input:         models.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "newpassword456"},
+			expectedError: false,
+		},
+		{
+			name:          "Missing current password",
+			input:         models.ChangePasswordRequest{NewPassword: "newpassword456"},
+			expectedError: true,
+			errorMessage:  "current password is required",
+		},
+		{
+			name:          "New password too long",
+			input:         models.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: strings.Repeat("a", 101)},
+			expectedError: true,
+			errorMessage:  "password must be no more than 100 characters long",
+		},
+		{
+			name:          "Same password",
+			input:         models.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "password123"},
+			expectedError: true,
+			errorMessage:  "new password must be different from the current password",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validators.ValidateChangePasswordRequest(&tc.input)
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorMessage)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// The password policy is the only source of a minimum length, so request
+// binding must accept short passwords and leave them to it.
+func TestPasswordBindingLeavesLengthToPolicy(t *testing.T) {
+	testCases := []struct {
+		name string
+		body string
+		dest interface{}
+	}{
+		{name: "Register", body: `{"name":"John Doe","username":"johndoe","email":"john@example.com","password":"abc"}`, dest: &models.RegisterRequest{}},
+		{name: "Login", body: `{"username":"johndoe","password":"abc"}`, dest: &models.LoginRequest{}},
+		{name: "Change password", body: `{"current_password":"password123","new_password":"abc"}`, dest: &models.ChangePasswordRequest{}},
+		{name: "Reset password", body: `{"token":"token","password":"abc"}`, dest: &models.ResetPasswordRequest{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.NoError(t, binding.JSON.BindBody([]byte(tc.body), tc.dest))
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "john@example.com", validators.NormalizeEmail("  John@Example.COM "))
+	assert.Equal(t, "johndoe", validators.NormalizeUsername("JohnDoe"))
+}
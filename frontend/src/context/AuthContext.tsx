@@ -1,7 +1,7 @@
 import React, { createContext, useContext, useState, useEffect } from 'react';
 import type { ReactNode } from 'react';
 import type { User, LoginRequest, RegisterRequest } from '../types';
-import { authService } from '../services/api';
+import { authService, clearSession, storeSession } from '../services/api';
 
 interface AuthContextType {
   user: User | null;
@@ -43,8 +43,7 @@ export const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
           await authService.getProfile();
         } catch (error) {
           console.error('Failed to verify token:', error);
-          localStorage.removeItem('token');
-          localStorage.removeItem('user');
+          clearSession();
         }
       }
       setIsLoading(false);
@@ -58,8 +57,7 @@ export const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
       setIsLoading(true);
       const response = await authService.login(credentials);
       
-      localStorage.setItem('token', response.token);
-      localStorage.setItem('user', JSON.stringify(response.user));
+      storeSession(response);
       setUser(response.user);
     } catch (error) {
       throw error;
@@ -73,8 +71,7 @@ export const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
       setIsLoading(true);
       const response = await authService.register(userData);
       
-      localStorage.setItem('token', response.token);
-      localStorage.setItem('user', JSON.stringify(response.user));
+      storeSession(response);
       setUser(response.user);
     } catch (error) {
       throw error;
@@ -90,8 +87,7 @@ export const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
       console.error('Logout error:', error);
     } finally {
       setUser(null);
-      localStorage.removeItem('token');
-      localStorage.removeItem('user');
+      clearSession();
     }
   };
 
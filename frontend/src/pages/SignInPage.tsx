@@ -30,12 +30,12 @@ export const SignInPage = () => {
   const form = useForm<LoginRequest>({
     mode: 'uncontrolled',
     initialValues: {
-      username: '',
+      identifier: '',
       password: '',
     },
     validate: {
-      username: (value) => (value.length < 1 ? 'Username is required' : null),
-      password: (value) => (value.length < 6 ? 'Password must be at least 6 characters' : null),
+      identifier: (value) => (value.length < 1 ? 'Username or email is required' : null),
+      password: (value) => (value.length < 1 ? 'Password is required' : null),
     },
   });
 
@@ -77,11 +77,11 @@ export const SignInPage = () => {
                 )}
 
                 <TextInput
-                  label="Username"
-                  placeholder="Your username"
+                  label="Username or email"
+                  placeholder="Your username or email"
                   required
-                  key={form.key('username')}
-                  {...form.getInputProps('username')}
+                  key={form.key('identifier')}
+                  {...form.getInputProps('identifier')}
                 />
 
                 <PasswordInput
@@ -16,7 +16,7 @@ import { useForm } from '@mantine/form';
 import { IconInfoCircle } from '@tabler/icons-react';
 import { Link, useNavigate } from 'react-router-dom';
 import { useAuth } from '../context/AuthContext';
-import type { RegisterRequest } from '../types';
+import type { RegisterRequest, Violation } from '../types';
 
 // Enhanced form interface with password confirmation
 interface SignUpFormData extends RegisterRequest {
@@ -42,7 +42,8 @@ export const SignUpPage = () => {
       name: (value) => (value.length < 2 ? 'Name must have at least 2 letters' : null),
       username: (value) => (value.length < 3 ? 'Username must be at least 3 characters' : null),
       email: (value) => (/^\S+@\S+$/.test(value) ? null : 'Invalid email'),
-      password: (value) => (value.length < 6 ? 'Password must be at least 6 characters' : null),
+      // The server's password policy reports what a password is missing.
+      password: (value) => (value.length < 1 ? 'Password is required' : null),
       confirmPassword: (value, values) => (value !== values.password ? 'Passwords do not match' : null),
     },
   });
@@ -63,6 +64,10 @@ export const SignUpPage = () => {
       await register(sanitizedData);
       navigate('/');
     } catch (err: any) {
+      if (err.code === 'WEAK_PASSWORD' && err.violations?.length) {
+        form.setFieldError('password', err.violations.map((v: Violation) => v.message).join('. '));
+        return;
+      }
       const errorMessage = err.response?.data?.error || err.message || 'Registration failed';
       setError(errorMessage);
     } finally {
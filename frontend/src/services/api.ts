@@ -1,4 +1,4 @@
-import axios from 'axios';
+import axios, { type InternalAxiosRequestConfig } from 'axios';
 import type { LoginRequest, RegisterRequest, AuthResponse, User, ProductsResponse, ProductFilters } from '../types';
 
 const API_BASE_URL = import.meta.env.VITE_API_BASE_URL || 'http://localhost:8080/api';
@@ -19,12 +19,64 @@ api.interceptors.request.use((config) => {
   return config;
 });
 
+// Stores the tokens and user of a login, registration or refresh.
+export const storeSession = (session: AuthResponse) => {
+  localStorage.setItem('token', session.token);
+  localStorage.setItem('refreshToken', session.refresh_token);
+  localStorage.setItem('user', JSON.stringify(session.user));
+};
+
+export const clearSession = () => {
+  localStorage.removeItem('token');
+  localStorage.removeItem('refreshToken');
+  localStorage.removeItem('user');
+};
+
+// Requests whose 401 means bad credentials rather than an expired token.
+const NO_REFRESH_PATHS = ['/login', '/login/mfa', '/register', '/token/refresh'];
+
+// The refresh in flight, shared by every request that failed meanwhile:
+// refresh tokens rotate, so a second refresh with the same token would fail.
+let refreshRequest: Promise<string> | null = null;
+
+const refreshAccessToken = (): Promise<string> => {
+  if (!refreshRequest) {
+    const refreshToken = localStorage.getItem('refreshToken');
+    refreshRequest = (refreshToken
+      ? axios
+          .post(`${API_BASE_URL}/token/refresh`, { refresh_token: refreshToken }, { withCredentials: true })
+          .then((response) => {
+            const session: AuthResponse = response.data.data;
+            storeSession(session);
+            return session.token;
+          })
+      : Promise.reject(new Error('No refresh token'))
+    ).finally(() => {
+      refreshRequest = null;
+    });
+  }
+  return refreshRequest;
+};
+
+type RetriableRequestConfig = InternalAxiosRequestConfig & { _retried?: boolean };
+
 api.interceptors.response.use(
   (response) => response,
-  (error) => {
+  async (error) => {
+    const request = error.config as RetriableRequestConfig | undefined;
+
     if (error.response?.status === 401) {
-      localStorage.removeItem('token');
-      localStorage.removeItem('user');
+      if (request && !request._retried && !NO_REFRESH_PATHS.includes(request.url ?? '')) {
+        request._retried = true;
+        try {
+          const token = await refreshAccessToken();
+          request.headers.Authorization = `Bearer ${token}`;
+          return api(request);
+        } catch {
+          // Fall through and sign out below.
+        }
+      }
+      clearSession();
       window.location.href = '/sign-in';
     }
     
@@ -35,6 +87,7 @@ api.interceptors.response.use(
         response: error.response,
         code: error.response.data.code,
         details: error.response.data.details,
+        violations: error.response.data.violations,
       });
       return Promise.reject(enhancedError);
     }
@@ -56,10 +109,10 @@ export const authService = {
 
   logout: async (): Promise<void> => {
     try {
-      await api.post('/logout');
+      // Sending the refresh token revokes it along with the access token.
+      await api.post('/logout', { refresh_token: localStorage.getItem('refreshToken') ?? '' });
     } finally {
-      localStorage.removeItem('token');
-      localStorage.removeItem('user');
+      clearSession();
     }
   },
 
@@ -21,7 +21,7 @@ export interface Product {
 }
 
 export interface LoginRequest {
-  username: string;
+  identifier: string;
   password: string;
 }
 
@@ -34,6 +34,8 @@ export interface RegisterRequest {
 
 export interface AuthResponse {
   token: string;
+  refresh_token: string;
+  expires_in: number;
   user: User;
   message: string;
 }
@@ -49,10 +51,16 @@ export interface SuccessResponse<T = any> {
   data?: T;
 }
 
+export interface Violation {
+  rule: string;
+  message: string;
+}
+
 export interface ErrorResponse {
   error: string;
   code?: string;
   details?: string;
+  violations?: Violation[];
 }
 
 export interface ProductsResponse {